@@ -5,12 +5,30 @@ import (
 	"os"
 	"strconv"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/albar2305/payment-app/utils/common"
 )
 
+const (
+	AppEnvDevelopment = "development"
+	AppEnvTest        = "test"
+	AppEnvProduction  = "production"
+
+	// GatewayProviderSimulator settles charges only when told to through the
+	// simulator routes, which exist in development and test only.
+	GatewayProviderSimulator = "simulator"
+)
+
 type ApiConfig struct {
 	ApiPort string
+	AppEnv  string
+}
+
+// DevMode reports whether the app runs in development or test, where
+// simulators of outside services are exposed.
+func (c ApiConfig) DevMode() bool {
+	return c.AppEnv == AppEnvDevelopment || c.AppEnv == AppEnvTest
 }
 
 type DbConfig struct {
@@ -32,11 +50,73 @@ type TokenConfig struct {
 	TokenSymetricKey     string
 }
 
+type IdempotencyConfig struct {
+	IdempotencyKeyTTL time.Duration
+}
+
+type PaymentConfig struct {
+	AuthorizationTTL         time.Duration
+	SubscriptionMaxRetries   int
+	SubscriptionRetryBackoff time.Duration
+}
+
+type SettlementConfig struct {
+	SettlementPath string
+}
+
+type GatewayConfig struct {
+	GatewayProvider      string
+	GatewayWebhookSecret string
+	ApiBaseURL           string
+	TopUpOrderTTL        time.Duration
+}
+
+type QRConfig struct {
+	QRMerchantCity string
+	QRCountryCode  string
+}
+
+type LimitConfig struct {
+	LimitLocation *time.Location
+}
+
+type FraudConfig struct {
+	FraudLocation *time.Location
+}
+
+type PromoConfig struct {
+	PromoCashbackDelay time.Duration
+}
+
+type DisputeConfig struct {
+	DisputeFilingWindow   time.Duration
+	DisputeResponseWindow time.Duration
+}
+
+type StatementConfig struct {
+	StatementLocation *time.Location
+}
+
+type ReportConfig struct {
+	ReportLocation *time.Location
+}
+
 type Config struct {
 	ApiConfig
 	DbConfig
 	FileConfig
 	TokenConfig
+	IdempotencyConfig
+	PaymentConfig
+	SettlementConfig
+	GatewayConfig
+	QRConfig
+	LimitConfig
+	FraudConfig
+	PromoConfig
+	DisputeConfig
+	StatementConfig
+	ReportConfig
 }
 
 // Method
@@ -55,8 +135,18 @@ func (c *Config) ReadConfig() error {
 		Driver:   os.Getenv("DB_DRIVER"),
 	}
 
+	appEnv := os.Getenv("APP_ENV")
+	switch appEnv {
+	case "":
+		appEnv = AppEnvProduction
+	case AppEnvDevelopment, AppEnvTest, AppEnvProduction:
+	default:
+		return fmt.Errorf("unknown APP_ENV %q", appEnv)
+	}
+
 	c.ApiConfig = ApiConfig{
 		ApiPort: os.Getenv("API_PORT"),
+		AppEnv:  appEnv,
 	}
 
 	c.FileConfig = FileConfig{
@@ -81,14 +171,205 @@ func (c *Config) ReadConfig() error {
 		TokenSymetricKey:     os.Getenv("TOKEN_SYMMETRIC_KEY"),
 	}
 
+	idempotencyKeyTTL := 24 * time.Hour
+	if v := os.Getenv("IDEMPOTENCY_KEY_TTL"); v != "" {
+		appIdempotencyKeyTTL, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		idempotencyKeyTTL = time.Duration(appIdempotencyKeyTTL) * time.Hour
+	}
+
+	c.IdempotencyConfig = IdempotencyConfig{
+		IdempotencyKeyTTL: idempotencyKeyTTL,
+	}
+
+	authorizationTTL := 7 * 24 * time.Hour
+	if v := os.Getenv("AUTHORIZATION_TTL"); v != "" {
+		appAuthorizationTTL, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		authorizationTTL = time.Duration(appAuthorizationTTL) * time.Hour
+	}
+
+	subscriptionMaxRetries := 4
+	if v := os.Getenv("SUBSCRIPTION_MAX_RETRIES"); v != "" {
+		appSubscriptionMaxRetries, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		subscriptionMaxRetries = appSubscriptionMaxRetries
+	}
+
+	subscriptionRetryBackoff := time.Hour
+	if v := os.Getenv("SUBSCRIPTION_RETRY_BACKOFF"); v != "" {
+		appSubscriptionRetryBackoff, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		subscriptionRetryBackoff = time.Duration(appSubscriptionRetryBackoff) * time.Minute
+	}
+
+	c.PaymentConfig = PaymentConfig{
+		AuthorizationTTL:         authorizationTTL,
+		SubscriptionMaxRetries:   subscriptionMaxRetries,
+		SubscriptionRetryBackoff: subscriptionRetryBackoff,
+	}
+
+	settlementPath := os.Getenv("SETTLEMENT_PATH")
+	if settlementPath == "" {
+		settlementPath = "settlements"
+	}
+
+	c.SettlementConfig = SettlementConfig{
+		SettlementPath: settlementPath,
+	}
+
+	topUpOrderTTL := time.Hour
+	if v := os.Getenv("TOP_UP_ORDER_TTL"); v != "" {
+		appTopUpOrderTTL, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		topUpOrderTTL = time.Duration(appTopUpOrderTTL) * time.Minute
+	}
+
+	apiBaseURL := os.Getenv("API_BASE_URL")
+	if apiBaseURL == "" {
+		apiBaseURL = fmt.Sprintf("http://localhost:%s", c.ApiPort)
+	}
+
+	gatewayProvider := os.Getenv("GATEWAY_PROVIDER")
+	switch gatewayProvider {
+	case "":
+		gatewayProvider = GatewayProviderSimulator
+	case GatewayProviderSimulator:
+	default:
+		return fmt.Errorf("unknown GATEWAY_PROVIDER %q", gatewayProvider)
+	}
+
+	c.GatewayConfig = GatewayConfig{
+		GatewayProvider:      gatewayProvider,
+		GatewayWebhookSecret: os.Getenv("GATEWAY_WEBHOOK_SECRET"),
+		ApiBaseURL:           apiBaseURL,
+		TopUpOrderTTL:        topUpOrderTTL,
+	}
+
+	qrMerchantCity := os.Getenv("QR_MERCHANT_CITY")
+	if qrMerchantCity == "" {
+		qrMerchantCity = "JAKARTA"
+	}
+
+	qrCountryCode := os.Getenv("QR_COUNTRY_CODE")
+	if qrCountryCode == "" {
+		qrCountryCode = "ID"
+	}
+
+	c.QRConfig = QRConfig{
+		QRMerchantCity: qrMerchantCity,
+		QRCountryCode:  qrCountryCode,
+	}
+
+	limitLocation, err := loadLocation("LIMIT_TIME_ZONE")
+	if err != nil {
+		return err
+	}
+
+	c.LimitConfig = LimitConfig{
+		LimitLocation: limitLocation,
+	}
+
+	fraudLocation, err := loadLocation("FRAUD_TIME_ZONE")
+	if err != nil {
+		return err
+	}
+
+	c.FraudConfig = FraudConfig{
+		FraudLocation: fraudLocation,
+	}
+
+	promoCashbackDelay := 24 * time.Hour
+	if v := os.Getenv("PROMO_CASHBACK_DELAY"); v != "" {
+		appPromoCashbackDelay, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		promoCashbackDelay = time.Duration(appPromoCashbackDelay) * time.Hour
+	}
+
+	c.PromoConfig = PromoConfig{
+		PromoCashbackDelay: promoCashbackDelay,
+	}
+
+	disputeFilingWindow := 120 * 24 * time.Hour
+	if v := os.Getenv("DISPUTE_FILING_DAYS"); v != "" {
+		appDisputeFilingDays, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		disputeFilingWindow = time.Duration(appDisputeFilingDays) * 24 * time.Hour
+	}
+
+	disputeResponseWindow := 7 * 24 * time.Hour
+	if v := os.Getenv("DISPUTE_RESPONSE_DAYS"); v != "" {
+		appDisputeResponseDays, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		disputeResponseWindow = time.Duration(appDisputeResponseDays) * 24 * time.Hour
+	}
+
+	c.DisputeConfig = DisputeConfig{
+		DisputeFilingWindow:   disputeFilingWindow,
+		DisputeResponseWindow: disputeResponseWindow,
+	}
+
+	statementLocation, err := loadLocation("STATEMENT_TIME_ZONE")
+	if err != nil {
+		return err
+	}
+
+	c.StatementConfig = StatementConfig{
+		StatementLocation: statementLocation,
+	}
+
+	reportLocation, err := loadLocation("REPORT_TIME_ZONE")
+	if err != nil {
+		return err
+	}
+
+	c.ReportConfig = ReportConfig{
+		ReportLocation: reportLocation,
+	}
+
 	if c.DbConfig.Host == "" || c.DbConfig.Port == "" || c.DbConfig.Name == "" ||
 		c.DbConfig.User == "" || c.DbConfig.Password == "" || c.DbConfig.Driver == "" ||
-		c.ApiConfig.ApiPort == "" || c.FileConfig.FilePath == "" {
+		c.ApiConfig.ApiPort == "" || c.FileConfig.FilePath == "" || c.GatewayConfig.GatewayWebhookSecret == "" {
 		return fmt.Errorf("missing required environment variables")
 	}
 	return nil
 }
 
+// defaultLocation is the time zone days and months are counted in unless
+// configured otherwise. The app serves Indonesian wallets and merchants, so
+// it is Western Indonesia Time, where Jakarta is.
+const defaultLocation = "Asia/Jakarta"
+
+// loadLocation reads the time zone named by the environment variable
+// envKey, defaultLocation when it is not set.
+func loadLocation(envKey string) (*time.Location, error) {
+	name := os.Getenv(envKey)
+	if name == "" {
+		name = defaultLocation
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", envKey, err)
+	}
+	return loc, nil
+}
+
 // constructor
 func NewConfig() (*Config, error) {
 	cfg := &Config{}
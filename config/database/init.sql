@@ -16,6 +16,11 @@ CREATE TABLE customers (
     user_id VARCHAR (255) references users(id),
     name VARCHAR (255) NOT NULL,
     balance BIGINT NOT NULL,
+    held_amount BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR (3) NOT NULL DEFAULT 'IDR',
+    tier VARCHAR (50) NOT NULL DEFAULT 'basic',
+    frozen BOOLEAN NOT NULL DEFAULT false,
+    frozen_incoming BOOLEAN NOT NULL DEFAULT false,
     created_at timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -25,6 +30,10 @@ CREATE TABLE merchants (
     description TEXT,
     business_type VARCHAR (255),
     balance BIGINT NOT NULL,
+    held_amount BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR (3) NOT NULL DEFAULT 'IDR',
+    frozen BOOLEAN NOT NULL DEFAULT false,
+    frozen_incoming BOOLEAN NOT NULL DEFAULT false,
     created_at timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -33,5 +42,485 @@ CREATE TABLE transactions (
     sender_customer_id VARCHAR REFERENCES customers (id),
     receiver_merchant_id VARCHAR REFERENCES merchants (id),
     amount BIGINT NOT NULL,
+    currency VARCHAR (3) NOT NULL DEFAULT 'IDR',
+    source_amount BIGINT NOT NULL,
+    source_currency VARCHAR (3) NOT NULL DEFAULT 'IDR',
+    fx_rate NUMERIC (20, 10),
+    refunded_amount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR (50) NOT NULL DEFAULT 'captured',
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    fee BIGINT NOT NULL DEFAULT 0,
+    discount BIGINT NOT NULL DEFAULT 0,
+    invoice_id VARCHAR,
+    parent_id VARCHAR REFERENCES transactions (id),
+    expires_at timestamptz,
     created_at timestamptz NOT NULL DEFAULT (now())
 );
+
+CREATE INDEX transactions_parent_id_idx ON transactions (parent_id) WHERE parent_id IS NOT NULL;
+
+CREATE INDEX transactions_authorized_expires_at_idx ON transactions (expires_at) WHERE status = 'authorized';
+
+CREATE TABLE ledger_accounts (
+    id VARCHAR PRIMARY KEY,
+    owner_type VARCHAR (50) NOT NULL,
+    owner_id VARCHAR (255) NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE (owner_type, owner_id, currency)
+);
+
+CREATE TABLE journal_entries (
+    id VARCHAR PRIMARY KEY,
+    kind VARCHAR (50) NOT NULL,
+    reference_id VARCHAR (255),
+    description TEXT,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE ledger_postings (
+    id VARCHAR PRIMARY KEY,
+    journal_entry_id VARCHAR NOT NULL REFERENCES journal_entries (id),
+    account_id VARCHAR NOT NULL REFERENCES ledger_accounts (id),
+    amount BIGINT NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ledger_postings_account_id_idx ON ledger_postings (account_id);
+CREATE INDEX ledger_postings_journal_entry_id_idx ON ledger_postings (journal_entry_id);
+
+-- every journal entry must balance to zero in each currency once its transaction commits
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM ledger_postings p
+        JOIN ledger_accounts a ON a.id = p.account_id
+        WHERE p.journal_entry_id = NEW.journal_entry_id
+        GROUP BY a.currency
+        HAVING SUM(p.amount) <> 0) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+CREATE TABLE idempotency_keys (
+    key VARCHAR (255) NOT NULL,
+    user_id VARCHAR (255) NOT NULL,
+    request_path VARCHAR (255) NOT NULL,
+    request_hash VARCHAR (64) NOT NULL,
+    status_code INT,
+    response_body TEXT,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE TABLE refunds (
+    id VARCHAR PRIMARY KEY,
+    transaction_id VARCHAR NOT NULL REFERENCES transactions (id),
+    amount BIGINT NOT NULL,
+    fee BIGINT NOT NULL DEFAULT 0,
+    source_amount BIGINT NOT NULL DEFAULT 0,
+    reason TEXT,
+    created_by VARCHAR (255) REFERENCES users (id),
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX refunds_transaction_id_idx ON refunds (transaction_id);
+
+CREATE TABLE transfers (
+    id VARCHAR PRIMARY KEY,
+    sender_customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    receiver_customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    amount BIGINT NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    note TEXT,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX transfers_sender_customer_id_idx ON transfers (sender_customer_id);
+CREATE INDEX transfers_receiver_customer_id_idx ON transfers (receiver_customer_id);
+
+-- 1 unit of base_currency is worth rate units of quote_currency
+CREATE TABLE fx_rates (
+    base_currency VARCHAR (3) NOT NULL,
+    quote_currency VARCHAR (3) NOT NULL,
+    rate NUMERIC (20, 10) NOT NULL CHECK (rate > 0),
+    updated_by VARCHAR (255) REFERENCES users (id),
+    updated_at timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY (base_currency, quote_currency)
+);
+
+CREATE TABLE settlement_batches (
+    id VARCHAR PRIMARY KEY,
+    settlement_date DATE NOT NULL,
+    status VARCHAR (50) NOT NULL,
+    payout_count INT NOT NULL DEFAULT 0,
+    file_path VARCHAR (255),
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    completed_at timestamptz
+);
+
+CREATE TABLE payouts (
+    id VARCHAR PRIMARY KEY,
+    merchant_id VARCHAR NOT NULL REFERENCES merchants (id),
+    amount BIGINT NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    bank_code VARCHAR (50) NOT NULL,
+    account_number VARCHAR (50) NOT NULL,
+    account_name VARCHAR (255) NOT NULL,
+    status VARCHAR (50) NOT NULL,
+    batch_id VARCHAR REFERENCES settlement_batches (id),
+    provider_reference VARCHAR (255),
+    failure_reason TEXT,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX payouts_merchant_id_idx ON payouts (merchant_id);
+CREATE INDEX payouts_batch_id_idx ON payouts (batch_id);
+CREATE INDEX payouts_pending_created_at_idx ON payouts (created_at) WHERE status = 'pending';
+
+-- a rule applies to one merchant or to every merchant of a business type;
+-- the merchant rule wins when both exist
+CREATE TABLE fee_rules (
+    id VARCHAR PRIMARY KEY,
+    merchant_id VARCHAR REFERENCES merchants (id),
+    business_type VARCHAR (255),
+    percentage_bps INT NOT NULL DEFAULT 0,
+    fixed_fee BIGINT NOT NULL DEFAULT 0,
+    min_fee BIGINT NOT NULL DEFAULT 0,
+    max_fee BIGINT NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now()),
+    CHECK ((merchant_id IS NULL) <> (business_type IS NULL))
+);
+
+CREATE UNIQUE INDEX fee_rules_merchant_id_key ON fee_rules (merchant_id) WHERE merchant_id IS NOT NULL;
+CREATE UNIQUE INDEX fee_rules_business_type_key ON fee_rules (business_type) WHERE business_type IS NOT NULL;
+
+CREATE TABLE fee_rule_tiers (
+    fee_rule_id VARCHAR NOT NULL REFERENCES fee_rules (id) ON DELETE CASCADE,
+    min_monthly_volume BIGINT NOT NULL,
+    percentage_bps INT NOT NULL DEFAULT 0,
+    fixed_fee BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (fee_rule_id, min_monthly_volume)
+);
+
+CREATE TABLE reconciliation_reports (
+    id VARCHAR PRIMARY KEY,
+    wallets_checked INT NOT NULL,
+    mismatch_count INT NOT NULL,
+    started_at timestamptz NOT NULL,
+    finished_at timestamptz NOT NULL
+);
+
+-- records holds the business records whose ledger postings disagree, as JSON
+CREATE TABLE reconciliation_mismatches (
+    id VARCHAR PRIMARY KEY,
+    report_id VARCHAR NOT NULL REFERENCES reconciliation_reports (id) ON DELETE CASCADE,
+    owner_type VARCHAR (50) NOT NULL,
+    owner_id VARCHAR (255) NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    stored_balance BIGINT NOT NULL,
+    expected_balance BIGINT NOT NULL,
+    ledger_balance BIGINT NOT NULL,
+    records JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX reconciliation_mismatches_report_id_idx ON reconciliation_mismatches (report_id);
+
+CREATE TABLE top_up_orders (
+    id VARCHAR PRIMARY KEY,
+    customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    amount BIGINT NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    status VARCHAR (50) NOT NULL,
+    gateway_reference VARCHAR (255) UNIQUE,
+    checkout_url TEXT,
+    failure_reason TEXT,
+    expires_at timestamptz NOT NULL,
+    paid_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX top_up_orders_customer_id_idx ON top_up_orders (customer_id);
+CREATE INDEX top_up_orders_pending_expires_at_idx ON top_up_orders (expires_at) WHERE status = 'pending';
+
+CREATE TABLE subscriptions (
+    id VARCHAR PRIMARY KEY,
+    customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    merchant_id VARCHAR NOT NULL REFERENCES merchants (id),
+    interval VARCHAR (20) NOT NULL,
+    amount_type VARCHAR (20) NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    max_amount BIGINT NOT NULL DEFAULT 0,
+    next_amount BIGINT,
+    status VARCHAR (50) NOT NULL,
+    started_at timestamptz NOT NULL,
+    next_charge_at timestamptz NOT NULL,
+    retry_at timestamptz,
+    retry_count INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX subscriptions_customer_id_idx ON subscriptions (customer_id);
+-- next_charge_at is the billing date of the current period; retry_at delays
+-- a failed charge without moving it
+CREATE INDEX subscriptions_active_due_at_idx ON subscriptions (COALESCE(retry_at, next_charge_at)) WHERE status = 'active';
+
+CREATE TABLE subscription_charges (
+    id VARCHAR PRIMARY KEY,
+    subscription_id VARCHAR NOT NULL REFERENCES subscriptions (id),
+    transaction_id VARCHAR REFERENCES transactions (id),
+    amount BIGINT NOT NULL,
+    status VARCHAR (50) NOT NULL,
+    attempt INT NOT NULL,
+    failure_reason TEXT,
+    -- the billing date the subscription moves to when the charge succeeds
+    next_charge_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX subscription_charges_subscription_id_idx ON subscription_charges (subscription_id);
+CREATE INDEX subscription_charges_transaction_id_idx ON subscription_charges (transaction_id);
+
+CREATE TABLE invoices (
+    id VARCHAR PRIMARY KEY,
+    merchant_id VARCHAR NOT NULL REFERENCES merchants (id),
+    code VARCHAR (20) NOT NULL UNIQUE,
+    amount BIGINT NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR (50) NOT NULL,
+    transaction_id VARCHAR UNIQUE REFERENCES transactions (id),
+    paid_by_customer_id VARCHAR REFERENCES customers (id),
+    expires_at timestamptz NOT NULL,
+    paid_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX invoices_merchant_id_idx ON invoices (merchant_id, created_at);
+CREATE INDEX invoices_open_expires_at_idx ON invoices (expires_at) WHERE status = 'open';
+
+CREATE TABLE invoice_items (
+    id VARCHAR PRIMARY KEY,
+    invoice_id VARCHAR NOT NULL REFERENCES invoices (id),
+    position INT NOT NULL,
+    description TEXT NOT NULL,
+    quantity BIGINT NOT NULL,
+    unit_price BIGINT NOT NULL,
+    amount BIGINT NOT NULL
+);
+
+CREATE INDEX invoice_items_invoice_id_idx ON invoice_items (invoice_id, position);
+
+-- A limit row holds the defaults of a customer tier in one currency, or the
+-- override of one customer. NULL columns mean no limit; an override column
+-- left NULL falls back to the tier.
+CREATE TABLE spending_limits (
+    id VARCHAR PRIMARY KEY,
+    customer_id VARCHAR REFERENCES customers (id) ON DELETE CASCADE,
+    tier VARCHAR (50),
+    currency VARCHAR (3) NOT NULL,
+    max_single_amount BIGINT,
+    daily_amount BIGINT,
+    monthly_amount BIGINT,
+    hourly_count INT,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now()),
+    CHECK ((customer_id IS NULL) <> (tier IS NULL))
+);
+
+CREATE UNIQUE INDEX spending_limits_customer_id_key ON spending_limits (customer_id) WHERE customer_id IS NOT NULL;
+CREATE UNIQUE INDEX spending_limits_tier_key ON spending_limits (tier, currency) WHERE tier IS NOT NULL;
+
+INSERT INTO spending_limits (id, tier, currency, max_single_amount, daily_amount, monthly_amount, hourly_count) VALUES
+    ('basic-idr', 'basic', 'IDR', 2000000, 5000000, 20000000, 20),
+    ('verified-idr', 'verified', 'IDR', 10000000, 20000000, 100000000, 60);
+
+-- Fraud rules are fixed; admins tune their thresholds and actions. Amounts are
+-- in the minor unit of IDR.
+CREATE TABLE fraud_rules (
+    rule VARCHAR (50) PRIMARY KEY,
+    action VARCHAR (50) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    min_amount BIGINT NOT NULL DEFAULT 0,
+    account_age_hours INT NOT NULL DEFAULT 0,
+    window_minutes INT NOT NULL DEFAULT 0,
+    max_count INT NOT NULL DEFAULT 0,
+    limit_percent INT NOT NULL DEFAULT 0,
+    start_hour INT NOT NULL DEFAULT 0,
+    end_hour INT NOT NULL DEFAULT 0,
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+INSERT INTO fraud_rules (rule, action, min_amount, account_age_hours, window_minutes, max_count, limit_percent, start_hour, end_hour) VALUES
+    ('new_account_large_amount', 'review', 1000000, 24, 0, 0, 0, 0, 0),
+    ('rapid_repeat_merchant', 'block', 0, 0, 10, 5, 0, 0, 0),
+    ('near_limit', 'review', 0, 0, 0, 0, 90, 0, 0),
+    ('unusual_hours', 'review', 500000, 0, 0, 0, 0, 0, 5);
+
+CREATE TABLE fraud_reviews (
+    id VARCHAR PRIMARY KEY,
+    transaction_id VARCHAR NOT NULL UNIQUE REFERENCES transactions (id),
+    customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    reasons JSONB NOT NULL,
+    status VARCHAR (50) NOT NULL DEFAULT 'pending',
+    note TEXT NOT NULL DEFAULT '',
+    reviewed_by VARCHAR,
+    reviewed_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX fraud_reviews_status_created_at_idx ON fraud_reviews (status, created_at);
+
+-- Freezes and holds placed by support on customer and merchant wallets. Rows
+-- are never deleted so they keep the audit trail; the frozen flags and
+-- held_amount of the wallet follow the active ones.
+CREATE TABLE wallet_freezes (
+    id VARCHAR PRIMARY KEY,
+    owner_type VARCHAR (50) NOT NULL,
+    owner_id VARCHAR NOT NULL,
+    block_incoming BOOLEAN NOT NULL DEFAULT false,
+    reason TEXT NOT NULL,
+    frozen_by VARCHAR NOT NULL,
+    lift_reason TEXT,
+    lifted_by VARCHAR,
+    lifted_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX wallet_freezes_active_owner_key ON wallet_freezes (owner_type, owner_id) WHERE lifted_at IS NULL;
+CREATE INDEX wallet_freezes_owner_idx ON wallet_freezes (owner_type, owner_id, created_at);
+
+CREATE TABLE wallet_holds (
+    id VARCHAR PRIMARY KEY,
+    owner_type VARCHAR (50) NOT NULL,
+    owner_id VARCHAR NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR (3) NOT NULL,
+    reason TEXT NOT NULL,
+    created_by VARCHAR NOT NULL,
+    release_reason TEXT,
+    released_by VARCHAR,
+    released_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX wallet_holds_owner_idx ON wallet_holds (owner_type, owner_id, created_at);
+
+-- A discount lowers the amount of the payment, so the merchant funds it.
+-- Cashback is paid by the platform from the promotions account.
+CREATE TABLE promo_campaigns (
+    id VARCHAR PRIMARY KEY,
+    code VARCHAR (50) NOT NULL UNIQUE,
+    name VARCHAR (255) NOT NULL,
+    reward_type VARCHAR (50) NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    percentage_bps BIGINT NOT NULL DEFAULT 0,
+    fixed_amount BIGINT NOT NULL DEFAULT 0,
+    max_reward BIGINT NOT NULL DEFAULT 0,
+    min_spend BIGINT NOT NULL DEFAULT 0,
+    max_uses BIGINT NOT NULL DEFAULT 0,
+    max_uses_per_customer BIGINT NOT NULL DEFAULT 0,
+    used_count BIGINT NOT NULL DEFAULT 0,
+    merchant_ids VARCHAR[] NOT NULL DEFAULT '{}',
+    business_types VARCHAR[] NOT NULL DEFAULT '{}',
+    starts_at timestamptz NOT NULL,
+    ends_at timestamptz,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE promo_redemptions (
+    id VARCHAR PRIMARY KEY,
+    campaign_id VARCHAR NOT NULL REFERENCES promo_campaigns (id),
+    customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    transaction_id VARCHAR NOT NULL UNIQUE REFERENCES transactions (id),
+    reward_type VARCHAR (50) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR (3) NOT NULL,
+    credited_amount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR (50) NOT NULL,
+    credited_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX promo_redemptions_campaign_customer_idx ON promo_redemptions (campaign_id, customer_id);
+CREATE INDEX promo_redemptions_pending_created_at_idx ON promo_redemptions (created_at) WHERE status = 'pending';
+
+-- won and lost are from the customer's side; a won dispute is charged back
+-- to the customer as a refund.
+CREATE TABLE disputes (
+    id VARCHAR PRIMARY KEY,
+    transaction_id VARCHAR NOT NULL UNIQUE REFERENCES transactions (id),
+    customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    merchant_id VARCHAR NOT NULL REFERENCES merchants (id),
+    reason VARCHAR (50) NOT NULL,
+    description TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR (3) NOT NULL,
+    status VARCHAR (50) NOT NULL DEFAULT 'open',
+    merchant_response TEXT,
+    resolution_note TEXT,
+    resolved_by VARCHAR (255),
+    refund_id VARCHAR REFERENCES refunds (id),
+    response_due_at timestamptz NOT NULL,
+    responded_at timestamptz,
+    resolved_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    updated_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX disputes_customer_id_idx ON disputes (customer_id, created_at);
+CREATE INDEX disputes_open_response_due_at_idx ON disputes (response_due_at) WHERE status = 'open';
+
+CREATE TABLE dispute_evidence (
+    id VARCHAR PRIMARY KEY,
+    dispute_id VARCHAR NOT NULL REFERENCES disputes (id),
+    party VARCHAR (50) NOT NULL,
+    file_name VARCHAR (255) NOT NULL,
+    content_type VARCHAR (255) NOT NULL,
+    size BIGINT NOT NULL,
+    description TEXT,
+    uploaded_by VARCHAR (255) NOT NULL,
+    path VARCHAR NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX dispute_evidence_dispute_id_idx ON dispute_evidence (dispute_id, created_at);
+
+CREATE INDEX ledger_postings_account_id_created_at_idx ON ledger_postings (account_id, created_at);
+
+-- Monthly statements generated in the background. period_end is exclusive.
+CREATE TABLE statements (
+    id VARCHAR PRIMARY KEY,
+    customer_id VARCHAR NOT NULL REFERENCES customers (id),
+    currency VARCHAR (3) NOT NULL,
+    period_start timestamptz NOT NULL,
+    period_end timestamptz NOT NULL,
+    opening_balance BIGINT NOT NULL,
+    total_in BIGINT NOT NULL,
+    total_out BIGINT NOT NULL,
+    closing_balance BIGINT NOT NULL,
+    csv_path VARCHAR NOT NULL,
+    pdf_path VARCHAR NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE (customer_id, period_start, period_end)
+);
+
+-- Sales reports scan the payments and refunds of a period.
+CREATE INDEX transactions_receiver_merchant_id_created_at_idx ON transactions (receiver_merchant_id, created_at);
+CREATE INDEX transactions_created_at_idx ON transactions (created_at);
+CREATE INDEX refunds_created_at_idx ON refunds (created_at);
@@ -0,0 +1,36 @@
+package delievery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RunCommand runs a one-off command instead of the HTTP server.
+func (s *Server) RunCommand(args []string) error {
+	switch args[0] {
+	case "reconcile":
+		return s.reconcile()
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// reconcile prints a fresh reconciliation report and fails when any wallet
+// does not match, so it can gate the month-end close in scripts.
+func (s *Server) reconcile() error {
+	report, err := s.useCaseManager.ReconciliationUseCase().RunReconciliation()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if report.MismatchCount > 0 {
+		return fmt.Errorf("%d of %d wallets do not reconcile", report.MismatchCount, report.WalletsChecked)
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package controller
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/albar2305/payment-app/config"
 	"github.com/albar2305/payment-app/delievery/middleware"
@@ -30,16 +29,17 @@ func (u *CustomerController) createCustomerHandler(c *gin.Context) {
 
 	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
 	arg := model.CreateCustomerRequest{
-		UserID:  authPayload.ID,
-		Name:    req.Name,
-		Balance: 0,
+		UserID:   authPayload.ID,
+		Name:     req.Name,
+		Balance:  0,
+		Currency: req.Currency,
 	}
 
 	fmt.Println(authPayload.ID)
 
 	customer, err := u.customerUC.RegisterNewCustomer(arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
@@ -70,25 +70,21 @@ func (u *CustomerController) getCustomerHandler(c *gin.Context) {
 }
 
 func (u *CustomerController) listCustomerHandler(c *gin.Context) {
-	page, _ := strconv.Atoi(c.Query("page"))
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	if page == 0 || limit == 0 {
-		page = 1
-		limit = 5
-	}
-
-	arg := model.PaginationParams{
-		Limit:  int32(limit),
-		Offset: int32((page - 1) * limit),
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
 	customers, err := u.customerUC.ListCustomer(arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, customers)
+	writePage(c, arg, customers, func(customer model.CustomerResponse) model.Cursor {
+		return model.CreatedAtCursor(customer.CreatedAt, customer.ID)
+	})
 }
 
 func (u *CustomerController) deleteCustomerHandler(c *gin.Context) {
@@ -107,23 +103,6 @@ func (u *CustomerController) deleteCustomerHandler(c *gin.Context) {
 	c.JSON(http.StatusNoContent, "")
 }
 
-func (u *CustomerController) addCustomerBalanceHandler(c *gin.Context) {
-	var req model.AddCustomerBalanceParams
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
-		return
-	}
-
-	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
-	customerResponse, err := u.customerUC.AddCustomerBalance(authPayload.ID, req.Amount)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
-		return
-	}
-
-	c.JSON(http.StatusOK, customerResponse)
-}
-
 func NewCustomerController(r *gin.Engine, usecase usecase.CustomerUseCase, cfg *config.Config) *CustomerController {
 	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
 	controller := CustomerController{
@@ -135,10 +114,9 @@ func NewCustomerController(r *gin.Engine, usecase usecase.CustomerUseCase, cfg *
 
 	rg := r.Group("/api/v1")
 	rg.POST("/customers", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.createCustomerHandler)
-	rg.GET("/customers/:name", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getCustomerHandler)
+	rg.GET("/customers/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getCustomerHandler)
 	rg.DELETE("/customers/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.deleteCustomerHandler)
 	rg.GET("/customers", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listCustomerHandler)
-	rg.POST("/customers/top-up", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.addCustomerBalanceHandler)
 
 	return &controller
 }
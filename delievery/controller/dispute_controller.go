@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type DisputeController struct {
+	router    *gin.Engine
+	disputeUC usecase.DisputeUseCase
+	maker     token.Maker
+	cfg       *config.Config
+}
+
+func (d *DisputeController) openDisputeHandler(c *gin.Context) {
+	var uri transactionUriRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	var req model.OpenDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	req.TransactionID = uri.ID
+	req.UserID = authPayload.ID
+
+	dispute, err := d.disputeUC.OpenDispute(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// ownerId returns the user whose disputes the caller may act on, or "" for
+// admins.
+func (d *DisputeController) ownerId(c *gin.Context) string {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == "admin" {
+		return ""
+	}
+	return authPayload.ID
+}
+
+func (d *DisputeController) listDisputeHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	disputes, err := d.disputeUC.ListDisputes(d.ownerId(c), c.Query("status"), arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, disputes, func(dispute model.Dispute) model.Cursor {
+		return model.CreatedAtCursor(dispute.CreatedAt, dispute.ID)
+	})
+}
+
+func (d *DisputeController) getDisputeHandler(c *gin.Context) {
+	dispute, err := d.disputeUC.GetDispute(d.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+func (d *DisputeController) respondDisputeHandler(c *gin.Context) {
+	var req model.RespondDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	dispute, err := d.disputeUC.RespondDispute(c.Param("id"), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+func (d *DisputeController) reviewDisputeHandler(c *gin.Context) {
+	dispute, err := d.disputeUC.ReviewDispute(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+func (d *DisputeController) resolveDisputeHandler(c *gin.Context) {
+	var req model.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	dispute, err := d.disputeUC.ResolveDispute(c.Param("id"), authPayload.ID, req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// uploadEvidenceHandler takes a multipart form with the evidence in "file"
+// and an optional "description". Customers always upload for their own side;
+// admins name the side in "party".
+func (d *DisputeController) uploadEvidenceHandler(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	party := model.DisputePartyCustomer
+	if authPayload.Role == "admin" {
+		party = c.PostForm("party")
+	}
+
+	evidence, err := d.disputeUC.AddEvidence(d.ownerId(c), model.DisputeEvidence{
+		DisputeID:   c.Param("id"),
+		Party:       party,
+		FileName:    header.Filename,
+		Description: c.PostForm("description"),
+		UploadedBy:  authPayload.ID,
+	}, file)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, evidence)
+}
+
+func (d *DisputeController) downloadEvidenceHandler(c *gin.Context) {
+	evidence, err := d.disputeUC.GetEvidence(d.ownerId(c), c.Param("id"), c.Param("evidence_id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.FileAttachment(evidence.Path, evidence.FileName)
+}
+
+func NewDisputeController(r *gin.Engine, usecase usecase.DisputeUseCase, cfg *config.Config) *DisputeController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := DisputeController{
+		router:    r,
+		disputeUC: usecase,
+		maker:     tokenMaker,
+		cfg:       cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/transactions/:id/disputes", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.openDisputeHandler)
+	rg.GET("/disputes", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listDisputeHandler)
+	rg.GET("/disputes/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getDisputeHandler)
+	rg.POST("/disputes/:id/evidence", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.uploadEvidenceHandler)
+	rg.GET("/disputes/:id/evidence/:evidence_id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.downloadEvidenceHandler)
+	rg.POST("/disputes/:id/respond", middleware.AuthMiddleware(tokenMaker, "admin"), controller.respondDisputeHandler)
+	rg.POST("/disputes/:id/review", middleware.AuthMiddleware(tokenMaker, "admin"), controller.reviewDisputeHandler)
+	rg.POST("/disputes/:id/resolve", middleware.AuthMiddleware(tokenMaker, "admin"), controller.resolveDisputeHandler)
+	return &controller
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type FeeController struct {
+	router     *gin.Engine
+	feeUC      usecase.FeeUseCase
+	merchantUC usecase.MerchantUseCase
+	maker      token.Maker
+	cfg        *config.Config
+}
+
+func (f *FeeController) setRuleHandler(c *gin.Context) {
+	var req model.CreateFeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	rule, err := f.feeUC.SetRule(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (f *FeeController) listRuleHandler(c *gin.Context) {
+	rules, err := f.feeUC.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (f *FeeController) getRuleHandler(c *gin.Context) {
+	rule, err := f.feeUC.GetRule(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (f *FeeController) deleteRuleHandler(c *gin.Context) {
+	if err := f.feeUC.DeleteRule(c.Param("id")); err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "")
+}
+
+func (f *FeeController) quoteFeeHandler(c *gin.Context) {
+	amount, err := strconv.ParseInt(c.Query("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(common.ErrInvalidAmount))
+		return
+	}
+
+	merchant, err := f.merchantUC.GetMerchant(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	quote, err := f.feeUC.QuoteFee(merchant, amount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func NewFeeController(r *gin.Engine, usecase usecase.FeeUseCase, merchantUC usecase.MerchantUseCase, cfg *config.Config) *FeeController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := FeeController{
+		router:     r,
+		feeUC:      usecase,
+		merchantUC: merchantUC,
+		maker:      tokenMaker,
+		cfg:        cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.PUT("/fee-rules", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setRuleHandler)
+	rg.GET("/fee-rules", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listRuleHandler)
+	rg.GET("/fee-rules/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getRuleHandler)
+	rg.DELETE("/fee-rules/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.deleteRuleHandler)
+	rg.GET("/merchants/:id/fee-quote", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.quoteFeeHandler)
+	return &controller
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type FraudController struct {
+	router  *gin.Engine
+	fraudUC usecase.FraudUseCase
+	maker   token.Maker
+	cfg     *config.Config
+}
+
+func (f *FraudController) listRuleHandler(c *gin.Context) {
+	rules, err := f.fraudUC.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (f *FraudController) setRuleHandler(c *gin.Context) {
+	var req model.SetFraudRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	rule, err := f.fraudUC.SetRule(c.Param("rule"), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (f *FraudController) listReviewHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	reviews, err := f.fraudUC.ListReviews(c.Query("status"), arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, reviews, func(review model.FraudReview) model.Cursor {
+		return model.CreatedAtCursor(review.CreatedAt, review.ID)
+	})
+}
+
+func (f *FraudController) getReviewHandler(c *gin.Context) {
+	review, err := f.fraudUC.GetReview(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func (f *FraudController) approveReviewHandler(c *gin.Context) {
+	var req model.DecideFraudReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	review, err := f.fraudUC.ApproveReview(c.Param("id"), authPayload.ID, req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func (f *FraudController) rejectReviewHandler(c *gin.Context) {
+	var req model.DecideFraudReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	review, err := f.fraudUC.RejectReview(c.Param("id"), authPayload.ID, req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func NewFraudController(r *gin.Engine, usecase usecase.FraudUseCase, cfg *config.Config) *FraudController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := FraudController{
+		router:  r,
+		fraudUC: usecase,
+		maker:   tokenMaker,
+		cfg:     cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.GET("/fraud-rules", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listRuleHandler)
+	rg.PUT("/fraud-rules/:rule", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setRuleHandler)
+	rg.GET("/fraud-reviews", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listReviewHandler)
+	rg.GET("/fraud-reviews/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getReviewHandler)
+	rg.POST("/fraud-reviews/:id/approve", middleware.AuthMiddleware(tokenMaker, "admin"), controller.approveReviewHandler)
+	rg.POST("/fraud-reviews/:id/reject", middleware.AuthMiddleware(tokenMaker, "admin"), controller.rejectReviewHandler)
+	return &controller
+}
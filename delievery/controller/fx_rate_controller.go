@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type FxRateController struct {
+	router   *gin.Engine
+	fxRateUC usecase.FxRateUseCase
+	maker    token.Maker
+	cfg      *config.Config
+}
+
+func (f *FxRateController) setRateHandler(c *gin.Context) {
+	var req model.SetFxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	req.BaseCurrency = strings.ToUpper(req.BaseCurrency)
+	req.QuoteCurrency = strings.ToUpper(req.QuoteCurrency)
+	req.UpdatedBy = authPayload.ID
+
+	rate, err := f.fxRateUC.SetRate(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+func (f *FxRateController) listRateHandler(c *gin.Context) {
+	rates, err := f.fxRateUC.ListRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+func NewFxRateController(r *gin.Engine, usecase usecase.FxRateUseCase, cfg *config.Config) *FxRateController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := FxRateController{
+		router:   r,
+		fxRateUC: usecase,
+		maker:    tokenMaker,
+		cfg:      cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.PUT("/fx-rates", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setRateHandler)
+	rg.GET("/fx-rates", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listRateHandler)
+	return &controller
+}
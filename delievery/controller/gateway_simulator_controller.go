@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/gateway"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+// GatewaySimulatorController stands in for the gateway checkout page when
+// the simulator gateway is used.
+type GatewaySimulatorController struct {
+	router    *gin.Engine
+	simulator *gateway.Simulator
+	maker     token.Maker
+	cfg       *config.Config
+}
+
+func (g *GatewaySimulatorController) completeChargeHandler(c *gin.Context) {
+	var req model.SimulateChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	event, err := g.simulator.Complete(c.Param("reference"), req.Status)
+	if err != nil {
+		if errors.Is(err, gateway.ErrChargeNotFound) {
+			c.JSON(http.StatusNotFound, common.ErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusBadGateway, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+func NewGatewaySimulatorController(r *gin.Engine, simulator *gateway.Simulator, cfg *config.Config) *GatewaySimulatorController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := GatewaySimulatorController{
+		router:    r,
+		simulator: simulator,
+		maker:     tokenMaker,
+		cfg:       cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/simulator/gateway/charges/:reference", middleware.AuthMiddleware(tokenMaker, "admin"), controller.completeChargeHandler)
+	return &controller
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceController struct {
+	router    *gin.Engine
+	invoiceUC usecase.InvoiceUseCase
+	maker     token.Maker
+	cfg       *config.Config
+}
+
+func (i *InvoiceController) createInvoiceHandler(c *gin.Context) {
+	var req model.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+	req.MerchantID = c.Param("id")
+
+	invoice, err := i.invoiceUC.CreateInvoice(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func (i *InvoiceController) listInvoiceHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	invoices, err := i.invoiceUC.ListInvoices(c.Param("id"), c.Query("status"), arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, invoices, func(invoice model.Invoice) model.Cursor {
+		return model.CreatedAtCursor(invoice.CreatedAt, invoice.ID)
+	})
+}
+
+func (i *InvoiceController) getInvoiceHandler(c *gin.Context) {
+	invoice, err := i.invoiceUC.GetInvoice(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func (i *InvoiceController) cancelInvoiceHandler(c *gin.Context) {
+	invoice, err := i.invoiceUC.CancelInvoice(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func (i *InvoiceController) getPaymentLinkHandler(c *gin.Context) {
+	invoice, err := i.invoiceUC.GetInvoiceByCode(c.Param("code"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func (i *InvoiceController) payInvoiceHandler(c *gin.Context) {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+
+	invoice, err := i.invoiceUC.PayInvoice(authPayload.ID, c.Param("code"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func NewInvoiceController(r *gin.Engine, usecase usecase.InvoiceUseCase, idempotencyUC usecase.IdempotencyUseCase, cfg *config.Config) *InvoiceController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := InvoiceController{
+		router:    r,
+		invoiceUC: usecase,
+		maker:     tokenMaker,
+		cfg:       cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/merchants/:id/invoices", middleware.AuthMiddleware(tokenMaker, "admin"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.createInvoiceHandler)
+	rg.GET("/merchants/:id/invoices", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listInvoiceHandler)
+	rg.GET("/invoices/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getInvoiceHandler)
+	rg.POST("/invoices/:id/cancel", middleware.AuthMiddleware(tokenMaker, "admin"), controller.cancelInvoiceHandler)
+	rg.GET("/pay/:code", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getPaymentLinkHandler)
+	rg.POST("/pay/:code", middleware.AuthMiddleware(tokenMaker, "admin", "user"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.payInvoiceHandler)
+	return &controller
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerController struct {
+	router   *gin.Engine
+	ledgerUC usecase.LedgerUseCase
+	maker    token.Maker
+	cfg      *config.Config
+}
+
+type getLedgerAccountRequest struct {
+	OwnerType string `uri:"type" binding:"required,oneof=customer merchant system"`
+	OwnerID   string `uri:"id" binding:"required"`
+}
+
+type getJournalEntryRequest struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+func (l *LedgerController) getAccountHandler(c *gin.Context) {
+	var req getLedgerAccountRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	accounts, err := l.ledgerUC.ListAccounts(req.OwnerType, req.OwnerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (l *LedgerController) listAccountPostingsHandler(c *gin.Context) {
+	var req getLedgerAccountRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	postings, err := l.ledgerUC.ListAccountPostings(req.OwnerType, req.OwnerID, arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, postings, func(posting model.Posting) model.Cursor {
+		return model.CreatedAtCursor(posting.CreatedAt, posting.ID)
+	})
+}
+
+func (l *LedgerController) getJournalEntryHandler(c *gin.Context) {
+	var req getJournalEntryRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	entry, err := l.ledgerUC.GetJournalEntry(req.ID)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (l *LedgerController) createAdjustmentHandler(c *gin.Context) {
+	var req model.CreateAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	entry, err := l.ledgerUC.RegisterAdjustment(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (l *LedgerController) verifyBalancesHandler(c *gin.Context) {
+	mismatches, err := l.ledgerUC.VerifyBalances()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balanced":   len(mismatches) == 0,
+		"mismatches": mismatches,
+	})
+}
+
+func NewLedgerController(r *gin.Engine, usecase usecase.LedgerUseCase, cfg *config.Config) *LedgerController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := LedgerController{
+		router:   r,
+		ledgerUC: usecase,
+		maker:    tokenMaker,
+		cfg:      cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.GET("/ledger/accounts/:type/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getAccountHandler)
+	rg.GET("/ledger/accounts/:type/:id/postings", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listAccountPostingsHandler)
+	rg.GET("/ledger/entries/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getJournalEntryHandler)
+	rg.POST("/ledger/adjustments", middleware.AuthMiddleware(tokenMaker, "admin"), controller.createAdjustmentHandler)
+	rg.GET("/ledger/verify", middleware.AuthMiddleware(tokenMaker, "admin"), controller.verifyBalancesHandler)
+	return &controller
+}
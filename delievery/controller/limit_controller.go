@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type LimitController struct {
+	router  *gin.Engine
+	limitUC usecase.LimitUseCase
+	maker   token.Maker
+	cfg     *config.Config
+}
+
+func (l *LimitController) setTierLimitHandler(c *gin.Context) {
+	var req model.SetSpendingLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	limit, err := l.limitUC.SetTierLimit(c.Param("tier"), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+func (l *LimitController) listTierLimitHandler(c *gin.Context) {
+	limits, err := l.limitUC.ListTierLimits()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+func (l *LimitController) getCustomerLimitHandler(c *gin.Context) {
+	limits, err := l.limitUC.GetCustomerLimits(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+func (l *LimitController) setCustomerLimitHandler(c *gin.Context) {
+	var req model.SetSpendingLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	limit, err := l.limitUC.SetCustomerLimit(c.Param("id"), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+func (l *LimitController) deleteCustomerLimitHandler(c *gin.Context) {
+	if err := l.limitUC.DeleteCustomerLimit(c.Param("id")); err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "")
+}
+
+func (l *LimitController) setCustomerTierHandler(c *gin.Context) {
+	var req model.SetCustomerTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	limits, err := l.limitUC.SetCustomerTier(c.Param("id"), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+func (l *LimitController) getMyLimitHandler(c *gin.Context) {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	limits, err := l.limitUC.GetMyLimits(authPayload.ID)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+func NewLimitController(r *gin.Engine, usecase usecase.LimitUseCase, cfg *config.Config) *LimitController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := LimitController{
+		router:  r,
+		limitUC: usecase,
+		maker:   tokenMaker,
+		cfg:     cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.GET("/limits", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getMyLimitHandler)
+	rg.GET("/limits/tiers", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listTierLimitHandler)
+	rg.PUT("/limits/tiers/:tier", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setTierLimitHandler)
+	rg.GET("/customers/:id/limits", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getCustomerLimitHandler)
+	rg.PUT("/customers/:id/limits", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setCustomerLimitHandler)
+	rg.DELETE("/customers/:id/limits", middleware.AuthMiddleware(tokenMaker, "admin"), controller.deleteCustomerLimitHandler)
+	rg.PUT("/customers/:id/tier", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setCustomerTierHandler)
+	return &controller
+}
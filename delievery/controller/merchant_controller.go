@@ -2,7 +2,6 @@ package controller
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/albar2305/payment-app/config"
 	"github.com/albar2305/payment-app/delievery/middleware"
@@ -37,7 +36,7 @@ func (u *MerchantController) createMerchantHandler(c *gin.Context) {
 
 	merchant, err := u.merchantUC.RegisterNewMerchant(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
@@ -61,25 +60,21 @@ func (u *MerchantController) deleteMerchantHandler(c *gin.Context) {
 }
 
 func (u *MerchantController) listMerchantHandler(c *gin.Context) {
-	page, _ := strconv.Atoi(c.Query("page"))
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	if page == 0 || limit == 0 {
-		page = 1
-		limit = 5
-	}
-
-	arg := model.PaginationParams{
-		Limit:  int32(limit),
-		Offset: int32((page - 1) * limit),
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
-	customers, err := u.merchantUC.ListMerchant(arg)
+	merchants, err := u.merchantUC.ListMerchant(arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, customers)
+	writePage(c, arg, merchants, func(merchant model.Merchant) model.Cursor {
+		return model.CreatedAtCursor(merchant.CreatedAt, merchant.ID)
+	})
 }
 
 func (u *MerchantController) getMerchantHandler(c *gin.Context) {
@@ -90,7 +85,7 @@ func (u *MerchantController) getMerchantHandler(c *gin.Context) {
 	}
 	merchant, err := u.merchantUC.GetMerchant(req.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPageLimit is the page size when the request does not give one.
+const defaultPageLimit = 5
+
+// pageParams reads the page of a listing from the query. A request with
+// cursor, empty for the first page, is paged by keyset from it; any other
+// request pages by offset as it always has.
+func pageParams(c *gin.Context) (model.PaginationParams, error) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	v, ok := c.GetQuery("cursor")
+	if !ok {
+		page, _ := strconv.Atoi(c.Query("page"))
+		if page == 0 || limit == 0 {
+			page = 1
+			limit = defaultPageLimit
+		}
+		return model.PaginationParams{
+			Limit:  int32(limit),
+			Offset: int32((page - 1) * limit),
+		}, nil
+	}
+
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	params := model.PaginationParams{
+		Limit:  int32(limit),
+		Keyset: true,
+	}
+	if v != "" {
+		cursor, err := model.DecodeCursor(v)
+		if err != nil {
+			return model.PaginationParams{}, fmt.Errorf("%w: %v", common.ErrInvalidCursor, err)
+		}
+		params.Cursor = &cursor
+	}
+	return params, nil
+}
+
+// writePage answers with the rows read for params: a bare list for offset
+// pages, and a page with cursors for keyset ones. key gives the cursor
+// pointing at a row.
+func writePage[T any](c *gin.Context, params model.PaginationParams, rows []T, key func(T) model.Cursor) {
+	if !params.Keyset {
+		c.JSON(http.StatusOK, rows)
+		return
+	}
+	c.JSON(http.StatusOK, model.NewPage(rows, params, key))
+}
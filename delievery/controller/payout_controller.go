@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type PayoutController struct {
+	router   *gin.Engine
+	payoutUC usecase.PayoutUseCase
+	maker    token.Maker
+	cfg      *config.Config
+}
+
+func (p *PayoutController) createPayoutHandler(c *gin.Context) {
+	var req model.CreatePayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+	req.MerchantID = c.Param("id")
+
+	payout, err := p.payoutUC.RequestPayout(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, payout)
+}
+
+func (p *PayoutController) listPayoutHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	payouts, err := p.payoutUC.GetPayoutsByMerchantId(c.Param("id"), arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, payouts, func(payout model.Payout) model.Cursor {
+		return model.CreatedAtCursor(payout.CreatedAt, payout.ID)
+	})
+}
+
+func (p *PayoutController) runSettlementHandler(c *gin.Context) {
+	var req model.RunSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	date := time.Now()
+	if req.Date != "" {
+		date, _ = time.Parse(time.DateOnly, req.Date)
+	}
+
+	batch, err := p.payoutUC.RunSettlement(date)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+func (p *PayoutController) listSettlementHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	batches, err := p.payoutUC.ListSettlementBatches(arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, batches, model.SettlementBatch.Cursor)
+}
+
+func (p *PayoutController) getSettlementHandler(c *gin.Context) {
+	batch, err := p.payoutUC.GetSettlementBatch(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+func (p *PayoutController) downloadSettlementHandler(c *gin.Context) {
+	batch, err := p.payoutUC.GetSettlementBatch(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+	if batch.FilePath == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "settlement batch is still processing"})
+		return
+	}
+
+	c.FileAttachment(batch.FilePath, filepath.Base(batch.FilePath))
+}
+
+func NewPayoutController(r *gin.Engine, usecase usecase.PayoutUseCase, idempotencyUC usecase.IdempotencyUseCase, cfg *config.Config) *PayoutController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := PayoutController{
+		router:   r,
+		payoutUC: usecase,
+		maker:    tokenMaker,
+		cfg:      cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/merchants/:id/payouts", middleware.AuthMiddleware(tokenMaker, "admin"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.createPayoutHandler)
+	rg.GET("/merchants/:id/payouts", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listPayoutHandler)
+	rg.POST("/settlements", middleware.AuthMiddleware(tokenMaker, "admin"), controller.runSettlementHandler)
+	rg.GET("/settlements", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listSettlementHandler)
+	rg.GET("/settlements/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getSettlementHandler)
+	rg.GET("/settlements/:id/file", middleware.AuthMiddleware(tokenMaker, "admin"), controller.downloadSettlementHandler)
+	return &controller
+}
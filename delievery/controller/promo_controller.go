@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type PromoController struct {
+	router  *gin.Engine
+	promoUC usecase.PromoUseCase
+	maker   token.Maker
+	cfg     *config.Config
+}
+
+func (p *PromoController) createCampaignHandler(c *gin.Context) {
+	var req model.PromoCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	campaign, err := p.promoUC.CreateCampaign(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, campaign)
+}
+
+func (p *PromoController) updateCampaignHandler(c *gin.Context) {
+	var req model.PromoCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	campaign, err := p.promoUC.UpdateCampaign(c.Param("id"), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (p *PromoController) getCampaignHandler(c *gin.Context) {
+	campaign, err := p.promoUC.GetCampaign(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (p *PromoController) listCampaignHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	campaigns, err := p.promoUC.ListCampaigns(arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, campaigns, func(campaign model.PromoCampaign) model.Cursor {
+		return model.CreatedAtCursor(campaign.CreatedAt, campaign.ID)
+	})
+}
+
+func (p *PromoController) endCampaignHandler(c *gin.Context) {
+	campaign, err := p.promoUC.EndCampaign(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (p *PromoController) listRedemptionHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	redemptions, err := p.promoUC.ListRedemptions(c.Param("id"), arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, redemptions, func(redemption model.PromoRedemption) model.Cursor {
+		return model.CreatedAtCursor(redemption.CreatedAt, redemption.ID)
+	})
+}
+
+func NewPromoController(r *gin.Engine, usecase usecase.PromoUseCase, cfg *config.Config) *PromoController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := PromoController{
+		router:  r,
+		promoUC: usecase,
+		maker:   tokenMaker,
+		cfg:     cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/promos", middleware.AuthMiddleware(tokenMaker, "admin"), controller.createCampaignHandler)
+	rg.GET("/promos", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listCampaignHandler)
+	rg.GET("/promos/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getCampaignHandler)
+	rg.PUT("/promos/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.updateCampaignHandler)
+	rg.DELETE("/promos/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.endCampaignHandler)
+	rg.GET("/promos/:id/redemptions", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listRedemptionHandler)
+	return &controller
+}
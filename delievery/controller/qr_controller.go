@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type QRController struct {
+	router *gin.Engine
+	qrUC   usecase.QRUseCase
+	maker  token.Maker
+	cfg    *config.Config
+}
+
+func (q *QRController) merchantQRHandler(c *gin.Context) {
+	code, err := q.qrUC.MerchantQR(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+func (q *QRController) merchantQRImageHandler(c *gin.Context) {
+	code, err := q.qrUC.MerchantQR(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+	q.writePNG(c, code)
+}
+
+func (q *QRController) invoiceQRHandler(c *gin.Context) {
+	code, err := q.qrUC.InvoiceQR(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+func (q *QRController) invoiceQRImageHandler(c *gin.Context) {
+	code, err := q.qrUC.InvoiceQR(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+	q.writePNG(c, code)
+}
+
+func (q *QRController) writePNG(c *gin.Context, code model.QRCode) {
+	image, err := q.qrUC.RenderPNG(code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+func (q *QRController) parseQRHandler(c *gin.Context) {
+	var req model.ParseQRRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	preview, err := q.qrUC.ParseQR(req.Payload)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func NewQRController(r *gin.Engine, usecase usecase.QRUseCase, cfg *config.Config) *QRController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := QRController{
+		router: r,
+		qrUC:   usecase,
+		maker:  tokenMaker,
+		cfg:    cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.GET("/merchants/:id/qr", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.merchantQRHandler)
+	rg.GET("/merchants/:id/qr/image", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.merchantQRImageHandler)
+	rg.GET("/invoices/:id/qr", middleware.AuthMiddleware(tokenMaker, "admin"), controller.invoiceQRHandler)
+	rg.GET("/invoices/:id/qr/image", middleware.AuthMiddleware(tokenMaker, "admin"), controller.invoiceQRImageHandler)
+	rg.POST("/qr/parse", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.parseQRHandler)
+	return &controller
+}
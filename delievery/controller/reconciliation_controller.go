@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationController struct {
+	router           *gin.Engine
+	reconciliationUC usecase.ReconciliationUseCase
+	maker            token.Maker
+	cfg              *config.Config
+}
+
+func (r *ReconciliationController) runReconciliationHandler(c *gin.Context) {
+	report, err := r.reconciliationUC.RunReconciliation()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (r *ReconciliationController) getLatestReportHandler(c *gin.Context) {
+	report, err := r.reconciliationUC.GetLatestReport()
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func NewReconciliationController(r *gin.Engine, usecase usecase.ReconciliationUseCase, cfg *config.Config) *ReconciliationController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := ReconciliationController{
+		router:           r,
+		reconciliationUC: usecase,
+		maker:            tokenMaker,
+		cfg:              cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/reconciliation/reports", middleware.AuthMiddleware(tokenMaker, "admin"), controller.runReconciliationHandler)
+	rg.GET("/reconciliation/reports/latest", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getLatestReportHandler)
+	return &controller
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type RefundController struct {
+	router   *gin.Engine
+	refundUC usecase.RefundUseCase
+	maker    token.Maker
+	cfg      *config.Config
+}
+
+type transactionUriRequest struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+func (r *RefundController) createRefundHandler(c *gin.Context) {
+	var uri transactionUriRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	var req model.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	refundRequest := model.CreateRefundRequest{
+		TransactionID: uri.ID,
+		Amount:        req.Amount,
+		Reason:        req.Reason,
+		CreatedBy:     authPayload.ID,
+	}
+
+	refund, err := r.refundUC.RegisterNewRefund(refundRequest)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+// ownerId returns the user whose payments the caller may see, or "" for
+// admins.
+func (r *RefundController) ownerId(c *gin.Context) string {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == "admin" {
+		return ""
+	}
+	return authPayload.ID
+}
+
+func (r *RefundController) listRefundHandler(c *gin.Context) {
+	var uri transactionUriRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	history, err := r.refundUC.GetRefundHistory(r.ownerId(c), uri.ID)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func NewRefundController(r *gin.Engine, usecase usecase.RefundUseCase, cfg *config.Config) *RefundController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := RefundController{
+		router:   r,
+		refundUC: usecase,
+		maker:    tokenMaker,
+		cfg:      cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/transactions/:id/refunds", middleware.AuthMiddleware(tokenMaker, "admin"), controller.createRefundHandler)
+	rg.GET("/transactions/:id/refunds", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listRefundHandler)
+	return &controller
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	router   *gin.Engine
+	reportUC usecase.ReportUseCase
+	maker    token.Maker
+	cfg      *config.Config
+}
+
+func (r *ReportController) merchantSalesReportHandler(c *gin.Context) {
+	r.salesReport(c, c.Param("id"))
+}
+
+func (r *ReportController) salesReportHandler(c *gin.Context) {
+	r.salesReport(c, "")
+}
+
+// salesReport answers with the sales report of a merchant, or of all
+// merchants when merchantId is empty, as JSON or as a CSV download.
+func (r *ReportController) salesReport(c *gin.Context, merchantId string) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	report, err := r.reportUC.SalesReport(model.SalesReportRequest{
+		MerchantID: merchantId,
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+		Interval:   c.Query("interval"),
+		TimeZone:   c.Query("time_zone"),
+	})
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := r.reportUC.WriteSalesReportCSV(&buf, report); err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+	name := fmt.Sprintf("sales_%s_%s_%s.csv", report.Interval, c.Query("from"), c.Query("to"))
+	if merchantId != "" {
+		name = fmt.Sprintf("sales_%s_%s_%s_%s.csv", merchantId, report.Interval, c.Query("from"), c.Query("to"))
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+func NewReportController(r *gin.Engine, usecase usecase.ReportUseCase, cfg *config.Config) *ReportController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := ReportController{
+		router:   r,
+		reportUC: usecase,
+		maker:    tokenMaker,
+		cfg:      cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.GET("/merchants/:id/sales-report", middleware.AuthMiddleware(tokenMaker, "admin"), controller.merchantSalesReportHandler)
+	rg.GET("/reports/sales", middleware.AuthMiddleware(tokenMaker, "admin"), controller.salesReportHandler)
+	return &controller
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type StatementController struct {
+	router      *gin.Engine
+	statementUC usecase.StatementUseCase
+	maker       token.Maker
+	cfg         *config.Config
+}
+
+var statementContentTypes = map[string]string{
+	model.StatementFormatCSV: "text/csv",
+	model.StatementFormatPDF: "application/pdf",
+}
+
+// ownerId returns the user whose statements the caller may see, or "" for
+// admins.
+func (s *StatementController) ownerId(c *gin.Context) string {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == "admin" {
+		return ""
+	}
+	return authPayload.ID
+}
+
+func (s *StatementController) getStatementHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", model.StatementFormatJSON)
+	contentType, ok := statementContentTypes[format]
+	if !ok && format != model.StatementFormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or pdf"})
+		return
+	}
+
+	statement, err := s.statementUC.GetStatement(s.ownerId(c), c.Param("id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+	if format == model.StatementFormatJSON {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := s.statementUC.WriteStatement(&buf, statement, format); err != nil {
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		return
+	}
+	name := fmt.Sprintf("statement_%s_%s_%s.%s", statement.CustomerID, c.Query("from"), c.Query("to"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func (s *StatementController) listStatementHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	statements, err := s.statementUC.ListStatements(s.ownerId(c), c.Param("id"), arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, statements, model.Statement.Cursor)
+}
+
+func (s *StatementController) downloadStatementHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", model.StatementFormatPDF)
+	if _, ok := statementContentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or pdf"})
+		return
+	}
+
+	statement, err := s.statementUC.GetGeneratedStatement(s.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	filePath := statement.PDFPath
+	if format == model.StatementFormatCSV {
+		filePath = statement.CSVPath
+	}
+	name := fmt.Sprintf("statement_%s_%s.%s", statement.CustomerID, statement.PeriodStart.Format("2006-01"), format)
+	c.FileAttachment(filePath, name)
+}
+
+func NewStatementController(r *gin.Engine, usecase usecase.StatementUseCase, cfg *config.Config) *StatementController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := StatementController{
+		router:      r,
+		statementUC: usecase,
+		maker:       tokenMaker,
+		cfg:         cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.GET("/customers/:id/statement", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getStatementHandler)
+	rg.GET("/customers/:id/statements", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listStatementHandler)
+	rg.GET("/statements/:id/file", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.downloadStatementHandler)
+	return &controller
+}
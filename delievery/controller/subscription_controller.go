@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionController struct {
+	router         *gin.Engine
+	subscriptionUC usecase.SubscriptionUseCase
+	maker          token.Maker
+	cfg            *config.Config
+}
+
+func (s *SubscriptionController) createSubscriptionHandler(c *gin.Context) {
+	var req model.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	req.UserID = authPayload.ID
+
+	subscription, err := s.subscriptionUC.CreateSubscription(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (s *SubscriptionController) listSubscriptionHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	subscriptions, err := s.subscriptionUC.ListSubscriptions(authPayload.ID, arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, subscriptions, func(subscription model.Subscription) model.Cursor {
+		return model.CreatedAtCursor(subscription.CreatedAt, subscription.ID)
+	})
+}
+
+// ownerId returns the user whose subscriptions the caller may act on, or ""
+// for admins.
+func (s *SubscriptionController) ownerId(c *gin.Context) string {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == "admin" {
+		return ""
+	}
+	return authPayload.ID
+}
+
+func (s *SubscriptionController) getSubscriptionHandler(c *gin.Context) {
+	subscription, err := s.subscriptionUC.GetSubscription(s.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (s *SubscriptionController) pauseSubscriptionHandler(c *gin.Context) {
+	subscription, err := s.subscriptionUC.PauseSubscription(s.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (s *SubscriptionController) resumeSubscriptionHandler(c *gin.Context) {
+	subscription, err := s.subscriptionUC.ResumeSubscription(s.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (s *SubscriptionController) cancelSubscriptionHandler(c *gin.Context) {
+	subscription, err := s.subscriptionUC.CancelSubscription(s.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (s *SubscriptionController) setNextAmountHandler(c *gin.Context) {
+	var req model.SetNextAmountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	subscription, err := s.subscriptionUC.SetNextAmount(c.Param("id"), req.Amount)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (s *SubscriptionController) listChargesHandler(c *gin.Context) {
+	charges, err := s.subscriptionUC.ListCharges(s.ownerId(c), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, charges)
+}
+
+func NewSubscriptionController(r *gin.Engine, usecase usecase.SubscriptionUseCase, cfg *config.Config) *SubscriptionController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := SubscriptionController{
+		router:         r,
+		subscriptionUC: usecase,
+		maker:          tokenMaker,
+		cfg:            cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/subscriptions", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.createSubscriptionHandler)
+	rg.GET("/subscriptions", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listSubscriptionHandler)
+	rg.GET("/subscriptions/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getSubscriptionHandler)
+	rg.POST("/subscriptions/:id/pause", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.pauseSubscriptionHandler)
+	rg.POST("/subscriptions/:id/resume", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.resumeSubscriptionHandler)
+	rg.POST("/subscriptions/:id/cancel", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.cancelSubscriptionHandler)
+	rg.PUT("/subscriptions/:id/next-amount", middleware.AuthMiddleware(tokenMaker, "admin"), controller.setNextAmountHandler)
+	rg.GET("/subscriptions/:id/charges", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listChargesHandler)
+	return &controller
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/gateway"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type TopUpController struct {
+	router  *gin.Engine
+	topUpUC usecase.TopUpUseCase
+	maker   token.Maker
+	cfg     *config.Config
+}
+
+func (t *TopUpController) createTopUpHandler(c *gin.Context) {
+	var req model.CreateTopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	req.UserID = authPayload.ID
+
+	order, err := t.topUpUC.CreateTopUp(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (t *TopUpController) getTopUpHandler(c *gin.Context) {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	userId := authPayload.ID
+	if authPayload.Role == "admin" {
+		userId = ""
+	}
+
+	order, err := t.topUpUC.GetTopUp(userId, c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (t *TopUpController) listTopUpHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	orders, err := t.topUpUC.ListTopUps(authPayload.ID, arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, orders, func(order model.TopUpOrder) model.Cursor {
+		return model.CreatedAtCursor(order.CreatedAt, order.ID)
+	})
+}
+
+// gatewayWebhookHandler receives payment updates from the gateway. It is not
+// behind AuthMiddleware; the body signature authenticates the gateway.
+func (t *TopUpController) gatewayWebhookHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	order, err := t.topUpUC.HandleGatewayWebhook(body, c.GetHeader(gateway.SignatureHeader))
+	if err != nil {
+		if errors.Is(err, gateway.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, common.ErrorResponse(err))
+			return
+		}
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": order.ID, "status": order.Status})
+}
+
+func NewTopUpController(r *gin.Engine, usecase usecase.TopUpUseCase, idempotencyUC usecase.IdempotencyUseCase, cfg *config.Config) *TopUpController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := TopUpController{
+		router:  r,
+		topUpUC: usecase,
+		maker:   tokenMaker,
+		cfg:     cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/customers/top-up", middleware.AuthMiddleware(tokenMaker, "admin", "user"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.createTopUpHandler)
+	rg.GET("/top-ups", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listTopUpHandler)
+	rg.GET("/top-ups/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getTopUpHandler)
+	rg.POST("/webhooks/gateway", controller.gatewayWebhookHandler)
+	return &controller
+}
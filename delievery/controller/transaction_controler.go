@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/albar2305/payment-app/config"
 	"github.com/albar2305/payment-app/delievery/middleware"
@@ -17,6 +20,7 @@ import (
 type TransactionController struct {
 	router        *gin.Engine
 	transactionUC usecase.TransactionUseCase
+	idempotencyUC usecase.IdempotencyUseCase
 	maker         token.Maker
 	cfg           *config.Config
 }
@@ -33,76 +37,302 @@ func (t *TransactionController) createTransactionHandler(c *gin.Context) {
 		UserId:             authPayload.ID,
 		ReceiverMerchantId: req.ReceiverMerchantId,
 		Amount:             req.Amount,
+		Splits:             req.Splits,
+		PromoCode:          req.PromoCode,
 	}
 
 	user, err := t.transactionUC.RegisterNewTransaction(transactionRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
+func (t *TransactionController) authorizeTransactionHandler(c *gin.Context) {
+	var req model.CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	transactionRequest := model.CreateTransactionRequest{
+		UserId:             authPayload.ID,
+		ReceiverMerchantId: req.ReceiverMerchantId,
+		Amount:             req.Amount,
+		Splits:             req.Splits,
+		PromoCode:          req.PromoCode,
+	}
+
+	transaction, err := t.transactionUC.AuthorizeTransaction(transactionRequest)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+func (t *TransactionController) captureTransactionHandler(c *gin.Context) {
+	var uri transactionUriRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	var req model.CaptureTransactionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+			return
+		}
+	}
+	req.TransactionID = uri.ID
+
+	transaction, err := t.transactionUC.CaptureTransaction(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+func (t *TransactionController) voidTransactionHandler(c *gin.Context) {
+	var uri transactionUriRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	transaction, err := t.transactionUC.VoidTransaction(uri.ID)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// paymentErrorStatus maps errors from money movement to an HTTP status.
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, common.ErrInvalidAmount),
+		errors.Is(err, common.ErrSelfTransfer),
+		errors.Is(err, common.ErrUnsupportedCurrency),
+		errors.Is(err, common.ErrInvalidFxRate),
+		errors.Is(err, common.ErrInvalidFeeRule),
+		errors.Is(err, common.ErrInvalidInvoice),
+		errors.Is(err, common.ErrInvalidQRPayload),
+		errors.Is(err, common.ErrInvalidSplit),
+		errors.Is(err, common.ErrInvalidLimit),
+		errors.Is(err, common.ErrInvalidFraudRule),
+		errors.Is(err, common.ErrInvalidPromo),
+		errors.Is(err, common.ErrInvalidDispute),
+		errors.Is(err, common.ErrInvalidPeriod),
+		errors.Is(err, common.ErrInvalidReport),
+		errors.Is(err, common.ErrInvalidFilter),
+		errors.Is(err, common.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, common.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, common.ErrInsufficientFunds),
+		errors.Is(err, common.ErrRefundExceeded),
+		errors.Is(err, common.ErrCaptureExceeded),
+		errors.Is(err, common.ErrCurrencyMismatch),
+		errors.Is(err, common.ErrFxRateNotFound),
+		errors.Is(err, common.ErrNoPendingPayouts),
+		errors.Is(err, common.ErrLimitExceeded),
+		errors.Is(err, common.ErrPaymentBlocked),
+		errors.Is(err, common.ErrWalletFrozen):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, common.ErrInvalidStatus),
+		errors.Is(err, common.ErrScreeningOutdated):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// transactionFilter reads the filters and sorting of a transaction listing
+// from the query. Times are RFC 3339, and status takes a comma separated list.
+func transactionFilter(c *gin.Context) (model.TransactionFilter, error) {
+	filter := model.TransactionFilter{
+		CustomerID: c.Query("customer_id"),
+		MerchantID: c.Query("merchant_id"),
+		SortBy:     c.Query("sort"),
+		SortOrder:  c.Query("order"),
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = model.TransactionSortCreatedAt
+	}
+	if filter.SortOrder == "" {
+		filter.SortOrder = model.SortOrderAsc
+	}
+	if v := c.Query("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+
+	var err error
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return model.TransactionFilter{}, err
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return model.TransactionFilter{}, err
+	}
+	if filter.MinAmount, err = queryAmount(c, "min_amount"); err != nil {
+		return model.TransactionFilter{}, err
+	}
+	if filter.MaxAmount, err = queryAmount(c, "max_amount"); err != nil {
+		return model.TransactionFilter{}, err
+	}
+	return filter, nil
+}
+
+// queryTime reads an optional RFC 3339 time from the query.
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a time like 2006-01-02T15:04:05+07:00", common.ErrInvalidFilter, name)
+	}
+	return &at, nil
+}
+
+// queryAmount reads an optional whole amount from the query.
+func queryAmount(c *gin.Context, name string) (*int64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a whole number", common.ErrInvalidFilter, name)
+	}
+	return &amount, nil
+}
+
 func (t *TransactionController) listTransactionHandler(c *gin.Context) {
-	page, _ := strconv.Atoi(c.Query("page"))
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	if page == 0 || limit == 0 {
-		page = 1
-		limit = 5
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
-	arg := model.PaginationParams{
-		Limit:  int32(limit),
-		Offset: int32((page - 1) * limit),
+	filter, err := transactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
-	transactions, err := t.transactionUC.ListTransaction(arg)
+	transactions, err := t.transactionUC.ListTransaction(t.ownerId(c), filter, arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	writePage(c, arg, transactions, filter.Cursor)
+}
+
+// ownerId returns the user whose payments the caller may see, or "" for
+// admins.
+func (t *TransactionController) ownerId(c *gin.Context) string {
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == "admin" {
+		return ""
+	}
+	return authPayload.ID
+}
+
+// transactionExpand reads the relations to load with a transaction from the
+// comma separated expand query. Without expand all of them are loaded.
+func transactionExpand(c *gin.Context) (model.TransactionExpand, error) {
+	v, ok := c.GetQuery("expand")
+	if !ok {
+		return model.TransactionExpand{Customer: true, Merchant: true}, nil
+	}
+
+	var expand model.TransactionExpand
+	for _, name := range strings.Split(v, ",") {
+		switch name {
+		case model.TransactionExpandCustomer:
+			expand.Customer = true
+		case model.TransactionExpandMerchant:
+			expand.Merchant = true
+		case "":
+		default:
+			return model.TransactionExpand{}, fmt.Errorf("cannot expand %q, expand takes customer and merchant", name)
+		}
+	}
+	return expand, nil
 }
 
-func (t *TransactionController) getTransactionHandlerByCustomerID(c *gin.Context) {
-	id := c.Params.ByName("id")
-	fmt.Println(id)
+func (t *TransactionController) getTransactionHandler(c *gin.Context) {
+	var uri transactionUriRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
 
-	page, _ := strconv.Atoi(c.Query("page"))
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	if page == 0 || limit == 0 {
-		page = 1
-		limit = 5
+	expand, err := transactionExpand(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
-	arg := model.PaginationParams{
-		Limit:  int32(limit),
-		Offset: int32((page - 1) * limit),
+	transaction, err := t.transactionUC.GetTransactionDetail(t.ownerId(c), uri.ID, expand)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+func (t *TransactionController) listCustomerTransactionHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	filter, err := transactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
-	transactions, err := t.transactionUC.GetTransactionByCustomerId(id, arg)
+	transactions, err := t.transactionUC.GetTransactionByCustomerId(t.ownerId(c), c.Param("id"), filter, arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	writePage(c, arg, transactions, filter.Cursor)
 }
 
-func NewTransactionController(r *gin.Engine, usecase usecase.TransactionUseCase, cfg *config.Config) *TransactionController {
+func NewTransactionController(r *gin.Engine, usecase usecase.TransactionUseCase, idempotencyUC usecase.IdempotencyUseCase, cfg *config.Config) *TransactionController {
 	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
 	controller := TransactionController{
 		router:        r,
 		transactionUC: usecase,
+		idempotencyUC: idempotencyUC,
 		maker:         tokenMaker,
 		cfg:           cfg,
 	}
 
 	rg := r.Group("/api/v1")
-	rg.POST("/transactions", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.createTransactionHandler)
-	rg.GET("/transactions/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getTransactionHandlerByCustomerID)
+	rg.POST("/transactions", middleware.AuthMiddleware(tokenMaker, "admin", "user"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.createTransactionHandler)
+	rg.POST("/transactions/authorizations", middleware.AuthMiddleware(tokenMaker, "admin", "user"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.authorizeTransactionHandler)
+	rg.POST("/transactions/:id/capture", middleware.AuthMiddleware(tokenMaker, "admin"), controller.captureTransactionHandler)
+	rg.POST("/transactions/:id/void", middleware.AuthMiddleware(tokenMaker, "admin"), controller.voidTransactionHandler)
+	rg.GET("/transactions/:id", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.getTransactionHandler)
+	rg.GET("/customers/:id/transactions", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listCustomerTransactionHandler)
 	rg.GET("/transactions", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listTransactionHandler)
 	return &controller
 }
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type TransferController struct {
+	router     *gin.Engine
+	transferUC usecase.TransferUseCase
+	maker      token.Maker
+	cfg        *config.Config
+}
+
+func (t *TransferController) createTransferHandler(c *gin.Context) {
+	var req model.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	req.UserId = authPayload.ID
+
+	transfer, err := t.transferUC.RegisterNewTransfer(req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func (t *TransferController) listTransferHandler(c *gin.Context) {
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	transfers, err := t.transferUC.ListTransferByUserId(authPayload.ID, arg)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	writePage(c, arg, transfers, func(transfer model.Transfer) model.Cursor {
+		return model.CreatedAtCursor(transfer.CreatedAt, transfer.ID)
+	})
+}
+
+func NewTransferController(r *gin.Engine, usecase usecase.TransferUseCase, idempotencyUC usecase.IdempotencyUseCase, cfg *config.Config) *TransferController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := TransferController{
+		router:     r,
+		transferUC: usecase,
+		maker:      tokenMaker,
+		cfg:        cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	rg.POST("/transfers", middleware.AuthMiddleware(tokenMaker, "admin", "user"), middleware.IdempotencyMiddleware(idempotencyUC, cfg.IdempotencyKeyTTL), controller.createTransferHandler)
+	rg.GET("/transfers", middleware.AuthMiddleware(tokenMaker, "admin", "user"), controller.listTransferHandler)
+	return &controller
+}
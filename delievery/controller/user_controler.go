@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/albar2305/payment-app/config"
@@ -85,25 +84,21 @@ func (u *UserController) updateUserHandler(c *gin.Context) {
 }
 
 func (u *UserController) listUserHandler(c *gin.Context) {
-	page, _ := strconv.Atoi(c.Query("page"))
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	if page == 0 || limit == 0 {
-		page = 1
-		limit = 5
-	}
-
-	arg := model.PaginationParams{
-		Limit:  int32(limit),
-		Offset: int32((page - 1) * limit),
+	arg, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
 	}
 
 	users, err := u.userUC.ListUser(arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	writePage(c, arg, users, func(user model.UserResponse) model.Cursor {
+		return model.CreatedAtCursor(user.CreatedAt, user.ID)
+	})
 }
 
 type loginUserRequest struct {
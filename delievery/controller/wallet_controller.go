@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/delievery/middleware"
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type WalletController struct {
+	router   *gin.Engine
+	walletUC usecase.WalletUseCase
+	maker    token.Maker
+	cfg      *config.Config
+}
+
+func (w *WalletController) freezeHandler(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.FreezeWalletRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+			return
+		}
+
+		authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+		freeze, err := w.walletUC.FreezeWallet(ownerType, c.Param("id"), authPayload.ID, req)
+		if err != nil {
+			c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, freeze)
+	}
+}
+
+func (w *WalletController) unfreezeHandler(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.WalletActionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+			return
+		}
+
+		authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+		freeze, err := w.walletUC.UnfreezeWallet(ownerType, c.Param("id"), authPayload.ID, req)
+		if err != nil {
+			c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, freeze)
+	}
+}
+
+func (w *WalletController) listFreezeHandler(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		freezes, err := w.walletUC.ListFreezes(ownerType, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, freezes)
+	}
+}
+
+func (w *WalletController) placeHoldHandler(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.PlaceHoldRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+			return
+		}
+
+		authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+		hold, err := w.walletUC.PlaceHold(ownerType, c.Param("id"), authPayload.ID, req)
+		if err != nil {
+			c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, hold)
+	}
+}
+
+func (w *WalletController) listHoldHandler(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		holds, err := w.walletUC.ListHolds(ownerType, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, common.ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, holds)
+	}
+}
+
+func (w *WalletController) getHoldHandler(c *gin.Context) {
+	hold, err := w.walletUC.GetHold(c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func (w *WalletController) releaseHoldHandler(c *gin.Context) {
+	var req model.WalletActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.ErrorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	hold, err := w.walletUC.ReleaseHold(c.Param("id"), authPayload.ID, req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), common.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func NewWalletController(r *gin.Engine, usecase usecase.WalletUseCase, cfg *config.Config) *WalletController {
+	tokenMaker, _ := token.NewJWTMaker(cfg.TokenSymetricKey)
+	controller := WalletController{
+		router:   r,
+		walletUC: usecase,
+		maker:    tokenMaker,
+		cfg:      cfg,
+	}
+
+	rg := r.Group("/api/v1")
+	for _, owner := range []struct{ path, ownerType string }{
+		{"/customers", model.AccountOwnerCustomer},
+		{"/merchants", model.AccountOwnerMerchant},
+	} {
+		rg.POST(owner.path+"/:id/freeze", middleware.AuthMiddleware(tokenMaker, "admin"), controller.freezeHandler(owner.ownerType))
+		rg.POST(owner.path+"/:id/unfreeze", middleware.AuthMiddleware(tokenMaker, "admin"), controller.unfreezeHandler(owner.ownerType))
+		rg.GET(owner.path+"/:id/freezes", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listFreezeHandler(owner.ownerType))
+		rg.POST(owner.path+"/:id/holds", middleware.AuthMiddleware(tokenMaker, "admin"), controller.placeHoldHandler(owner.ownerType))
+		rg.GET(owner.path+"/:id/holds", middleware.AuthMiddleware(tokenMaker, "admin"), controller.listHoldHandler(owner.ownerType))
+	}
+	rg.GET("/holds/:id", middleware.AuthMiddleware(tokenMaker, "admin"), controller.getHoldHandler)
+	rg.POST("/holds/:id/release", middleware.AuthMiddleware(tokenMaker, "admin"), controller.releaseHoldHandler)
+	return &controller
+}
@@ -0,0 +1,144 @@
+package delievery
+
+import (
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+func (s *Server) jobs() []job {
+	return []job{
+		{
+			name:     "expire uncaptured authorizations",
+			interval: time.Minute,
+			run: func() error {
+				expired, err := s.useCaseManager.TransactionUseCase().ExpireAuthorizations()
+				if expired > 0 {
+					s.log.Infof("expired %d authorizations", expired)
+				}
+				return err
+			},
+		},
+		{
+			name:     "expire unpaid top-up orders",
+			interval: time.Minute,
+			run: func() error {
+				expired, err := s.useCaseManager.TopUpUseCase().ExpireTopUps()
+				if expired > 0 {
+					s.log.Infof("expired %d top-up orders", expired)
+				}
+				return err
+			},
+		},
+		{
+			name:     "expire overdue invoices",
+			interval: time.Minute,
+			run: func() error {
+				expired, err := s.useCaseManager.InvoiceUseCase().ExpireInvoices()
+				if expired > 0 {
+					s.log.Infof("expired %d invoices", expired)
+				}
+				return err
+			},
+		},
+		{
+			name:     "charge due subscriptions",
+			interval: time.Minute,
+			run: func() error {
+				charged, err := s.useCaseManager.SubscriptionUseCase().ChargeDueSubscriptions()
+				if charged > 0 {
+					s.log.Infof("charged %d subscriptions", charged)
+				}
+				return err
+			},
+		},
+		{
+			name:     "credit due cashback",
+			interval: time.Hour,
+			run: func() error {
+				credited, err := s.useCaseManager.PromoUseCase().CreditDueCashback()
+				if credited > 0 {
+					s.log.Infof("credited cashback for %d payments", credited)
+				}
+				return err
+			},
+		},
+		{
+			name:     "escalate disputes past the response deadline",
+			interval: time.Hour,
+			run: func() error {
+				escalated, err := s.useCaseManager.DisputeUseCase().EscalateOverdueDisputes()
+				if escalated > 0 {
+					s.log.Infof("sent %d unanswered disputes to review", escalated)
+				}
+				return err
+			},
+		},
+		{
+			name:     "generate monthly statements",
+			interval: time.Hour,
+			run: func() error {
+				generated, err := s.useCaseManager.StatementUseCase().GenerateMonthlyStatements()
+				if generated > 0 {
+					s.log.Infof("generated %d monthly statements", generated)
+				}
+				return err
+			},
+		},
+		{
+			name:     "purge expired idempotency keys",
+			interval: time.Hour,
+			run: func() error {
+				purged, err := s.useCaseManager.IdempotencyUseCase().PurgeExpired()
+				if err == nil && purged > 0 {
+					s.log.Infof("purged %d expired idempotency keys", purged)
+				}
+				return err
+			},
+		},
+		{
+			name:     "settle pending payouts",
+			interval: time.Hour,
+			run: func() error {
+				return s.useCaseManager.PayoutUseCase().SettlePendingPayouts()
+			},
+		},
+		{
+			name:     "reconcile balances",
+			interval: 24 * time.Hour,
+			run: func() error {
+				report, err := s.useCaseManager.ReconciliationUseCase().RunReconciliation()
+				if err == nil && report.MismatchCount > 0 {
+					s.log.Warnf("reconciliation %s found %d mismatched wallets", report.ID, report.MismatchCount)
+				}
+				return err
+			},
+		},
+	}
+}
+
+// startJobs runs every background job on its own ticker until the process
+// exits, logging each failure a run reports.
+func (s *Server) startJobs() {
+	for _, j := range s.jobs() {
+		go func(j job) {
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				err := j.run()
+				// batch jobs join the error of every item they skipped
+				if joined, ok := err.(interface{ Unwrap() []error }); ok {
+					for _, err := range joined.Unwrap() {
+						s.log.Errorf("job %q failed: %v", j.name, err)
+					}
+				} else if err != nil {
+					s.log.Errorf("job %q failed: %v", j.name, err)
+				}
+			}
+		}(j)
+	}
+}
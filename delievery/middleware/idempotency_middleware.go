@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the stored response when a request is retried
+// with the same Idempotency-Key header and body. It must run after
+// AuthMiddleware because keys are scoped to the authenticated user.
+func IdempotencyMiddleware(idempotencyUC usecase.IdempotencyUseCase, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			err := errors.New("idempotency key must be at most 255 characters")
+			c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		authPayload := c.MustGet(AuthorizationPayloadKey).(*token.Payload)
+		hash := sha256.Sum256(append([]byte(c.Request.Method+" "+c.FullPath()+"\n"), body...))
+		stored, replay, err := idempotencyUC.Begin(model.IdempotencyKey{
+			Key:         key,
+			UserID:      authPayload.ID,
+			RequestPath: c.FullPath(),
+			RequestHash: hex.EncodeToString(hash[:]),
+			ExpiresAt:   time.Now().Add(ttl),
+		})
+		if err != nil {
+			if errors.Is(err, usecase.ErrIdempotencyKeyConflict) || errors.Is(err, usecase.ErrIdempotencyKeyInProgress) {
+				c.AbortWithStatusJSON(http.StatusConflict, common.ErrorResponse(err))
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse(err))
+			return
+		}
+		if replay {
+			c.Header(IdempotencyReplayedHeader, "true")
+			c.Data(stored.StatusCode, "application/json; charset=utf-8", stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// The key is released unless the response gets stored, also when the
+		// handler panics, so the client can retry with the same key.
+		completed := false
+		defer func() {
+			if !completed {
+				_ = idempotencyUC.Release(authPayload.ID, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so the client can retry with the same key.
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := idempotencyUC.Complete(authPayload.ID, key, status, recorder.body.Bytes()); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		completed = true
+	}
+}
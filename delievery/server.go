@@ -7,6 +7,7 @@ import (
 	"github.com/albar2305/payment-app/delievery/controller"
 	"github.com/albar2305/payment-app/manager"
 	"github.com/albar2305/payment-app/utils/exception"
+	"github.com/albar2305/payment-app/utils/gateway"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -20,6 +21,7 @@ type Server struct {
 
 func (s *Server) Run() {
 	s.setupControllers()
+	s.startJobs()
 	err := s.engine.Run(s.host)
 	if err != nil {
 		panic(err)
@@ -30,16 +32,39 @@ func (s *Server) setupControllers() {
 	cfg, _ := config.NewConfig()
 	controller.NewUserController(s.engine, s.useCaseManager.UserUseCase(), cfg)
 	controller.NewCustomerController(s.engine, s.useCaseManager.CustomerUseCase(), cfg)
-	controller.NewTransactionController(s.engine, s.useCaseManager.TransactionUseCase(), cfg)
+	controller.NewTransactionController(s.engine, s.useCaseManager.TransactionUseCase(), s.useCaseManager.IdempotencyUseCase(), cfg)
 	controller.NewMerchantController(s.engine, s.useCaseManager.MerchantUseCase(), cfg)
+	controller.NewLedgerController(s.engine, s.useCaseManager.LedgerUseCase(), cfg)
+	controller.NewRefundController(s.engine, s.useCaseManager.RefundUseCase(), cfg)
+	controller.NewTransferController(s.engine, s.useCaseManager.TransferUseCase(), s.useCaseManager.IdempotencyUseCase(), cfg)
+	controller.NewFxRateController(s.engine, s.useCaseManager.FxRateUseCase(), cfg)
+	controller.NewPayoutController(s.engine, s.useCaseManager.PayoutUseCase(), s.useCaseManager.IdempotencyUseCase(), cfg)
+	controller.NewFeeController(s.engine, s.useCaseManager.FeeUseCase(), s.useCaseManager.MerchantUseCase(), cfg)
+	controller.NewReconciliationController(s.engine, s.useCaseManager.ReconciliationUseCase(), cfg)
+	controller.NewTopUpController(s.engine, s.useCaseManager.TopUpUseCase(), s.useCaseManager.IdempotencyUseCase(), cfg)
+	// Completing simulated charges settles top-ups without payment, so it is
+	// never exposed in production.
+	if simulator, ok := s.useCaseManager.PaymentGateway().(*gateway.Simulator); ok && cfg.DevMode() {
+		controller.NewGatewaySimulatorController(s.engine, simulator, cfg)
+	}
+	controller.NewSubscriptionController(s.engine, s.useCaseManager.SubscriptionUseCase(), cfg)
+	controller.NewInvoiceController(s.engine, s.useCaseManager.InvoiceUseCase(), s.useCaseManager.IdempotencyUseCase(), cfg)
+	controller.NewQRController(s.engine, s.useCaseManager.QRUseCase(), cfg)
+	controller.NewLimitController(s.engine, s.useCaseManager.LimitUseCase(), cfg)
+	controller.NewFraudController(s.engine, s.useCaseManager.FraudUseCase(), cfg)
+	controller.NewWalletController(s.engine, s.useCaseManager.WalletUseCase(), cfg)
+	controller.NewPromoController(s.engine, s.useCaseManager.PromoUseCase(), cfg)
+	controller.NewDisputeController(s.engine, s.useCaseManager.DisputeUseCase(), cfg)
+	controller.NewStatementController(s.engine, s.useCaseManager.StatementUseCase(), cfg)
+	controller.NewReportController(s.engine, s.useCaseManager.ReportUseCase(), cfg)
 }
 
 func NewServer() *Server {
 	cfg, err := config.NewConfig()
 	exception.CheckErr(err)
 	infraManager, _ := manager.NewInfraManager(cfg)
-	repoManager := manager.NewRepoManager(infraManager)
-	useCaseManager := manager.NewUseCaseManager(repoManager)
+	repoManager := manager.NewRepoManager(infraManager, cfg)
+	useCaseManager := manager.NewUseCaseManager(repoManager, cfg)
 	engine := gin.Default()
 	host := fmt.Sprintf(":%s", cfg.ApiPort)
 	return &Server{
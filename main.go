@@ -1,7 +1,17 @@
 package main
 
-import "github.com/albar2305/payment-app/delievery"
+import (
+	"os"
+
+	"github.com/albar2305/payment-app/delievery"
+	"github.com/albar2305/payment-app/utils/exception"
+)
 
 func main() {
-	delievery.NewServer().Run()
+	server := delievery.NewServer()
+	if len(os.Args) > 1 {
+		exception.CheckErr(server.RunCommand(os.Args[1:]))
+		return
+	}
+	server.Run()
 }
@@ -1,21 +1,133 @@
 package manager
 
-import "github.com/albar2305/payment-app/repository"
+import (
+	"github.com/albar2305/payment-app/config"
+	"github.com/albar2305/payment-app/repository"
+)
 
 type RepoManager interface {
 	UserRepo() repository.UserRepository
 	CustomerRepo() repository.CustomerRepository
 	MerchantRepo() repository.MerchantRepository
 	TransactionRepo() repository.TransactionRepository
+	LedgerRepo() repository.LedgerRepository
+	IdempotencyRepo() repository.IdempotencyRepository
+	RefundRepo() repository.RefundRepository
+	TransferRepo() repository.TransferRepository
+	FxRateRepo() repository.FxRateRepository
+	PayoutRepo() repository.PayoutRepository
+	FeeRuleRepo() repository.FeeRuleRepository
+	ReconciliationRepo() repository.ReconciliationRepository
+	TopUpRepo() repository.TopUpRepository
+	SubscriptionRepo() repository.SubscriptionRepository
+	InvoiceRepo() repository.InvoiceRepository
+	SpendingLimitRepo() repository.SpendingLimitRepository
+	FraudRepo() repository.FraudRepository
+	WalletRepo() repository.WalletRepository
+	PromoRepo() repository.PromoRepository
+	DisputeRepo() repository.DisputeRepository
+	StatementRepo() repository.StatementRepository
+	ReportRepo() repository.ReportRepository
 }
 
 type repoManager struct {
 	infra InfraManager
+	cfg   *config.Config
+}
+
+// RefundRepo implements RepoManager.
+func (r *repoManager) RefundRepo() repository.RefundRepository {
+	return repository.NewRefundRepository(r.infra.Conn())
+}
+
+// IdempotencyRepo implements RepoManager.
+func (r *repoManager) IdempotencyRepo() repository.IdempotencyRepository {
+	return repository.NewIdempotencyRepository(r.infra.Conn())
+}
+
+// LedgerRepo implements RepoManager.
+func (r *repoManager) LedgerRepo() repository.LedgerRepository {
+	return repository.NewLedgerRepository(r.infra.Conn())
+}
+
+// TransferRepo implements RepoManager.
+func (r *repoManager) TransferRepo() repository.TransferRepository {
+	return repository.NewTransferRepository(r.infra.Conn())
+}
+
+// FxRateRepo implements RepoManager.
+func (r *repoManager) FxRateRepo() repository.FxRateRepository {
+	return repository.NewFxRateRepository(r.infra.Conn())
+}
+
+// PayoutRepo implements RepoManager.
+func (r *repoManager) PayoutRepo() repository.PayoutRepository {
+	return repository.NewPayoutRepository(r.infra.Conn())
+}
+
+// FeeRuleRepo implements RepoManager.
+func (r *repoManager) FeeRuleRepo() repository.FeeRuleRepository {
+	return repository.NewFeeRuleRepository(r.infra.Conn())
+}
+
+// ReconciliationRepo implements RepoManager.
+func (r *repoManager) ReconciliationRepo() repository.ReconciliationRepository {
+	return repository.NewReconciliationRepository(r.infra.Conn())
+}
+
+// TopUpRepo implements RepoManager.
+func (r *repoManager) TopUpRepo() repository.TopUpRepository {
+	return repository.NewTopUpRepository(r.infra.Conn(), r.cfg.LimitLocation)
+}
+
+// SubscriptionRepo implements RepoManager.
+func (r *repoManager) SubscriptionRepo() repository.SubscriptionRepository {
+	return repository.NewSubscriptionRepository(r.infra.Conn())
+}
+
+// InvoiceRepo implements RepoManager.
+func (r *repoManager) InvoiceRepo() repository.InvoiceRepository {
+	return repository.NewInvoiceRepository(r.infra.Conn())
+}
+
+// SpendingLimitRepo implements RepoManager.
+func (r *repoManager) SpendingLimitRepo() repository.SpendingLimitRepository {
+	return repository.NewSpendingLimitRepository(r.infra.Conn(), r.cfg.LimitLocation)
+}
+
+// FraudRepo implements RepoManager.
+func (r *repoManager) FraudRepo() repository.FraudRepository {
+	return repository.NewFraudRepository(r.infra.Conn(), r.cfg.LimitLocation)
+}
+
+// WalletRepo implements RepoManager.
+func (r *repoManager) WalletRepo() repository.WalletRepository {
+	return repository.NewWalletRepository(r.infra.Conn())
+}
+
+// PromoRepo implements RepoManager.
+func (r *repoManager) PromoRepo() repository.PromoRepository {
+	return repository.NewPromoRepository(r.infra.Conn())
+}
+
+// DisputeRepo implements RepoManager.
+func (r *repoManager) DisputeRepo() repository.DisputeRepository {
+	return repository.NewDisputeRepository(r.infra.Conn())
+}
+
+// StatementRepo implements RepoManager.
+func (r *repoManager) StatementRepo() repository.StatementRepository {
+	return repository.NewStatementRepository(r.infra.Conn())
+}
+
+// ReportRepo implements RepoManager.
+func (r *repoManager) ReportRepo() repository.ReportRepository {
+	return repository.NewReportRepository(r.infra.Conn())
 }
 
 // TransactionRepo implements RepoManager.
 func (r *repoManager) TransactionRepo() repository.TransactionRepository {
-	return repository.NewTransactionRepository(r.infra.Conn())
+	return repository.NewTransactionRepository(r.infra.Conn(), r.cfg.LimitLocation)
 }
 
 // MerchantRepo implements RepoManager.
@@ -32,6 +144,6 @@ func (r *repoManager) UserRepo() repository.UserRepository {
 	return repository.NewUserRepository(r.infra.Conn())
 }
 
-func NewRepoManager(infra InfraManager) RepoManager {
-	return &repoManager{infra: infra}
+func NewRepoManager(infra InfraManager, cfg *config.Config) RepoManager {
+	return &repoManager{infra: infra, cfg: cfg}
 }
@@ -1,7 +1,13 @@
 package manager
 
 import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/albar2305/payment-app/config"
 	"github.com/albar2305/payment-app/usecase"
+	"github.com/albar2305/payment-app/utils/bank"
+	"github.com/albar2305/payment-app/utils/gateway"
 )
 
 type UseCaseManager interface {
@@ -9,15 +15,138 @@ type UseCaseManager interface {
 	CustomerUseCase() usecase.CustomerUseCase
 	MerchantUseCase() usecase.MerchantUseCase
 	TransactionUseCase() usecase.TransactionUseCase
+	LedgerUseCase() usecase.LedgerUseCase
+	IdempotencyUseCase() usecase.IdempotencyUseCase
+	RefundUseCase() usecase.RefundUseCase
+	TransferUseCase() usecase.TransferUseCase
+	FxRateUseCase() usecase.FxRateUseCase
+	PayoutUseCase() usecase.PayoutUseCase
+	FeeUseCase() usecase.FeeUseCase
+	ReconciliationUseCase() usecase.ReconciliationUseCase
+	TopUpUseCase() usecase.TopUpUseCase
+	SubscriptionUseCase() usecase.SubscriptionUseCase
+	InvoiceUseCase() usecase.InvoiceUseCase
+	QRUseCase() usecase.QRUseCase
+	LimitUseCase() usecase.LimitUseCase
+	FraudUseCase() usecase.FraudUseCase
+	WalletUseCase() usecase.WalletUseCase
+	PromoUseCase() usecase.PromoUseCase
+	DisputeUseCase() usecase.DisputeUseCase
+	StatementUseCase() usecase.StatementUseCase
+	ReportUseCase() usecase.ReportUseCase
+	PaymentGateway() gateway.Gateway
 }
 
 type useCaseManager struct {
-	repoManager RepoManager
+	repoManager  RepoManager
+	cfg          *config.Config
+	bankProvider bank.Provider
+	gateway      gateway.Gateway
+}
+
+// PaymentGateway implements UseCaseManager.
+func (u *useCaseManager) PaymentGateway() gateway.Gateway {
+	return u.gateway
+}
+
+// RefundUseCase implements UseCaseManager.
+func (u *useCaseManager) RefundUseCase() usecase.RefundUseCase {
+	return usecase.NewRefundUseCase(u.repoManager.RefundRepo(), u.TransactionUseCase())
+}
+
+// IdempotencyUseCase implements UseCaseManager.
+func (u *useCaseManager) IdempotencyUseCase() usecase.IdempotencyUseCase {
+	return usecase.NewIdempotencyUseCase(u.repoManager.IdempotencyRepo())
+}
+
+// LedgerUseCase implements UseCaseManager.
+func (u *useCaseManager) LedgerUseCase() usecase.LedgerUseCase {
+	return usecase.NewLedgerUseCase(u.repoManager.LedgerRepo())
+}
+
+// TransferUseCase implements UseCaseManager.
+func (u *useCaseManager) TransferUseCase() usecase.TransferUseCase {
+	return usecase.NewTransferUseCase(u.repoManager.TransferRepo(), u.UserUseCase(), u.CustomerUseCase())
+}
+
+// FxRateUseCase implements UseCaseManager.
+func (u *useCaseManager) FxRateUseCase() usecase.FxRateUseCase {
+	return usecase.NewFxRateUseCase(u.repoManager.FxRateRepo())
+}
+
+// PayoutUseCase implements UseCaseManager.
+func (u *useCaseManager) PayoutUseCase() usecase.PayoutUseCase {
+	return usecase.NewPayoutUseCase(u.repoManager.PayoutRepo(), u.bankProvider, u.cfg.SettlementPath)
+}
+
+// FeeUseCase implements UseCaseManager.
+func (u *useCaseManager) FeeUseCase() usecase.FeeUseCase {
+	return usecase.NewFeeUseCase(u.repoManager.FeeRuleRepo(), u.MerchantUseCase())
+}
+
+// ReconciliationUseCase implements UseCaseManager.
+func (u *useCaseManager) ReconciliationUseCase() usecase.ReconciliationUseCase {
+	return usecase.NewReconciliationUseCase(u.repoManager.ReconciliationRepo())
+}
+
+// TopUpUseCase implements UseCaseManager.
+func (u *useCaseManager) TopUpUseCase() usecase.TopUpUseCase {
+	return usecase.NewTopUpUseCase(u.repoManager.TopUpRepo(), u.CustomerUseCase(), u.gateway, u.cfg.TopUpOrderTTL)
+}
+
+// SubscriptionUseCase implements UseCaseManager.
+func (u *useCaseManager) SubscriptionUseCase() usecase.SubscriptionUseCase {
+	return usecase.NewSubscriptionUseCase(u.repoManager.SubscriptionRepo(), u.CustomerUseCase(), u.MerchantUseCase(), u.TransactionUseCase(), u.cfg.SubscriptionMaxRetries, u.cfg.SubscriptionRetryBackoff)
+}
+
+// InvoiceUseCase implements UseCaseManager.
+func (u *useCaseManager) InvoiceUseCase() usecase.InvoiceUseCase {
+	return usecase.NewInvoiceUseCase(u.repoManager.InvoiceRepo(), u.MerchantUseCase(), u.TransactionUseCase(), u.cfg.ApiBaseURL)
+}
+
+// QRUseCase implements UseCaseManager.
+func (u *useCaseManager) QRUseCase() usecase.QRUseCase {
+	return usecase.NewQRUseCase(u.MerchantUseCase(), u.InvoiceUseCase(), u.cfg.QRMerchantCity, u.cfg.QRCountryCode)
+}
+
+// LimitUseCase implements UseCaseManager.
+func (u *useCaseManager) LimitUseCase() usecase.LimitUseCase {
+	return usecase.NewLimitUseCase(u.repoManager.SpendingLimitRepo(), u.CustomerUseCase())
+}
+
+// FraudUseCase implements UseCaseManager.
+func (u *useCaseManager) FraudUseCase() usecase.FraudUseCase {
+	return usecase.NewFraudUseCase(u.repoManager.FraudRepo(), u.FxRateUseCase(), u.cfg.AuthorizationTTL, u.cfg.FraudLocation)
+}
+
+// WalletUseCase implements UseCaseManager.
+func (u *useCaseManager) WalletUseCase() usecase.WalletUseCase {
+	return usecase.NewWalletUseCase(u.repoManager.WalletRepo())
+}
+
+// PromoUseCase implements UseCaseManager.
+func (u *useCaseManager) PromoUseCase() usecase.PromoUseCase {
+	return usecase.NewPromoUseCase(u.repoManager.PromoRepo(), u.cfg.PromoCashbackDelay)
+}
+
+// DisputeUseCase implements UseCaseManager.
+func (u *useCaseManager) DisputeUseCase() usecase.DisputeUseCase {
+	return usecase.NewDisputeUseCase(u.repoManager.DisputeRepo(), u.CustomerUseCase(), u.cfg.FilePath, u.cfg.DisputeFilingWindow, u.cfg.DisputeResponseWindow)
+}
+
+// StatementUseCase implements UseCaseManager.
+func (u *useCaseManager) StatementUseCase() usecase.StatementUseCase {
+	return usecase.NewStatementUseCase(u.repoManager.StatementRepo(), u.CustomerUseCase(), filepath.Join(u.cfg.FilePath, "statements"), u.cfg.StatementLocation)
+}
+
+// ReportUseCase implements UseCaseManager.
+func (u *useCaseManager) ReportUseCase() usecase.ReportUseCase {
+	return usecase.NewReportUseCase(u.repoManager.ReportRepo(), u.MerchantUseCase(), u.cfg.ReportLocation)
 }
 
 // TransactionUseCase implements UseCaseManager.
 func (u *useCaseManager) TransactionUseCase() usecase.TransactionUseCase {
-	return usecase.NewTransactionUseCase(u.repoManager.TransactionRepo(), u.UserUseCase(), u.CustomerUseCase(), u.MerchantUseCase())
+	return usecase.NewTransactionUseCase(u.repoManager.TransactionRepo(), u.UserUseCase(), u.CustomerUseCase(), u.MerchantUseCase(), u.FxRateUseCase(), u.FeeUseCase(), u.FraudUseCase(), u.PromoUseCase(), u.cfg.AuthorizationTTL)
 }
 
 // MerchantUseCase implements UseCaseManager.
@@ -35,6 +164,22 @@ func (u *useCaseManager) UserUseCase() usecase.UserUseCase {
 	return usecase.NewUserUseCase(u.repoManager.UserRepo())
 }
 
-func NewUseCaseManager(repoManager RepoManager) UseCaseManager {
-	return &useCaseManager{repoManager: repoManager}
+func NewUseCaseManager(repoManager RepoManager, cfg *config.Config) UseCaseManager {
+	return &useCaseManager{
+		repoManager:  repoManager,
+		cfg:          cfg,
+		bankProvider: bank.NewFakeProvider(),
+		gateway:      newGateway(cfg),
+	}
+}
+
+// newGateway returns the payment gateway named by the config. ReadConfig
+// only accepts providers listed here.
+func newGateway(cfg *config.Config) gateway.Gateway {
+	switch cfg.GatewayProvider {
+	case config.GatewayProviderSimulator:
+		return gateway.NewSimulator(cfg.GatewayWebhookSecret, cfg.ApiBaseURL, cfg.ApiBaseURL+"/api/v1/webhooks/gateway")
+	default:
+		panic(fmt.Sprintf("unknown payment gateway %q", cfg.GatewayProvider))
+	}
 }
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const DefaultCurrency = "IDR"
+
+// CurrencyExponents lists the supported currencies and the number of minor
+// units digits. Every amount is stored in the minor unit of its currency.
+var CurrencyExponents = map[string]int{
+	"IDR": 0,
+	"SGD": 2,
+	"USD": 2,
+}
+
+func IsSupportedCurrency(code string) bool {
+	_, ok := CurrencyExponents[code]
+	return ok
+}
+
+// FxRate says one unit of BaseCurrency is worth Rate units of QuoteCurrency.
+// Rate is a decimal string so it keeps the precision stored in the database.
+type FxRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	UpdatedBy     string    `json:"updated_by"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type SetFxRateRequest struct {
+	BaseCurrency  string `json:"base_currency" binding:"required,len=3"`
+	QuoteCurrency string `json:"quote_currency" binding:"required,len=3"`
+	Rate          string `json:"rate" binding:"required"`
+	UpdatedBy     string `json:"updated_by"`
+}
@@ -3,28 +3,34 @@ package model
 import "time"
 
 type Customer struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Name      string    `json:"name"`
-	Balance   int64     `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	Name           string    `json:"name"`
+	Balance        int64     `json:"balance"`
+	HeldAmount     int64     `json:"held_amount"`
+	Currency       string    `json:"currency"`
+	Tier           string    `json:"tier"`
+	Frozen         bool      `json:"frozen"`
+	FrozenIncoming bool      `json:"frozen_incoming"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type CreateCustomerRequest struct {
-	UserID  string `json:"user_id"`
-	Name    string `json:"name"`
-	Balance int64  `json:"balance"`
-}
-
-type AddCustomerBalanceParams struct {
-	Amount int64  `json:"amount"`
-	ID     string `json:"id"`
+	UserID   string `json:"user_id"`
+	Name     string `json:"name"`
+	Balance  int64  `json:"balance"`
+	Currency string `json:"currency"`
 }
 
 type CustomerResponse struct {
-	ID        string       `json:"id"`
-	User      UserResponse `json:"user_id"`
-	Name      string       `json:"name"`
-	Balance   int64        `json:"balance"`
-	CreatedAt time.Time    `json:"created_at"`
+	ID             string       `json:"id"`
+	User           UserResponse `json:"user_id"`
+	Name           string       `json:"name"`
+	Balance        int64        `json:"balance"`
+	HeldAmount     int64        `json:"held_amount"`
+	Currency       string       `json:"currency"`
+	Tier           string       `json:"tier"`
+	Frozen         bool         `json:"frozen"`
+	FrozenIncoming bool         `json:"frozen_incoming"`
+	CreatedAt      time.Time    `json:"created_at"`
 }
@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// A dispute is opened by the customer and answered by the merchant before
+// support reviews it. Won and lost are from the customer's side: a won
+// dispute charges the amount back to the customer.
+const (
+	DisputeStatusOpen             = "open"
+	DisputeStatusMerchantResponse = "merchant_response"
+	DisputeStatusUnderReview      = "under_review"
+	DisputeStatusWon              = "won"
+	DisputeStatusLost             = "lost"
+)
+
+const (
+	DisputeReasonFraudulent     = "fraudulent"
+	DisputeReasonNotReceived    = "not_received"
+	DisputeReasonNotAsDescribed = "not_as_described"
+	DisputeReasonDuplicate      = "duplicate"
+	DisputeReasonOther          = "other"
+)
+
+const (
+	DisputePartyCustomer = "customer"
+	DisputePartyMerchant = "merchant"
+)
+
+// Dispute contests Amount of a captured payment, in the payment currency.
+// The amount is held on the merchant wallet until the dispute is decided.
+// The merchant must respond before ResponseDueAt, or the dispute goes to
+// review without a response. RefundID is the chargeback of a won dispute.
+type Dispute struct {
+	ID               string            `json:"id"`
+	TransactionID    string            `json:"transaction_id"`
+	CustomerID       string            `json:"customer_id"`
+	MerchantID       string            `json:"merchant_id"`
+	Reason           string            `json:"reason"`
+	Description      string            `json:"description"`
+	Amount           int64             `json:"amount"`
+	Currency         string            `json:"currency"`
+	Status           string            `json:"status"`
+	MerchantResponse string            `json:"merchant_response,omitempty"`
+	ResolutionNote   string            `json:"resolution_note,omitempty"`
+	ResolvedBy       string            `json:"resolved_by,omitempty"`
+	RefundID         string            `json:"refund_id,omitempty"`
+	Evidence         []DisputeEvidence `json:"evidence,omitempty"`
+	ResponseDueAt    time.Time         `json:"response_due_at"`
+	RespondedAt      *time.Time        `json:"responded_at,omitempty"`
+	ResolvedAt       *time.Time        `json:"resolved_at,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// DisputeEvidence is a file uploaded for one side of a dispute. The file is
+// kept on disk at Path.
+type DisputeEvidence struct {
+	ID          string    `json:"id"`
+	DisputeID   string    `json:"dispute_id"`
+	Party       string    `json:"party"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Description string    `json:"description,omitempty"`
+	UploadedBy  string    `json:"uploaded_by"`
+	Path        string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OpenDisputeRequest disputes the whole refundable amount when Amount is zero.
+type OpenDisputeRequest struct {
+	TransactionID string `json:"-"`
+	UserID        string `json:"-"`
+	Reason        string `json:"reason" binding:"required,oneof=fraudulent not_received not_as_described duplicate other"`
+	Description   string `json:"description" binding:"required"`
+	Amount        int64  `json:"amount" binding:"omitempty,gt=0"`
+}
+
+type RespondDisputeRequest struct {
+	Response string `json:"response" binding:"required"`
+}
+
+// ResolveDisputeRequest decides a dispute under review. Outcome is won when
+// the customer gets the money back.
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome" binding:"required,oneof=won lost"`
+	Note    string `json:"note"`
+}
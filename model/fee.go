@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// FeeRule prices payments of one merchant or of every merchant with a
+// business type. PercentageBps is in basis points (1/100 of a percent) and
+// the fixed, min and max fees are in the minor unit of the merchant
+// currency. A zero MaxFee means no cap.
+type FeeRule struct {
+	ID            string    `json:"id"`
+	MerchantID    string    `json:"merchant_id,omitempty"`
+	BusinessType  string    `json:"business_type,omitempty"`
+	PercentageBps int64     `json:"percentage_bps"`
+	FixedFee      int64     `json:"fixed_fee"`
+	MinFee        int64     `json:"min_fee"`
+	MaxFee        int64     `json:"max_fee"`
+	Tiers         []FeeTier `json:"tiers"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// FeeTier replaces the percentage and fixed fee of its rule once the
+// merchant has captured at least MinMonthlyVolume in the current month.
+type FeeTier struct {
+	MinMonthlyVolume int64 `json:"min_monthly_volume" binding:"gt=0"`
+	PercentageBps    int64 `json:"percentage_bps" binding:"gte=0,lte=10000"`
+	FixedFee         int64 `json:"fixed_fee" binding:"gte=0"`
+}
+
+type CreateFeeRuleRequest struct {
+	MerchantID    string    `json:"merchant_id" binding:"required_without=BusinessType,excluded_with=BusinessType"`
+	BusinessType  string    `json:"business_type" binding:"required_without=MerchantID"`
+	PercentageBps int64     `json:"percentage_bps" binding:"gte=0,lte=10000"`
+	FixedFee      int64     `json:"fixed_fee" binding:"gte=0"`
+	MinFee        int64     `json:"min_fee" binding:"gte=0"`
+	MaxFee        int64     `json:"max_fee" binding:"gte=0"`
+	Tiers         []FeeTier `json:"tiers" binding:"dive"`
+}
+
+type FeeQuote struct {
+	MerchantID string `json:"merchant_id"`
+	Amount     int64  `json:"amount"`
+	Fee        int64  `json:"fee"`
+	Net        int64  `json:"net"`
+	Currency   string `json:"currency"`
+	FeeRuleID  string `json:"fee_rule_id,omitempty"`
+}
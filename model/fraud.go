@@ -0,0 +1,108 @@
+package model
+
+import "time"
+
+// Fraud screening decides whether a payment goes through, waits for an admin
+// in the review queue, or is refused.
+const (
+	FraudDecisionAllow  = "allow"
+	FraudDecisionReview = "review"
+	FraudDecisionBlock  = "block"
+)
+
+const (
+	// FraudRuleNewAccountLargeAmount flags payments of at least MinAmount
+	// from customers younger than AccountAgeHours.
+	FraudRuleNewAccountLargeAmount = "new_account_large_amount"
+	// FraudRuleRapidRepeatMerchant flags a customer who already paid the same
+	// merchant MaxCount times in the last WindowMinutes.
+	FraudRuleRapidRepeatMerchant = "rapid_repeat_merchant"
+	// FraudRuleNearLimit flags payments that take the customer to
+	// LimitPercent or more of a spending limit without breaking it.
+	FraudRuleNearLimit = "near_limit"
+	// FraudRuleUnusualHours flags payments of at least MinAmount made from
+	// StartHour up to EndHour, local time.
+	FraudRuleUnusualHours = "unusual_hours"
+)
+
+const (
+	FraudReviewStatusPending  = "pending"
+	FraudReviewStatusApproved = "approved"
+	FraudReviewStatusRejected = "rejected"
+)
+
+// FraudRule is one of the fixed screening rules. Only the fields named in the
+// description of the rule are used. Amounts are in the minor unit of
+// DefaultCurrency.
+type FraudRule struct {
+	Rule            string    `json:"rule"`
+	Action          string    `json:"action"`
+	Enabled         bool      `json:"enabled"`
+	MinAmount       int64     `json:"min_amount"`
+	AccountAgeHours int       `json:"account_age_hours"`
+	WindowMinutes   int       `json:"window_minutes"`
+	MaxCount        int       `json:"max_count"`
+	LimitPercent    int       `json:"limit_percent"`
+	StartHour       int       `json:"start_hour"`
+	EndHour         int       `json:"end_hour"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type SetFraudRuleRequest struct {
+	Action          string `json:"action" binding:"required,oneof=review block"`
+	Enabled         *bool  `json:"enabled" binding:"required"`
+	MinAmount       int64  `json:"min_amount" binding:"gte=0"`
+	AccountAgeHours int    `json:"account_age_hours" binding:"gte=0"`
+	WindowMinutes   int    `json:"window_minutes" binding:"gte=0"`
+	MaxCount        int    `json:"max_count" binding:"gte=0"`
+	LimitPercent    int    `json:"limit_percent" binding:"gte=0,lte=100"`
+	StartHour       int    `json:"start_hour" binding:"gte=0,lte=23"`
+	EndHour         int    `json:"end_hour" binding:"gte=0,lte=23"`
+}
+
+// FraudSignals is what screening knows about the paying customer.
+// MerchantPayments counts the recent payments to each merchant of the
+// payment, and PaymentCount all payments the customer ever made.
+type FraudSignals struct {
+	AccountCreatedAt time.Time
+	PaymentCount     int64
+	MerchantPayments map[string]int64
+	Limits           SpendingLimit
+	Usage            LimitUsage
+}
+
+// FraudReason is a rule that matched a payment.
+type FraudReason struct {
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	Detail string `json:"detail"`
+}
+
+// FraudAssessment is the outcome of screening a payment, the strictest action
+// of the rules that matched. PaymentCount is the number of payments of the
+// customer the rules saw.
+type FraudAssessment struct {
+	Decision     string        `json:"decision"`
+	Reasons      []FraudReason `json:"reasons"`
+	PaymentCount int64         `json:"-"`
+}
+
+// FraudReview is a payment waiting in, or decided from, the review queue.
+type FraudReview struct {
+	ID            string        `json:"id"`
+	TransactionID string        `json:"transaction_id"`
+	CustomerID    string        `json:"customer_id"`
+	MerchantID    string        `json:"merchant_id"`
+	Amount        int64         `json:"amount"`
+	Currency      string        `json:"currency"`
+	Reasons       []FraudReason `json:"reasons"`
+	Status        string        `json:"status"`
+	Note          string        `json:"note"`
+	ReviewedBy    string        `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time    `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type DecideFraudReviewRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// IdempotencyKey stores the outcome of the first request sent with a given
+// Idempotency-Key header. StatusCode is zero while that request is running.
+type IdempotencyKey struct {
+	Key          string    `json:"key"`
+	UserID       string    `json:"user_id"`
+	RequestPath  string    `json:"request_path"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody []byte    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
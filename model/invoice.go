@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+const (
+	InvoiceStatusOpen = "open"
+	// An invoice whose payment is held for fraud review takes no other
+	// payment. It is paid when the payment is approved and open again when
+	// it is rejected.
+	InvoiceStatusInReview  = "in_review"
+	InvoiceStatusPaid      = "paid"
+	InvoiceStatusExpired   = "expired"
+	InvoiceStatusCancelled = "cancelled"
+)
+
+// Invoice is a payment request from a merchant. Any logged in customer can
+// pay an open invoice through its Code before ExpiresAt. Amount is in the
+// merchant currency.
+type Invoice struct {
+	ID               string        `json:"id"`
+	MerchantID       string        `json:"merchant_id"`
+	Code             string        `json:"code"`
+	PaymentLink      string        `json:"payment_link"`
+	Amount           int64         `json:"amount"`
+	Currency         string        `json:"currency"`
+	Description      string        `json:"description"`
+	Items            []InvoiceItem `json:"items,omitempty"`
+	Status           string        `json:"status"`
+	TransactionID    string        `json:"transaction_id,omitempty"`
+	PaidByCustomerID string        `json:"paid_by_customer_id,omitempty"`
+	ExpiresAt        time.Time     `json:"expires_at"`
+	PaidAt           *time.Time    `json:"paid_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+type InvoiceItem struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	Amount      int64  `json:"amount"`
+}
+
+// CreateInvoiceRequest takes either Amount or Items; with items the amount is
+// their total.
+type CreateInvoiceRequest struct {
+	MerchantID  string                     `json:"merchant_id"`
+	Amount      int64                      `json:"amount" binding:"omitempty,gt=0"`
+	Description string                     `json:"description"`
+	Items       []CreateInvoiceItemRequest `json:"items" binding:"omitempty,dive"`
+	ExpiresAt   *time.Time                 `json:"expires_at"`
+}
+
+type CreateInvoiceItemRequest struct {
+	Description string `json:"description" binding:"required"`
+	Quantity    int64  `json:"quantity" binding:"required,gt=0"`
+	UnitPrice   int64  `json:"unit_price" binding:"required,gt=0"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+const (
+	AccountOwnerCustomer = "customer"
+	AccountOwnerMerchant = "merchant"
+	AccountOwnerSystem   = "system"
+)
+
+// System accounts act as the counterparty for money entering or leaving wallets.
+const (
+	SystemAccountTopUp      = "top_up"
+	SystemAccountAdjustment = "adjustment"
+	SystemAccountFx         = "fx"
+	SystemAccountFees       = "fees"
+	// SystemAccountPromotions pays the cashback of promo campaigns.
+	SystemAccountPromotions = "promotions"
+	// SystemAccountPayoutsPending holds merchant money between a payout
+	// request and the bank transfer; SystemAccountBankSettlement is money
+	// that has left the platform.
+	SystemAccountPayoutsPending = "payouts_pending"
+	SystemAccountBankSettlement = "bank_settlement"
+)
+
+const (
+	JournalKindTopUp      = "top_up"
+	JournalKindPayment    = "payment"
+	JournalKindAdjustment = "adjustment"
+	JournalKindRefund     = "refund"
+	JournalKindTransfer   = "transfer"
+	JournalKindPayout     = "payout"
+	JournalKindPayoutPaid = "payout_paid"
+	JournalKindPayoutFail = "payout_failed"
+	JournalKindCashback   = "cashback"
+)
+
+type LedgerAccount struct {
+	ID        string    `json:"id"`
+	OwnerType string    `json:"owner_type"`
+	OwnerID   string    `json:"owner_id"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	ReferenceID string    `json:"reference_id"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Posting is one leg of a journal entry. A positive amount increases the
+// account balance, a negative amount decreases it. Postings of an entry must
+// sum to zero per currency.
+type Posting struct {
+	ID             string    `json:"id"`
+	JournalEntryID string    `json:"journal_entry_id"`
+	AccountID      string    `json:"account_id"`
+	OwnerType      string    `json:"owner_type"`
+	OwnerID        string    `json:"owner_id"`
+	Currency       string    `json:"currency"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CreateAdjustmentRequest struct {
+	OwnerType   string `json:"owner_type" binding:"required,oneof=customer merchant"`
+	OwnerID     string `json:"owner_id" binding:"required"`
+	Amount      int64  `json:"amount" binding:"required"`
+	Description string `json:"description" binding:"required"`
+}
+
+type BalanceMismatch struct {
+	OwnerType     string `json:"owner_type"`
+	OwnerID       string `json:"owner_id"`
+	Currency      string `json:"currency"`
+	StoredBalance int64  `json:"stored_balance"`
+	LedgerBalance int64  `json:"ledger_balance"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+const (
+	CustomerTierBasic    = "basic"
+	CustomerTierVerified = "verified"
+)
+
+func IsCustomerTier(tier string) bool {
+	return tier == CustomerTierBasic || tier == CustomerTierVerified
+}
+
+// Limits are counted separately for payments and for top-ups.
+const (
+	LimitKindPayment = "payment"
+	LimitKindTopUp   = "top_up"
+)
+
+// SpendingLimit holds the limits of a customer tier in one currency, or the
+// override of one customer in the customer currency. Amounts are in the
+// minor unit of Currency. A nil field means no limit; in an override it
+// falls back to the tier.
+type SpendingLimit struct {
+	ID              string    `json:"id,omitempty"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	Tier            string    `json:"tier,omitempty"`
+	Currency        string    `json:"currency"`
+	MaxSingleAmount *int64    `json:"max_single_amount"`
+	DailyAmount     *int64    `json:"daily_amount"`
+	MonthlyAmount   *int64    `json:"monthly_amount"`
+	HourlyCount     *int64    `json:"hourly_count"`
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+}
+
+type SetSpendingLimitRequest struct {
+	Currency        string `json:"currency" binding:"omitempty,len=3"`
+	MaxSingleAmount *int64 `json:"max_single_amount" binding:"omitempty,gt=0"`
+	DailyAmount     *int64 `json:"daily_amount" binding:"omitempty,gt=0"`
+	MonthlyAmount   *int64 `json:"monthly_amount" binding:"omitempty,gt=0"`
+	HourlyCount     *int64 `json:"hourly_count" binding:"omitempty,gt=0"`
+}
+
+type SetCustomerTierRequest struct {
+	Tier string `json:"tier" binding:"required,oneof=basic verified"`
+}
+
+// LimitUsage is what a customer has used of the daily, monthly and hourly
+// limits of one kind.
+type LimitUsage struct {
+	DailyAmount   int64 `json:"daily_amount"`
+	MonthlyAmount int64 `json:"monthly_amount"`
+	HourlyCount   int64 `json:"hourly_count"`
+}
+
+// CustomerLimits are the limits in effect for a customer and how much of
+// them is used.
+type CustomerLimits struct {
+	CustomerID string        `json:"customer_id"`
+	Tier       string        `json:"tier"`
+	Limits     SpendingLimit `json:"limits"`
+	Overridden bool          `json:"overridden"`
+	Payments   LimitUsage    `json:"payments"`
+	TopUps     LimitUsage    `json:"top_ups"`
+}
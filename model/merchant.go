@@ -3,12 +3,16 @@ package model
 import "time"
 
 type Merchant struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"desc"`
-	BusinesType string    `json:"busines_type"`
-	Balance     int64     `json:"balance"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"desc"`
+	BusinesType    string    `json:"busines_type"`
+	Balance        int64     `json:"balance"`
+	HeldAmount     int64     `json:"held_amount"`
+	Currency       string    `json:"currency"`
+	Frozen         bool      `json:"frozen"`
+	FrozenIncoming bool      `json:"frozen_incoming"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type CreateMerchantRequest struct {
@@ -16,4 +20,5 @@ type CreateMerchantRequest struct {
 	Description string `json:"description"`
 	BusinesType string `json:"busines_type"`
 	Balance     int64  `json:"balance"`
+	Currency    string `json:"currency"`
 }
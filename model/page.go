@@ -1,6 +1,97 @@
 package model
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// PaginationParams selects a page by Offset, or with Keyset by Cursor. A
+// keyset listing starts at the first row when Cursor is nil and reads one row
+// more than Limit, which tells NewPage whether another page follows.
 type PaginationParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Limit  int32   `json:"limit"`
+	Offset int32   `json:"offset"`
+	Keyset bool    `json:"-"`
+	Cursor *Cursor `json:"-"`
+}
+
+// Cursor points at the row a keyset page continues from: the page holds the
+// rows after it, or the rows before it when Before is set. Value is the sort
+// column of that row as text, and Sort names the sorting of the listing it
+// came from when the listing can be sorted more than one way.
+type Cursor struct {
+	Sort   string `json:"s,omitempty"`
+	Value  string `json:"v"`
+	ID     string `json:"id"`
+	Before bool   `json:"b,omitempty"`
+}
+
+// CreatedAtCursor points at a row of a listing sorted by creation time.
+func CreatedAtCursor(createdAt time.Time, id string) Cursor {
+	return Cursor{Value: createdAt.Format(time.RFC3339Nano), ID: id}
+}
+
+// Encode returns the cursor as an opaque string for clients.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor reads a cursor returned by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Cursor{}, err
+	}
+	return c, nil
+}
+
+// Page is one page of a keyset listing with the cursors of the pages around
+// it. A cursor is empty when there is no page that way.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// NewPage makes a page of the rows read for params, in the order the
+// database returned them. key gives the cursor pointing at a row.
+func NewPage[T any](rows []T, params PaginationParams, key func(T) Cursor) Page[T] {
+	before := params.Cursor != nil && params.Cursor.Before
+	more := len(rows) > int(params.Limit)
+	if more {
+		rows = rows[:params.Limit]
+	}
+	// Rows before the cursor are read backwards.
+	if before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := Page[T]{Data: rows}
+	if len(rows) == 0 {
+		page.Data = []T{}
+		return page
+	}
+	// Coming from a cursor means there are rows on the side it came from.
+	if more || before {
+		page.NextCursor = key(rows[len(rows)-1]).Encode()
+	}
+	if (more && before) || (!before && params.Cursor != nil) {
+		prev := key(rows[0])
+		prev.Before = true
+		page.PrevCursor = prev.Encode()
+	}
+	return page
 }
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	PayoutStatusPending    = "pending"
+	PayoutStatusProcessing = "processing"
+	PayoutStatusPaid       = "paid"
+	PayoutStatusFailed     = "failed"
+)
+
+const (
+	SettlementStatusProcessing = "processing"
+	SettlementStatusCompleted  = "completed"
+)
+
+type Payout struct {
+	ID                string    `json:"id"`
+	MerchantID        string    `json:"merchant_id"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	BankCode          string    `json:"bank_code"`
+	AccountNumber     string    `json:"account_number"`
+	AccountName       string    `json:"account_name"`
+	Status            string    `json:"status"`
+	BatchID           string    `json:"batch_id,omitempty"`
+	ProviderReference string    `json:"provider_reference,omitempty"`
+	FailureReason     string    `json:"failure_reason,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type CreatePayoutRequest struct {
+	MerchantID    string `json:"merchant_id"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	BankCode      string `json:"bank_code" binding:"required"`
+	AccountNumber string `json:"account_number" binding:"required,numeric"`
+	AccountName   string `json:"account_name" binding:"required"`
+}
+
+// SettlementBatch groups the payouts requested on one day so finance can
+// review them in a single settlement file.
+type SettlementBatch struct {
+	ID             string     `json:"id"`
+	SettlementDate time.Time  `json:"settlement_date"`
+	Status         string     `json:"status"`
+	PayoutCount    int        `json:"payout_count"`
+	FilePath       string     `json:"file_path,omitempty"`
+	Payouts        []Payout   `json:"payouts,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// Cursor points at b in a listing of batches, latest settlement date first.
+func (b SettlementBatch) Cursor() Cursor {
+	return Cursor{Value: b.SettlementDate.Format(time.DateOnly), ID: b.ID}
+}
+
+type RunSettlementRequest struct {
+	Date string `json:"date" binding:"omitempty,datetime=2006-01-02"`
+}
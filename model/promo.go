@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+const (
+	PromoRewardDiscount = "discount"
+	PromoRewardCashback = "cashback"
+)
+
+// A discount is applied when the payment is made; cashback waits pending
+// until the payment has settled and is then credited to the customer.
+const (
+	PromoRedemptionStatusApplied   = "applied"
+	PromoRedemptionStatusPending   = "pending"
+	PromoRedemptionStatusCredited  = "credited"
+	PromoRedemptionStatusCancelled = "cancelled"
+)
+
+// PromoCampaign is a promo code customers enter when paying a merchant. The
+// reward is Amount * PercentageBps / 10000 + FixedAmount, capped at MaxReward
+// when it is not zero. Amounts are in the minor unit of Currency, and only
+// payments in that currency are eligible. Empty MerchantIDs and
+// BusinessTypes make every merchant eligible. Zero usage caps mean no cap.
+type PromoCampaign struct {
+	ID                 string     `json:"id"`
+	Code               string     `json:"code"`
+	Name               string     `json:"name"`
+	RewardType         string     `json:"reward_type"`
+	Currency           string     `json:"currency"`
+	PercentageBps      int64      `json:"percentage_bps"`
+	FixedAmount        int64      `json:"fixed_amount"`
+	MaxReward          int64      `json:"max_reward"`
+	MinSpend           int64      `json:"min_spend"`
+	MaxUses            int64      `json:"max_uses"`
+	MaxUsesPerCustomer int64      `json:"max_uses_per_customer"`
+	UsedCount          int64      `json:"used_count"`
+	MerchantIDs        []string   `json:"merchant_ids"`
+	BusinessTypes      []string   `json:"business_types"`
+	StartsAt           time.Time  `json:"starts_at"`
+	EndsAt             *time.Time `json:"ends_at,omitempty"`
+	Active             bool       `json:"active"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+type PromoCampaignRequest struct {
+	Code               string     `json:"code" binding:"required,alphanum,max=50"`
+	Name               string     `json:"name" binding:"required"`
+	RewardType         string     `json:"reward_type" binding:"required,oneof=discount cashback"`
+	Currency           string     `json:"currency" binding:"omitempty,len=3"`
+	PercentageBps      int64      `json:"percentage_bps" binding:"gte=0,lte=10000"`
+	FixedAmount        int64      `json:"fixed_amount" binding:"gte=0"`
+	MaxReward          int64      `json:"max_reward" binding:"gte=0"`
+	MinSpend           int64      `json:"min_spend" binding:"gte=0"`
+	MaxUses            int64      `json:"max_uses" binding:"gte=0"`
+	MaxUsesPerCustomer int64      `json:"max_uses_per_customer" binding:"gte=0"`
+	MerchantIDs        []string   `json:"merchant_ids"`
+	BusinessTypes      []string   `json:"business_types"`
+	StartsAt           *time.Time `json:"starts_at"`
+	EndsAt             *time.Time `json:"ends_at"`
+	Active             *bool      `json:"active"`
+}
+
+// PromoRedemption is the use of a campaign by one payment. Amount is the
+// reward in the payment currency; CreditedAmount is the cashback credited in
+// the customer currency.
+type PromoRedemption struct {
+	ID             string     `json:"id"`
+	CampaignID     string     `json:"campaign_id"`
+	Code           string     `json:"code"`
+	CustomerID     string     `json:"customer_id"`
+	TransactionID  string     `json:"transaction_id"`
+	RewardType     string     `json:"reward_type"`
+	Amount         int64      `json:"amount"`
+	Currency       string     `json:"currency"`
+	CreditedAmount int64      `json:"credited_amount,omitempty"`
+	Status         string     `json:"status"`
+	CreditedAt     *time.Time `json:"credited_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package model
+
+const (
+	QRTypeStatic  = "static"
+	QRTypeDynamic = "dynamic"
+)
+
+// QRCode is an EMVCo merchant presented payload. A static code belongs to a
+// merchant and the customer enters the amount; a dynamic code belongs to an
+// invoice and carries its amount.
+type QRCode struct {
+	Type       string `json:"type"`
+	MerchantID string `json:"merchant_id"`
+	InvoiceID  string `json:"invoice_id,omitempty"`
+	Payload    string `json:"payload"`
+}
+
+type ParseQRRequest struct {
+	Payload string `json:"payload" binding:"required"`
+}
+
+// QRPaymentPreview is what a scanned payload would pay. Static codes are paid
+// with a transaction to MerchantID; dynamic codes through the payment link of
+// InvoiceCode.
+type QRPaymentPreview struct {
+	Type          string `json:"type"`
+	MerchantID    string `json:"merchant_id"`
+	MerchantName  string `json:"merchant_name"`
+	Amount        int64  `json:"amount,omitempty"`
+	Currency      string `json:"currency"`
+	InvoiceCode   string `json:"invoice_code,omitempty"`
+	InvoiceStatus string `json:"invoice_status,omitempty"`
+	PaymentLink   string `json:"payment_link,omitempty"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// ReconciliationReport compares every wallet's stored balance with the
+// balance expected from its business records (top-ups, payments, refunds,
+// transfers, payouts, adjustments) and with its ledger postings.
+type ReconciliationReport struct {
+	ID             string           `json:"id"`
+	WalletsChecked int              `json:"wallets_checked"`
+	MismatchCount  int              `json:"mismatch_count"`
+	Mismatches     []WalletMismatch `json:"mismatches"`
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     time.Time        `json:"finished_at"`
+}
+
+type WalletMismatch struct {
+	OwnerType       string           `json:"owner_type"`
+	OwnerID         string           `json:"owner_id"`
+	Currency        string           `json:"currency"`
+	StoredBalance   int64            `json:"stored_balance"`
+	ExpectedBalance int64            `json:"expected_balance"`
+	LedgerBalance   int64            `json:"ledger_balance"`
+	Records         []RecordMismatch `json:"records"`
+}
+
+// RecordMismatch is a business record whose effect on a wallet differs from
+// what the ledger posted for it.
+type RecordMismatch struct {
+	RecordType     string `json:"record_type"`
+	RecordID       string `json:"record_id"`
+	ExpectedAmount int64  `json:"expected_amount"`
+	PostedAmount   int64  `json:"posted_amount"`
+}
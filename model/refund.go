@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Refund returns Amount to the customer, who receives SourceAmount in the
+// wallet currency. Fee is the share of the original payment fee the platform
+// gives back, so the merchant only pays Amount minus Fee.
+type Refund struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transaction_id"`
+	Amount        int64     `json:"amount"`
+	Fee           int64     `json:"fee"`
+	SourceAmount  int64     `json:"source_amount"`
+	Reason        string    `json:"reason"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CreateRefundRequest refunds the remaining refundable amount when Amount is zero.
+type CreateRefundRequest struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        int64  `json:"amount" binding:"omitempty,gt=0"`
+	Reason        string `json:"reason"`
+	CreatedBy     string `json:"created_by"`
+}
+
+type RefundHistory struct {
+	Transaction Transaction `json:"transaction"`
+	Refunds     []Refund    `json:"refunds"`
+}
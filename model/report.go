@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+const (
+	ReportIntervalDay   = "day"
+	ReportIntervalWeek  = "week"
+	ReportIntervalMonth = "month"
+)
+
+// SalesReportRequest asks for the sales from From to To, both inclusive and
+// given as YYYY-MM-DD in TimeZone, bucketed by Interval. Without MerchantID
+// the report covers every merchant, grouped by business type.
+type SalesReportRequest struct {
+	MerchantID string `json:"merchant_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Interval   string `json:"interval"`
+	TimeZone   string `json:"time_zone"`
+}
+
+// SalesReport sums captured payments and refunds over [PeriodStart,
+// PeriodEnd). Rows hold one bucket each and leave out buckets without
+// activity; Totals sum the rows per business type and currency.
+type SalesReport struct {
+	MerchantID  string           `json:"merchant_id,omitempty"`
+	Interval    string           `json:"interval"`
+	TimeZone    string           `json:"time_zone"`
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Rows        []SalesReportRow `json:"rows"`
+	Totals      []SalesReportRow `json:"totals"`
+}
+
+// SalesReportRow is the activity of one bucket in the merchant currency.
+// Payments count on the day they were made and refunds on the day they were
+// made, so a bucket can refund more than it sold. Fees are those charged on
+// the payments less those given back by the refunds, and Net is what the
+// merchant kept: Gross minus Refunds minus Fees.
+type SalesReportRow struct {
+	PeriodStart   *time.Time `json:"period_start,omitempty"`
+	BusinesType   string     `json:"busines_type,omitempty"`
+	Currency      string     `json:"currency"`
+	Count         int64      `json:"count"`
+	Gross         int64      `json:"gross"`
+	AverageTicket int64      `json:"average_ticket"`
+	RefundCount   int64      `json:"refund_count"`
+	Refunds       int64      `json:"refunds"`
+	Fees          int64      `json:"fees"`
+	Net           int64      `json:"net"`
+}
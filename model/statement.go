@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+const (
+	StatementFormatJSON = "json"
+	StatementFormatCSV  = "csv"
+	StatementFormatPDF  = "pdf"
+)
+
+// Statement is the wallet activity of a customer over [PeriodStart,
+// PeriodEnd), from the ledger. Balance on each line is the running balance
+// after it. Monthly statements are generated in the background and have an
+// ID; their lines are only kept in the generated files.
+type Statement struct {
+	ID             string          `json:"id,omitempty"`
+	CustomerID     string          `json:"customer_id"`
+	CustomerName   string          `json:"customer_name"`
+	Currency       string          `json:"currency"`
+	PeriodStart    time.Time       `json:"period_start"`
+	PeriodEnd      time.Time       `json:"period_end"`
+	OpeningBalance int64           `json:"opening_balance"`
+	TotalIn        int64           `json:"total_in"`
+	TotalOut       int64           `json:"total_out"`
+	ClosingBalance int64           `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines,omitempty"`
+	CSVPath        string          `json:"-"`
+	PDFPath        string          `json:"-"`
+	CreatedAt      *time.Time      `json:"created_at,omitempty"`
+}
+
+// Cursor points at s in a listing of statements, latest period first.
+func (s Statement) Cursor() Cursor {
+	return Cursor{Value: s.PeriodStart.Format(time.RFC3339Nano), ID: s.ID}
+}
+
+// StatementLine is one movement of the wallet. Kind is the journal kind, and
+// Counterparty names the merchant or customer on the other side, if any.
+type StatementLine struct {
+	Date         time.Time `json:"date"`
+	Kind         string    `json:"kind"`
+	ReferenceID  string    `json:"reference_id"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Description  string    `json:"description"`
+	Amount       int64     `json:"amount"`
+	Balance      int64     `json:"balance"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+const (
+	SubscriptionIntervalDaily   = "daily"
+	SubscriptionIntervalWeekly  = "weekly"
+	SubscriptionIntervalMonthly = "monthly"
+)
+
+const (
+	SubscriptionAmountFixed    = "fixed"
+	SubscriptionAmountVariable = "variable"
+)
+
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPaused    = "paused"
+	SubscriptionStatusPastDue   = "past_due"
+	SubscriptionStatusCancelled = "cancelled"
+)
+
+const (
+	SubscriptionChargeSucceeded = "succeeded"
+	// A charge whose payment is held for fraud review. The period is not
+	// charged again meanwhile, and moves on once the payment is approved.
+	SubscriptionChargePending = "pending"
+	SubscriptionChargeFailed  = "failed"
+	SubscriptionChargeSkipped = "skipped"
+)
+
+// Subscription lets a merchant charge a customer every interval. Fixed
+// subscriptions charge Amount; variable ones charge NextAmount, which the
+// merchant sets before each charge and which may not exceed MaxAmount.
+// Amounts are in the merchant currency. NextChargeAt is the billing date of
+// the current period; a failed charge is retried at RetryAt.
+type Subscription struct {
+	ID           string     `json:"id"`
+	CustomerID   string     `json:"customer_id"`
+	MerchantID   string     `json:"merchant_id"`
+	Interval     string     `json:"interval"`
+	AmountType   string     `json:"amount_type"`
+	Amount       int64      `json:"amount,omitempty"`
+	MaxAmount    int64      `json:"max_amount,omitempty"`
+	NextAmount   *int64     `json:"next_amount,omitempty"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	NextChargeAt time.Time  `json:"next_charge_at"`
+	RetryAt      *time.Time `json:"retry_at,omitempty"`
+	RetryCount   int        `json:"retry_count"`
+	LastError    string     `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// SubscriptionCharge is one attempt to charge a subscription. NextChargeAt is
+// the billing date the subscription moves to when the charge succeeds.
+type SubscriptionCharge struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	TransactionID  string    `json:"transaction_id,omitempty"`
+	Amount         int64     `json:"amount"`
+	Status         string    `json:"status"`
+	Attempt        int       `json:"attempt"`
+	FailureReason  string    `json:"failure_reason,omitempty"`
+	NextChargeAt   time.Time `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CreateSubscriptionRequest struct {
+	UserID     string     `json:"user_id"`
+	MerchantID string     `json:"merchant_id" binding:"required"`
+	Interval   string     `json:"interval" binding:"required,oneof=daily weekly monthly"`
+	AmountType string     `json:"amount_type" binding:"required,oneof=fixed variable"`
+	Amount     int64      `json:"amount" binding:"required_if=AmountType fixed,gte=0"`
+	MaxAmount  int64      `json:"max_amount" binding:"required_if=AmountType variable,gte=0"`
+	StartAt    *time.Time `json:"start_at"`
+}
+
+type SetNextAmountRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
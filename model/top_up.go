@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const (
+	TopUpStatusPending = "pending"
+	TopUpStatusPaid    = "paid"
+	TopUpStatusFailed  = "failed"
+	TopUpStatusExpired = "expired"
+)
+
+// TopUpOrder is a wallet top-up waiting for the payment gateway. The wallet
+// is only credited when the gateway confirms the payment.
+type TopUpOrder struct {
+	ID               string     `json:"id"`
+	CustomerID       string     `json:"customer_id"`
+	Amount           int64      `json:"amount"`
+	Currency         string     `json:"currency"`
+	Status           string     `json:"status"`
+	GatewayReference string     `json:"gateway_reference,omitempty"`
+	CheckoutURL      string     `json:"checkout_url,omitempty"`
+	FailureReason    string     `json:"failure_reason,omitempty"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	PaidAt           *time.Time `json:"paid_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type CreateTopUpRequest struct {
+	UserID string `json:"user_id"`
+	Amount int64  `json:"amount" binding:"required,gt=0"`
+}
+
+type SimulateChargeRequest struct {
+	Status string `json:"status" binding:"required,oneof=paid failed expired"`
+}
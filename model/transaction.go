@@ -1,25 +1,152 @@
 package model
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
+const (
+	TransactionStatusAuthorized = "authorized"
+	TransactionStatusCaptured   = "captured"
+	TransactionStatusVoided     = "voided"
+	TransactionStatusExpired    = "expired"
+	// A payment flagged by fraud screening holds the funds until an admin
+	// approves it, or is rejected and releases them.
+	TransactionStatusPendingReview = "pending_review"
+	TransactionStatusRejected      = "rejected"
+)
+
+// Transaction is a payment from a customer to a merchant. Amount is the
+// authorized amount in the merchant currency; CapturedAmount is what actually
+// settled to the merchant. SourceAmount is the authorized amount converted to
+// the customer currency at FxRate, which is empty when no conversion applied.
+// Fee is kept by the platform out of CapturedAmount, so the merchant is
+// credited CapturedAmount minus Fee. InvoiceID is set when the payment paid
+// a merchant invoice. Discount is what a promo code took off the price, so
+// Amount is already discounted. SubscriptionCharge is set on a payment made
+// by a subscription, which is recorded together with the payment.
+// ScreenedPayments is the number of payments of the customer fraud screening
+// saw; the payment is only stored if no other payment came in since.
+//
+// A split payment is a parent transaction without a receiver merchant whose
+// Legs pay each merchant. The amounts of the parent are the totals of its
+// legs, and refunds are made against the legs.
 type Transaction struct {
-	ID                 string    `json:"id"`
-	SenderCustomerId   string    `json:"sender_customer_id"`
-	ReceiverMerchantId string    `json:"receiver_merchant_id"`
-	Amount             int64     `json:"amount"`
-	CreatedAt          time.Time `json:"created_at"`
+	ID                 string              `json:"id"`
+	SenderCustomerId   string              `json:"sender_customer_id"`
+	ReceiverMerchantId string              `json:"receiver_merchant_id"`
+	Amount             int64               `json:"amount"`
+	Currency           string              `json:"currency"`
+	SourceAmount       int64               `json:"source_amount"`
+	SourceCurrency     string              `json:"source_currency"`
+	FxRate             string              `json:"fx_rate,omitempty"`
+	CapturedAmount     int64               `json:"captured_amount"`
+	Fee                int64               `json:"fee"`
+	Discount           int64               `json:"discount,omitempty"`
+	RefundedAmount     int64               `json:"refunded_amount"`
+	Status             string              `json:"status"`
+	InvoiceID          string              `json:"invoice_id,omitempty"`
+	ParentID           string              `json:"parent_id,omitempty"`
+	Legs               []Transaction       `json:"legs,omitempty"`
+	Promo              *PromoRedemption    `json:"promo,omitempty"`
+	SubscriptionCharge *SubscriptionCharge `json:"-"`
+	ScreenedPayments   *int64              `json:"-"`
+	ExpiresAt          *time.Time          `json:"expires_at,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+}
+
+// IsSplit reports whether t is the parent of a split payment.
+func (t Transaction) IsSplit() bool {
+	return t.ReceiverMerchantId == ""
 }
 
+// CreateTransactionRequest pays one merchant, or several at once when Splits
+// is set. A split payment has no ReceiverMerchantId, and Amount is optional
+// but must match the total of the splits when given. PromoCode applies to
+// payments to a single merchant.
 type CreateTransactionRequest struct {
-	UserId             string `json:"user_id"`
-	ReceiverMerchantId string `json:"receiver_merchant_id"`
-	Amount             int64  `json:"amount"`
+	UserId             string                `json:"user_id"`
+	ReceiverMerchantId string                `json:"receiver_merchant_id" binding:"required_without=Splits"`
+	Amount             int64                 `json:"amount" binding:"required_without=Splits,gte=0"`
+	Splits             []SplitPaymentRequest `json:"splits" binding:"omitempty,dive"`
+	PromoCode          string                `json:"promo_code"`
+	InvoiceID          string                `json:"-"`
+	SubscriptionCharge *SubscriptionCharge   `json:"-"`
+}
+
+type SplitPaymentRequest struct {
+	ReceiverMerchantId string `json:"receiver_merchant_id" binding:"required"`
+	Amount             int64  `json:"amount" binding:"required,gt=0"`
+}
+
+// CaptureTransactionRequest captures the full authorized amount when Amount is zero.
+type CaptureTransactionRequest struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        int64  `json:"amount" binding:"omitempty,gt=0"`
+}
+
+const (
+	TransactionSortCreatedAt      = "created_at"
+	TransactionSortAmount         = "amount"
+	TransactionSortCapturedAmount = "captured_amount"
+	TransactionSortStatus         = "status"
+)
+
+// TransactionFilter narrows a transaction listing. Empty fields do not
+// filter. From is inclusive and To exclusive, and the amounts are compared
+// with Amount. A merchant matches split payments through their legs. The
+// listing is sorted by SortBy in SortOrder, then by ID.
+type TransactionFilter struct {
+	CustomerID string
+	MerchantID string
+	Statuses   []string
+	From       *time.Time
+	To         *time.Time
+	MinAmount  *int64
+	MaxAmount  *int64
+	SortBy     string
+	SortOrder  string
+}
+
+// SortKey names the sorting of the filter, so a cursor can be checked
+// against the listing it is used with.
+func (f TransactionFilter) SortKey() string {
+	return f.SortBy + " " + f.SortOrder
+}
+
+// Cursor points at t in a listing sorted by the filter.
+func (f TransactionFilter) Cursor(t Transaction) Cursor {
+	var c Cursor
+	switch f.SortBy {
+	case TransactionSortAmount:
+		c = Cursor{Value: strconv.FormatInt(t.Amount, 10), ID: t.ID}
+	case TransactionSortCapturedAmount:
+		c = Cursor{Value: strconv.FormatInt(t.CapturedAmount, 10), ID: t.ID}
+	case TransactionSortStatus:
+		c = Cursor{Value: t.Status, ID: t.ID}
+	default:
+		c = CreatedAtCursor(t.CreatedAt, t.ID)
+	}
+	c.Sort = f.SortKey()
+	return c
+}
+
+const (
+	TransactionExpandCustomer = "customer"
+	TransactionExpandMerchant = "merchant"
+)
+
+// TransactionExpand chooses the relations loaded with a transaction.
+type TransactionExpand struct {
+	Customer bool
+	Merchant bool
 }
 
+// TransactionResponse is a transaction with the relations asked for. The
+// customer comes with its user. A split payment has no merchant of its own;
+// its legs name theirs.
 type TransactionResponse struct {
-	ID        string           `json:"id"`
-	Customer  CustomerResponse `json:"customer"`
-	Merchant  Merchant         `json:"merchant"`
-	Amount    int64            `json:"amount"`
-	CreatedAt time.Time        `json:"created_at"`
+	Transaction
+	Customer *CustomerResponse `json:"customer,omitempty"`
+	Merchant *Merchant         `json:"merchant,omitempty"`
 }
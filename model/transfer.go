@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+const (
+	TransferDirectionSent     = "sent"
+	TransferDirectionReceived = "received"
+)
+
+type Transfer struct {
+	ID                 string    `json:"id"`
+	SenderCustomerId   string    `json:"sender_customer_id"`
+	ReceiverCustomerId string    `json:"receiver_customer_id"`
+	Amount             int64     `json:"amount"`
+	Currency           string    `json:"currency"`
+	Note               string    `json:"note"`
+	Direction          string    `json:"direction,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// CreateTransferRequest identifies the receiver by either customer ID or username.
+type CreateTransferRequest struct {
+	UserId             string `json:"user_id"`
+	ReceiverCustomerId string `json:"receiver_customer_id" binding:"required_without=ReceiverUsername"`
+	ReceiverUsername   string `json:"receiver_username" binding:"required_without=ReceiverCustomerId"`
+	Amount             int64  `json:"amount" binding:"required,gt=0"`
+	Note               string `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// WalletActorSystem is recorded as the author of holds placed automatically.
+const WalletActorSystem = "system"
+
+const (
+	WalletHoldStatusActive   = "active"
+	WalletHoldStatusReleased = "released"
+)
+
+// WalletFreeze stops a customer or merchant wallet from sending money, and
+// from receiving it too when BlockIncoming is set. It is active until
+// LiftedAt is set.
+type WalletFreeze struct {
+	ID            string     `json:"id"`
+	OwnerType     string     `json:"owner_type"`
+	OwnerID       string     `json:"owner_id"`
+	BlockIncoming bool       `json:"block_incoming"`
+	Reason        string     `json:"reason"`
+	FrozenBy      string     `json:"frozen_by"`
+	LiftReason    string     `json:"lift_reason,omitempty"`
+	LiftedBy      string     `json:"lifted_by,omitempty"`
+	LiftedAt      *time.Time `json:"lifted_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// WalletHold sets aside Amount of a wallet, in the wallet currency, until it
+// is released. Held money cannot be spent, transferred or paid out.
+type WalletHold struct {
+	ID            string     `json:"id"`
+	OwnerType     string     `json:"owner_type"`
+	OwnerID       string     `json:"owner_id"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	CreatedBy     string     `json:"created_by"`
+	ReleaseReason string     `json:"release_reason,omitempty"`
+	ReleasedBy    string     `json:"released_by,omitempty"`
+	ReleasedAt    *time.Time `json:"released_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type FreezeWalletRequest struct {
+	Reason        string `json:"reason" binding:"required"`
+	BlockIncoming bool   `json:"block_incoming"`
+}
+
+type PlaceHoldRequest struct {
+	Amount int64  `json:"amount" binding:"required,gt=0"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// WalletActionRequest carries the reason for lifting a freeze or releasing a
+// hold.
+type WalletActionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -12,29 +12,28 @@ type CustomerRepository interface {
 	GetByUserId(userId string) (model.Customer, error)
 	GetById(id string) (model.Customer, error)
 	List(params model.PaginationParams) ([]model.Customer, error)
-	AddCustomerBalance(id string, amount int64) (model.Customer, error)
 }
 
 type customerRepository struct {
 	db *sql.DB
 }
 
-// AddCustomerBalance implements CustomerRepository.
-func (c *customerRepository) AddCustomerBalance(id string, amount int64) (model.Customer, error) {
-	sql := `UPDATE customers
-	SET balance = balance + $1
-	WHERE user_id = $2
-	RETURNING id, user_id,name, balance, created_at`
-	row := c.db.QueryRow(sql, amount, id)
+const customerColumns = `id, user_id, name, balance, held_amount, currency, tier, frozen, frozen_incoming, created_at`
+
+func scanCustomer(row rowScanner) (model.Customer, error) {
 	var i model.Customer
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
 		&i.Name,
 		&i.Balance,
+		&i.HeldAmount,
+		&i.Currency,
+		&i.Tier,
+		&i.Frozen,
+		&i.FrozenIncoming,
 		&i.CreatedAt,
 	)
-
 	return i, err
 }
 
@@ -45,23 +44,13 @@ func (c *customerRepository) Create(arg model.Customer) (model.Customer, error)
 		id,
 		user_id,
 		name,
-		balance
+		balance,
+		currency
 	  ) VALUES (
-		$1, $2, $3, $4
-	  ) RETURNING id,user_id, name, balance, created_at`
-
-	row := c.db.QueryRow(sql, arg.ID, arg.UserID, arg.Name, arg.Balance)
-	var i model.Customer
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Name,
-		&i.Balance,
-		&i.CreatedAt,
-	)
-
-	return i, err
+		$1, $2, $3, $4, $5
+	  ) RETURNING ` + customerColumns
 
+	return scanCustomer(c.db.QueryRow(sql, arg.ID, arg.UserID, arg.Name, arg.Balance, arg.Currency))
 }
 
 // Delete implements CustomerRepository.
@@ -77,56 +66,35 @@ func (c *customerRepository) Delete(id string) error {
 
 // Get implements CustomerRepository.
 func (c *customerRepository) GetByUserId(userId string) (model.Customer, error) {
-	sql := `SELECT id, user_id, name, balance, created_at FROM customers
+	sql := `SELECT ` + customerColumns + ` FROM customers
 	WHERE user_id = $1 LIMIT 1`
-	row := c.db.QueryRow(sql, userId)
-	var i model.Customer
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Name,
-		&i.Balance,
-		&i.CreatedAt,
-	)
-	return i, err
+	return scanCustomer(c.db.QueryRow(sql, userId))
 }
 
 func (c *customerRepository) GetById(id string) (model.Customer, error) {
-	sql := `SELECT id, user_id, name, balance, created_at FROM customers
+	sql := `SELECT ` + customerColumns + ` FROM customers
 	WHERE id = $1 LIMIT 1`
-	row := c.db.QueryRow(sql, id)
-	var i model.Customer
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Name,
-		&i.Balance,
-		&i.CreatedAt,
-	)
-	return i, err
+	return scanCustomer(c.db.QueryRow(sql, id))
 }
 
 // List implements CustomerRepository.
 func (c *customerRepository) List(params model.PaginationParams) ([]model.Customer, error) {
-	sql := `SELECT id,user_id, name, balance, created_at FROM customers
-	ORDER BY created_at
-	LIMIT $1
-	OFFSET $2`
-	rows, err := c.db.Query(sql, params.Limit, params.Offset)
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + customerColumns + ` FROM customers
+	WHERE ` + cond + `
+	` + page
+	rows, err := c.db.Query(sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	items := []model.Customer{}
 	for rows.Next() {
-		var i model.Customer
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Name,
-			&i.Balance,
-			&i.CreatedAt,
-		); err != nil {
+		i, err := scanCustomer(rows)
+		if err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -0,0 +1,430 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type DisputeRepository interface {
+	Open(arg model.Dispute, filedSince time.Time) (model.Dispute, error)
+	Get(id string) (model.Dispute, error)
+	List(customerId string, status string, params model.PaginationParams) ([]model.Dispute, error)
+	Respond(id string, response string) (model.Dispute, error)
+	StartReview(id string) (model.Dispute, error)
+	ListOverdue(before time.Time) ([]string, error)
+	Resolve(id string, outcome string, note string, resolvedBy string, refundId string) (model.Dispute, error)
+	AddEvidence(arg model.DisputeEvidence) (model.DisputeEvidence, error)
+	GetEvidence(disputeId string, id string) (model.DisputeEvidence, error)
+}
+
+type disputeRepository struct {
+	db *sql.DB
+}
+
+func NewDisputeRepository(db *sql.DB) DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+// Open implements DisputeRepository. Only the customer who paid can dispute
+// a payment, once, and only if it was made after filedSince. A zero amount
+// disputes whatever is still refundable. The amount is held on the merchant
+// wallet, which may leave the merchant with a negative available balance.
+func (repo *disputeRepository) Open(arg model.Dispute, filedSince time.Time) (model.Dispute, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	t, err := scanTransaction(tx.QueryRow(sql, arg.TransactionID))
+	if err != nil {
+		return model.Dispute{}, notFound(err, "transaction", arg.TransactionID)
+	}
+	if t.SenderCustomerId != arg.CustomerID {
+		return model.Dispute{}, fmt.Errorf("transaction %s: %w", arg.TransactionID, common.ErrRecordNotFound)
+	}
+	if t.Status != model.TransactionStatusCaptured {
+		return model.Dispute{}, fmt.Errorf("%w: transaction %s is %s", common.ErrInvalidStatus, t.ID, t.Status)
+	}
+	if t.IsSplit() {
+		return model.Dispute{}, fmt.Errorf("%w: dispute the legs of split payment %s", common.ErrInvalidStatus, t.ID)
+	}
+	if t.CreatedAt.Before(filedSince) {
+		return model.Dispute{}, fmt.Errorf("%w: transaction %s is too old to dispute", common.ErrInvalidDispute, t.ID)
+	}
+
+	sql = `SELECT EXISTS (SELECT 1 FROM disputes WHERE transaction_id = $1)`
+	var disputed bool
+	if err := tx.QueryRow(sql, t.ID).Scan(&disputed); err != nil {
+		return model.Dispute{}, err
+	}
+	if disputed {
+		return model.Dispute{}, fmt.Errorf("%w: transaction %s is already disputed", common.ErrInvalidStatus, t.ID)
+	}
+
+	remaining := t.CapturedAmount - t.RefundedAmount
+	if arg.Amount == 0 {
+		arg.Amount = remaining
+	}
+	if arg.Amount <= 0 || arg.Amount > remaining {
+		return model.Dispute{}, common.ErrRefundExceeded
+	}
+
+	merchant, err := lockWallet(tx, model.AccountOwnerMerchant, t.ReceiverMerchantId)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	sql = `UPDATE ` + merchant.table + `
+	SET held_amount = held_amount + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, arg.Amount, t.ReceiverMerchantId); err != nil {
+		return model.Dispute{}, err
+	}
+
+	sql = `
+	INSERT INTO disputes (
+		id, transaction_id, customer_id, merchant_id, reason, description, amount, currency, status, response_due_at
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+	  ) RETURNING ` + disputeColumns
+	i, err := scanDispute(tx.QueryRow(sql, arg.ID, t.ID, t.SenderCustomerId, t.ReceiverMerchantId, arg.Reason, arg.Description,
+		arg.Amount, t.Currency, model.DisputeStatusOpen, arg.ResponseDueAt))
+	if err != nil {
+		return model.Dispute{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Dispute{}, err
+	}
+	return i, nil
+}
+
+// disputedAmount returns the amount of the undecided dispute on transaction
+// transactionId, or 0 when there is none. The dispute holds that amount on
+// the merchant wallet, or what is left to refund when that is less. The
+// transaction row must already be locked, as every change of the hold locks
+// it first.
+func disputedAmount(tx *sql.Tx, transactionId string) (int64, error) {
+	sql := `SELECT amount FROM disputes
+	WHERE transaction_id = $1 AND status IN ($2, $3, $4)`
+	var amount int64
+	err := tx.QueryRow(sql, transactionId, model.DisputeStatusOpen, model.DisputeStatusMerchantResponse, model.DisputeStatusUnderReview).Scan(&amount)
+	if isNoRows(err) {
+		return 0, nil
+	}
+	return amount, err
+}
+
+// Get implements DisputeRepository. The dispute comes with its evidence.
+func (repo *disputeRepository) Get(id string) (model.Dispute, error) {
+	sql := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1`
+	i, err := scanDispute(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.Dispute{}, notFound(err, "dispute", id)
+	}
+
+	sql = `SELECT ` + disputeEvidenceColumns + ` FROM dispute_evidence
+	WHERE dispute_id = $1
+	ORDER BY created_at`
+	rows, err := repo.db.Query(sql, id)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		evidence, err := scanDisputeEvidence(rows)
+		if err != nil {
+			return model.Dispute{}, err
+		}
+		i.Evidence = append(i.Evidence, evidence)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Dispute{}, err
+	}
+	return i, nil
+}
+
+// List implements DisputeRepository. An empty customerId or status does not
+// filter. The oldest come first, as a queue.
+func (repo *disputeRepository) List(customerId string, status string, params model.PaginationParams) ([]model.Dispute, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", false, []any{customerId, status})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + disputeColumns + ` FROM disputes
+	WHERE ($1 = '' OR customer_id = $1) AND ($2 = '' OR status = $2) AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.Dispute{}
+	for rows.Next() {
+		i, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Respond implements DisputeRepository. The merchant responds once, before
+// the response deadline.
+func (repo *disputeRepository) Respond(id string, response string) (model.Dispute, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	defer tx.Rollback()
+
+	d, err := lockDispute(tx, id)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	if d.Status != model.DisputeStatusOpen {
+		return model.Dispute{}, fmt.Errorf("%w: dispute %s is %s", common.ErrInvalidStatus, id, d.Status)
+	}
+	if !time.Now().Before(d.ResponseDueAt) {
+		return model.Dispute{}, fmt.Errorf("%w: the response to dispute %s was due at %s", common.ErrInvalidStatus, id, d.ResponseDueAt.Format(time.RFC3339))
+	}
+
+	sql := `UPDATE disputes
+	SET status = $1, merchant_response = $2, responded_at = now(), updated_at = now()
+	WHERE id = $3
+	RETURNING ` + disputeColumns
+	i, err := scanDispute(tx.QueryRow(sql, model.DisputeStatusMerchantResponse, response, id))
+	if err != nil {
+		return model.Dispute{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Dispute{}, err
+	}
+	return i, nil
+}
+
+// StartReview implements DisputeRepository.
+func (repo *disputeRepository) StartReview(id string) (model.Dispute, error) {
+	sql := `UPDATE disputes
+	SET status = $1, updated_at = now()
+	WHERE id = $2 AND status IN ($3, $4)
+	RETURNING ` + disputeColumns
+	i, err := scanDispute(repo.db.QueryRow(sql, model.DisputeStatusUnderReview, id,
+		model.DisputeStatusOpen, model.DisputeStatusMerchantResponse))
+	if isNoRows(err) {
+		if _, err := repo.Get(id); err != nil {
+			return model.Dispute{}, err
+		}
+		return model.Dispute{}, fmt.Errorf("%w: dispute %s cannot go to review", common.ErrInvalidStatus, id)
+	}
+	return i, err
+}
+
+// ListOverdue implements DisputeRepository. It returns open disputes the
+// merchant did not respond to before the deadline.
+func (repo *disputeRepository) ListOverdue(before time.Time) ([]string, error) {
+	sql := `SELECT id FROM disputes
+	WHERE status = $1 AND response_due_at <= $2
+	ORDER BY response_due_at`
+	rows, err := repo.db.Query(sql, model.DisputeStatusOpen, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Resolve implements DisputeRepository. The merchant hold is released either
+// way. A won dispute is charged back as refund refundId of what is still
+// refundable, up to the disputed amount; it goes through even when the
+// merchant wallet is frozen or short of funds. A chargeback to a wallet
+// frozen for incoming payments is held like a top-up.
+func (repo *disputeRepository) Resolve(id string, outcome string, note string, resolvedBy string, refundId string) (model.Dispute, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	defer tx.Rollback()
+
+	d, err := lockDispute(tx, id)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	if d.Status != model.DisputeStatusUnderReview {
+		return model.Dispute{}, fmt.Errorf("%w: dispute %s is %s", common.ErrInvalidStatus, id, d.Status)
+	}
+
+	sql := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	t, err := scanTransaction(tx.QueryRow(sql, d.TransactionID))
+	if err != nil {
+		return model.Dispute{}, err
+	}
+
+	customer, err := lockWallet(tx, model.AccountOwnerCustomer, d.CustomerID)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	merchant, err := lockWallet(tx, model.AccountOwnerMerchant, d.MerchantID)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+
+	// Refunds made meanwhile released part of the hold, so what is still
+	// held is also what is left to charge back.
+	chargebackId := ""
+	amount := t.CapturedAmount - t.RefundedAmount
+	if d.Amount < amount {
+		amount = d.Amount
+	}
+	sql = `UPDATE ` + merchant.table + `
+	SET held_amount = held_amount - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, amount, d.MerchantID); err != nil {
+		return model.Dispute{}, err
+	}
+
+	if outcome == model.DisputeStatusWon && amount > 0 {
+		arg := model.Refund{
+			ID:            refundId,
+			TransactionID: t.ID,
+			Amount:        amount,
+			Reason:        "chargeback for dispute " + d.ID,
+			CreatedBy:     resolvedBy,
+		}
+		arg.SourceAmount, arg.Fee = refundShares(t, amount)
+		refund, err := insertRefund(tx, t, arg)
+		if err != nil {
+			return model.Dispute{}, err
+		}
+		chargebackId = refund.ID
+
+		if customer.frozenIncoming {
+			_, err := insertWalletHold(tx, customer.table, model.WalletHold{
+				ID:        common.GenerateID(),
+				OwnerType: model.AccountOwnerCustomer,
+				OwnerID:   d.CustomerID,
+				Amount:    refund.SourceAmount,
+				Currency:  t.SourceCurrency,
+				Reason:    "chargeback for dispute " + d.ID + " to a frozen wallet",
+				CreatedBy: model.WalletActorSystem,
+			})
+			if err != nil {
+				return model.Dispute{}, err
+			}
+		}
+	}
+
+	sql = `UPDATE disputes
+	SET status = $1, resolution_note = $2, resolved_by = $3, refund_id = NULLIF($4, ''), resolved_at = now(), updated_at = now()
+	WHERE id = $5
+	RETURNING ` + disputeColumns
+	i, err := scanDispute(tx.QueryRow(sql, outcome, note, resolvedBy, chargebackId, id))
+	if err != nil {
+		return model.Dispute{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Dispute{}, err
+	}
+	return i, nil
+}
+
+// AddEvidence implements DisputeRepository.
+func (repo *disputeRepository) AddEvidence(arg model.DisputeEvidence) (model.DisputeEvidence, error) {
+	sql := `
+	INSERT INTO dispute_evidence (
+		id, dispute_id, party, file_name, content_type, size, description, uploaded_by, path
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9
+	  ) RETURNING ` + disputeEvidenceColumns
+	return scanDisputeEvidence(repo.db.QueryRow(sql, arg.ID, arg.DisputeID, arg.Party, arg.FileName, arg.ContentType,
+		arg.Size, arg.Description, arg.UploadedBy, arg.Path))
+}
+
+// GetEvidence implements DisputeRepository.
+func (repo *disputeRepository) GetEvidence(disputeId string, id string) (model.DisputeEvidence, error) {
+	sql := `SELECT ` + disputeEvidenceColumns + ` FROM dispute_evidence WHERE dispute_id = $1 AND id = $2`
+	i, err := scanDisputeEvidence(repo.db.QueryRow(sql, disputeId, id))
+	if err != nil {
+		return model.DisputeEvidence{}, notFound(err, "dispute evidence", id)
+	}
+	return i, nil
+}
+
+func lockDispute(tx *sql.Tx, id string) (model.Dispute, error) {
+	sql := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1 FOR UPDATE`
+	i, err := scanDispute(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.Dispute{}, notFound(err, "dispute", id)
+	}
+	return i, nil
+}
+
+const disputeColumns = `id, transaction_id, customer_id, merchant_id, reason, description, amount, currency, status,
+	COALESCE(merchant_response, ''), COALESCE(resolution_note, ''), COALESCE(resolved_by, ''), COALESCE(refund_id, ''),
+	response_due_at, responded_at, resolved_at, created_at, updated_at`
+
+func scanDispute(row rowScanner) (model.Dispute, error) {
+	var i model.Dispute
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.CustomerID,
+		&i.MerchantID,
+		&i.Reason,
+		&i.Description,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.MerchantResponse,
+		&i.ResolutionNote,
+		&i.ResolvedBy,
+		&i.RefundID,
+		&i.ResponseDueAt,
+		&i.RespondedAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const disputeEvidenceColumns = `id, dispute_id, party, file_name, content_type, size, COALESCE(description, ''), uploaded_by, path, created_at`
+
+func scanDisputeEvidence(row rowScanner) (model.DisputeEvidence, error) {
+	var i model.DisputeEvidence
+	err := row.Scan(
+		&i.ID,
+		&i.DisputeID,
+		&i.Party,
+		&i.FileName,
+		&i.ContentType,
+		&i.Size,
+		&i.Description,
+		&i.UploadedBy,
+		&i.Path,
+		&i.CreatedAt,
+	)
+	return i, err
+}
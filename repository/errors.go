@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// notFound translates sql.ErrNoRows into common.ErrRecordNotFound so callers
+// can tell a missing row apart from a database failure.
+func notFound(err error, entity string, id string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s %s: %w", entity, id, common.ErrRecordNotFound)
+	}
+	return err
+}
+
+// isNoRows reports whether err means a query matched no row.
+func isNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// fakeDB stands in for Postgres in repository tests. Each statement is
+// answered by the first route whose pattern matches it, with the whitespace
+// of the statement collapsed. Rows come back with exactly the columns the
+// statement selects or returns, so a scan that does not match its query
+// fails as it would against the database.
+type fakeDB struct {
+	t      *testing.T
+	routes []fakeRoute
+}
+
+// fakeRecord holds the values of a row by column name.
+type fakeRecord map[string]driver.Value
+
+type fakeRoute struct {
+	pattern *regexp.Regexp
+	answer  func(args []driver.Value) []fakeRecord
+}
+
+func newFakeDB(t *testing.T) *fakeDB {
+	return &fakeDB{t: t}
+}
+
+// on answers the statements matching pattern with the records answer
+// returns. Statements run with Exec may return nil.
+func (db *fakeDB) on(pattern string, answer func(args []driver.Value) []fakeRecord) {
+	db.routes = append(db.routes, fakeRoute{pattern: regexp.MustCompile(pattern), answer: answer})
+}
+
+// open returns a *sql.DB talking to db.
+func (db *fakeDB) open() *sql.DB {
+	conn := sql.OpenDB(db)
+	db.t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func (db *fakeDB) answer(query string, named []driver.NamedValue) ([]fakeRecord, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	args := make([]driver.Value, len(named))
+	for i, v := range named {
+		args[i] = v.Value
+	}
+	for _, r := range db.routes {
+		if r.pattern.MatchString(query) {
+			return r.answer(args), nil
+		}
+	}
+	db.t.Errorf("unexpected statement: %s", query)
+	return nil, fmt.Errorf("fake database: unexpected statement")
+}
+
+// Connect implements driver.Connector.
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{db}, nil
+}
+
+// Driver implements driver.Connector.
+func (db *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake database: open it with sql.OpenDB")
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake database: statements are not prepared")
+}
+
+func (c fakeConn) Close() error { return nil }
+
+// Begin starts a transaction that only pretends to be one: the routes apply
+// their changes right away.
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	records, err := c.db.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: selectedColumns(query), records: records}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	records, err := c.db.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(records)), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	records []fakeRecord
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.records) == 0 {
+		return io.EOF
+	}
+	record := r.records[0]
+	r.records = r.records[1:]
+	for i, column := range r.columns {
+		v, ok := record[column]
+		if !ok {
+			return fmt.Errorf("fake database: no column %q in %v", column, record)
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+var (
+	coalesce = regexp.MustCompile(`^COALESCE\((.*), [^,]*\)$`)
+	cast     = regexp.MustCompile(`::\w+`)
+)
+
+// selectedColumns returns what a statement returns or selects, one name per
+// column. COALESCE and casts are dropped, so the column of
+// COALESCE(fx_rate::text, 'x') is fx_rate.
+func selectedColumns(query string) []string {
+	query = strings.Join(strings.Fields(query), " ")
+	var list string
+	if i := strings.Index(query, " RETURNING "); i >= 0 {
+		list = query[i+len(" RETURNING "):]
+	} else if strings.HasPrefix(query, "SELECT ") {
+		list = query[len("SELECT "):]
+		if i := topLevelIndex(list, " FROM "); i >= 0 {
+			list = list[:i]
+		}
+	} else {
+		return nil
+	}
+
+	var columns []string
+	for {
+		i := topLevelIndex(list, ",")
+		column := list
+		if i >= 0 {
+			column = list[:i]
+		}
+		column = strings.TrimSpace(cast.ReplaceAllString(column, ""))
+		if m := coalesce.FindStringSubmatch(column); m != nil {
+			column = m[1]
+		}
+		columns = append(columns, column)
+		if i < 0 {
+			return columns
+		}
+		list = list[i+1:]
+	}
+}
+
+// topLevelIndex is strings.Index outside of parentheses.
+func topLevelIndex(s string, substr string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 && strings.HasPrefix(s[i:], substr) {
+			return i
+		}
+	}
+	return -1
+}
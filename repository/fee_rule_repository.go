@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type FeeRuleRepository interface {
+	Save(arg model.FeeRule) (model.FeeRule, error)
+	Get(id string) (model.FeeRule, error)
+	List() ([]model.FeeRule, error)
+	Delete(id string) error
+	FindForMerchant(merchantId string, businessType string) (model.FeeRule, error)
+	MonthlyVolume(merchantId string, since time.Time) (int64, error)
+}
+
+type feeRuleRepository struct {
+	db *sql.DB
+}
+
+func NewFeeRuleRepository(db *sql.DB) FeeRuleRepository {
+	return &feeRuleRepository{db: db}
+}
+
+// Save implements FeeRuleRepository. A rule replaces the existing rule for
+// the same merchant or business type in place, keeping its ID and creation
+// time, and its tiers are replaced with the new ones.
+func (repo *feeRuleRepository) Save(arg model.FeeRule) (model.FeeRule, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.FeeRule{}, err
+	}
+	defer tx.Rollback()
+
+	// The conflict target must name the partial unique index the rule falls
+	// under.
+	target := `(merchant_id) WHERE merchant_id IS NOT NULL`
+	if arg.MerchantID == "" {
+		target = `(business_type) WHERE business_type IS NOT NULL`
+	}
+	sql := `
+	INSERT INTO fee_rules (
+		id, merchant_id, business_type, percentage_bps, fixed_fee, min_fee, max_fee
+	  ) VALUES (
+		$1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7
+	  ) ON CONFLICT ` + target + ` DO UPDATE SET
+		percentage_bps = EXCLUDED.percentage_bps,
+		fixed_fee = EXCLUDED.fixed_fee,
+		min_fee = EXCLUDED.min_fee,
+		max_fee = EXCLUDED.max_fee,
+		updated_at = now()
+	  RETURNING ` + feeRuleColumns
+	i, err := scanFeeRule(tx.QueryRow(sql, arg.ID, arg.MerchantID, arg.BusinessType, arg.PercentageBps, arg.FixedFee, arg.MinFee, arg.MaxFee))
+	if err != nil {
+		return model.FeeRule{}, err
+	}
+
+	sql = `DELETE FROM fee_rule_tiers WHERE fee_rule_id = $1`
+	if _, err := tx.Exec(sql, i.ID); err != nil {
+		return model.FeeRule{}, err
+	}
+
+	for _, tier := range arg.Tiers {
+		sql := `
+		INSERT INTO fee_rule_tiers (
+			fee_rule_id, min_monthly_volume, percentage_bps, fixed_fee
+		  ) VALUES (
+			$1, $2, $3, $4
+		  )`
+		if _, err := tx.Exec(sql, i.ID, tier.MinMonthlyVolume, tier.PercentageBps, tier.FixedFee); err != nil {
+			return model.FeeRule{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.FeeRule{}, err
+	}
+	i.Tiers, err = repo.listTiers(i.ID)
+	return i, err
+}
+
+// Get implements FeeRuleRepository.
+func (repo *feeRuleRepository) Get(id string) (model.FeeRule, error) {
+	sql := `SELECT ` + feeRuleColumns + ` FROM fee_rules WHERE id = $1`
+	i, err := scanFeeRule(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.FeeRule{}, notFound(err, "fee rule", id)
+	}
+	i.Tiers, err = repo.listTiers(i.ID)
+	return i, err
+}
+
+// List implements FeeRuleRepository.
+func (repo *feeRuleRepository) List() ([]model.FeeRule, error) {
+	sql := `SELECT ` + feeRuleColumns + ` FROM fee_rules
+	ORDER BY business_type NULLS LAST, merchant_id`
+	rows, err := repo.db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.FeeRule{}
+	for rows.Next() {
+		i, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for n := range items {
+		items[n].Tiers, err = repo.listTiers(items[n].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Delete implements FeeRuleRepository.
+func (repo *feeRuleRepository) Delete(id string) error {
+	sql := `DELETE FROM fee_rules WHERE id = $1`
+	result, err := repo.db.Exec(sql, id)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("fee rule %s: %w", id, common.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// FindForMerchant implements FeeRuleRepository. The merchant's own rule wins
+// over the rule of its business type.
+func (repo *feeRuleRepository) FindForMerchant(merchantId string, businessType string) (model.FeeRule, error) {
+	sql := `SELECT ` + feeRuleColumns + ` FROM fee_rules
+	WHERE merchant_id = $1 OR business_type = NULLIF($2, '')
+	ORDER BY merchant_id IS NULL
+	LIMIT 1`
+	i, err := scanFeeRule(repo.db.QueryRow(sql, merchantId, businessType))
+	if err != nil {
+		return model.FeeRule{}, notFound(err, "fee rule for merchant", merchantId)
+	}
+	i.Tiers, err = repo.listTiers(i.ID)
+	return i, err
+}
+
+// MonthlyVolume implements FeeRuleRepository. It sums what the merchant has
+// captured since the given time.
+func (repo *feeRuleRepository) MonthlyVolume(merchantId string, since time.Time) (int64, error) {
+	sql := `SELECT COALESCE(SUM(captured_amount), 0) FROM transactions
+	WHERE receiver_merchant_id = $1 AND status = $2 AND created_at >= $3`
+	var volume int64
+	err := repo.db.QueryRow(sql, merchantId, model.TransactionStatusCaptured, since).Scan(&volume)
+	return volume, err
+}
+
+func (repo *feeRuleRepository) listTiers(ruleId string) ([]model.FeeTier, error) {
+	sql := `SELECT min_monthly_volume, percentage_bps, fixed_fee FROM fee_rule_tiers
+	WHERE fee_rule_id = $1
+	ORDER BY min_monthly_volume`
+	rows, err := repo.db.Query(sql, ruleId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.FeeTier{}
+	for rows.Next() {
+		var i model.FeeTier
+		if err := rows.Scan(
+			&i.MinMonthlyVolume,
+			&i.PercentageBps,
+			&i.FixedFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const feeRuleColumns = `id, COALESCE(merchant_id, ''), COALESCE(business_type, ''), percentage_bps, fixed_fee, min_fee, max_fee, created_at, updated_at`
+
+func scanFeeRule(row rowScanner) (model.FeeRule, error) {
+	var i model.FeeRule
+	err := row.Scan(
+		&i.ID,
+		&i.MerchantID,
+		&i.BusinessType,
+		&i.PercentageBps,
+		&i.FixedFee,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,476 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/lib/pq"
+)
+
+type FraudRepository interface {
+	ListRules() ([]model.FraudRule, error)
+	SaveRule(arg model.FraudRule) (model.FraudRule, error)
+	Signals(customerId string, merchantIds []string, since time.Time) (model.FraudSignals, error)
+	Hold(arg model.Transaction, review model.FraudReview) (model.Transaction, error)
+	GetReview(id string) (model.FraudReview, error)
+	ListReviews(status string, params model.PaginationParams) ([]model.FraudReview, error)
+	Approve(id string, reviewerId string, note string, expiresAt time.Time) (model.FraudReview, error)
+	Reject(id string, reviewerId string, note string) (model.FraudReview, error)
+}
+
+type fraudRepository struct {
+	db            *sql.DB
+	limitLocation *time.Location
+}
+
+func NewFraudRepository(db *sql.DB, limitLocation *time.Location) FraudRepository {
+	return &fraudRepository{db: db, limitLocation: limitLocation}
+}
+
+// ListRules implements FraudRepository.
+func (repo *fraudRepository) ListRules() ([]model.FraudRule, error) {
+	sql := `SELECT ` + fraudRuleColumns + ` FROM fraud_rules ORDER BY rule`
+	rows, err := repo.db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.FraudRule{}
+	for rows.Next() {
+		i, err := scanFraudRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SaveRule implements FraudRepository. Rules are fixed, so only existing
+// rules can be changed.
+func (repo *fraudRepository) SaveRule(arg model.FraudRule) (model.FraudRule, error) {
+	sql := `UPDATE fraud_rules
+	SET action = $2, enabled = $3, min_amount = $4, account_age_hours = $5, window_minutes = $6,
+		max_count = $7, limit_percent = $8, start_hour = $9, end_hour = $10, updated_at = now()
+	WHERE rule = $1
+	RETURNING ` + fraudRuleColumns
+	i, err := scanFraudRule(repo.db.QueryRow(sql, arg.Rule, arg.Action, arg.Enabled, arg.MinAmount, arg.AccountAgeHours,
+		arg.WindowMinutes, arg.MaxCount, arg.LimitPercent, arg.StartHour, arg.EndHour))
+	if err != nil {
+		return model.FraudRule{}, notFound(err, "fraud rule", arg.Rule)
+	}
+	return i, nil
+}
+
+// Signals implements FraudRepository. Every payment to the merchants since
+// since counts, whatever became of it.
+func (repo *fraudRepository) Signals(customerId string, merchantIds []string, since time.Time) (model.FraudSignals, error) {
+	var i model.FraudSignals
+	sql := `SELECT created_at FROM customers WHERE id = $1`
+	if err := repo.db.QueryRow(sql, customerId).Scan(&i.AccountCreatedAt); err != nil {
+		return model.FraudSignals{}, notFound(err, "customer", customerId)
+	}
+
+	paymentCount, err := customerPaymentCount(repo.db, customerId)
+	if err != nil {
+		return model.FraudSignals{}, err
+	}
+	i.PaymentCount = paymentCount
+
+	limits, err := effectiveLimits(repo.db, customerId)
+	if err != nil {
+		return model.FraudSignals{}, err
+	}
+	i.Limits = limits.Limits
+	if i.Usage, err = limitUsage(repo.db, repo.limitLocation, customerId, model.LimitKindPayment); err != nil {
+		return model.FraudSignals{}, err
+	}
+
+	i.MerchantPayments = map[string]int64{}
+	if len(merchantIds) == 0 {
+		return i, nil
+	}
+	sql = `SELECT receiver_merchant_id, COUNT(*) FROM transactions
+	WHERE sender_customer_id = $1 AND receiver_merchant_id = ANY($2) AND created_at >= $3
+	GROUP BY receiver_merchant_id`
+	rows, err := repo.db.Query(sql, customerId, pq.Array(merchantIds), since)
+	if err != nil {
+		return model.FraudSignals{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var merchantId string
+		var count int64
+		if err := rows.Scan(&merchantId, &count); err != nil {
+			return model.FraudSignals{}, err
+		}
+		i.MerchantPayments[merchantId] = count
+	}
+	if err := rows.Err(); err != nil {
+		return model.FraudSignals{}, err
+	}
+	return i, nil
+}
+
+// Hold implements FraudRepository. The payment is checked like any other and
+// stored pending review with its funds held, and review is queued. A held
+// authorization keeps its expiry for when it is approved. An invoice goes in
+// review and is only paid on approval, and a subscription charge is recorded
+// as pending.
+func (repo *fraudRepository) Hold(arg model.Transaction, review model.FraudReview) (model.Transaction, error) {
+	reasons, err := json.Marshal(review.Reasons)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	if arg.InvoiceID != "" {
+		if err := lockPayableInvoice(tx, arg); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	if arg.SubscriptionCharge != nil {
+		if err := lockChargeableSubscription(tx, arg.SubscriptionCharge.SubscriptionID); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	available, err := lockPaymentParties(tx, arg)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if err := checkScreening(tx, arg); err != nil {
+		return model.Transaction{}, err
+	}
+	if available < arg.SourceAmount {
+		return model.Transaction{}, common.ErrInsufficientFunds
+	}
+	if err := checkSpendingLimits(tx, repo.limitLocation, arg.SenderCustomerId, model.LimitKindPayment, arg.SourceAmount); err != nil {
+		return model.Transaction{}, err
+	}
+
+	arg.Status = model.TransactionStatusPendingReview
+	arg.CapturedAmount = 0
+	i, err := insertTransaction(tx, arg)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if arg.Promo != nil {
+		i.Promo = arg.Promo
+		if i.Promo, err = redeemPromo(tx, i); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	if i.InvoiceID != "" {
+		if err := markInvoiceInReview(tx, i); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	if arg.SubscriptionCharge != nil {
+		i.SubscriptionCharge = arg.SubscriptionCharge
+		if *i.SubscriptionCharge, err = recordSubscriptionPayment(tx, i); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	sql := `UPDATE customers
+	SET held_amount = held_amount + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, i.SourceAmount, i.SenderCustomerId); err != nil {
+		return model.Transaction{}, err
+	}
+
+	sql = `
+	INSERT INTO fraud_reviews (
+		id, transaction_id, customer_id, reasons, status
+	  ) VALUES (
+		$1, $2, $3, $4, $5
+	  )`
+	if _, err := tx.Exec(sql, review.ID, i.ID, i.SenderCustomerId, reasons, model.FraudReviewStatusPending); err != nil {
+		return model.Transaction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return i, nil
+}
+
+// GetReview implements FraudRepository.
+func (repo *fraudRepository) GetReview(id string) (model.FraudReview, error) {
+	return getFraudReview(repo.db, id)
+}
+
+// ListReviews implements FraudRepository. An empty status lists every
+// review. The oldest come first, as a queue.
+func (repo *fraudRepository) ListReviews(status string, params model.PaginationParams) ([]model.FraudReview, error) {
+	cond, page, args, err := pageClauses(params, "r.created_at", "timestamptz", false, []any{status})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + fraudReviewColumns + ` FROM fraud_reviews r
+	JOIN transactions t ON t.id = r.transaction_id
+	WHERE ($1 = '' OR r.status = $1) AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.FraudReview{}
+	for rows.Next() {
+		i, err := scanFraudReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Approve implements FraudRepository. A held payment is captured and settled,
+// pays its invoice and completes its subscription charge; a held
+// authorization becomes authorized until expiresAt and keeps its funds held.
+func (repo *fraudRepository) Approve(id string, reviewerId string, note string, expiresAt time.Time) (model.FraudReview, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.FraudReview{}, err
+	}
+	defer tx.Rollback()
+
+	held, err := lockHeldPayment(tx, id)
+	if err != nil {
+		return model.FraudReview{}, err
+	}
+
+	if held.ExpiresAt != nil {
+		sql := `UPDATE transactions
+		SET status = $1, expires_at = $2
+		WHERE id = $3`
+		if _, err := tx.Exec(sql, model.TransactionStatusAuthorized, expiresAt, held.ID); err != nil {
+			return model.FraudReview{}, err
+		}
+	} else {
+		if held.InvoiceID != "" {
+			if err := lockInvoiceInReview(tx, held); err != nil {
+				return model.FraudReview{}, err
+			}
+		}
+		if _, err := lockPaymentParties(tx, held); err != nil {
+			return model.FraudReview{}, err
+		}
+
+		sql := `UPDATE customers
+		SET held_amount = held_amount - $1
+		WHERE id = $2`
+		if _, err := tx.Exec(sql, held.SourceAmount, held.SenderCustomerId); err != nil {
+			return model.FraudReview{}, err
+		}
+
+		sql = `UPDATE transactions
+		SET status = $1, captured_amount = amount
+		WHERE id = $2
+		RETURNING ` + transactionColumns
+		i, err := scanTransaction(tx.QueryRow(sql, model.TransactionStatusCaptured, held.ID))
+		if err != nil {
+			return model.FraudReview{}, err
+		}
+		if err := settlePayment(tx, i, i.SourceAmount); err != nil {
+			return model.FraudReview{}, err
+		}
+		if i.InvoiceID != "" {
+			if err := markInvoicePaid(tx, i); err != nil {
+				return model.FraudReview{}, err
+			}
+		}
+		if err := decideSubscriptionCharge(tx, i.ID, true); err != nil {
+			return model.FraudReview{}, err
+		}
+	}
+
+	return decideFraudReview(tx, id, model.FraudReviewStatusApproved, reviewerId, note)
+}
+
+// Reject implements FraudRepository. The held funds go back to the customer,
+// an invoice in review is open again and a subscription charge fails.
+func (repo *fraudRepository) Reject(id string, reviewerId string, note string) (model.FraudReview, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.FraudReview{}, err
+	}
+	defer tx.Rollback()
+
+	held, err := lockHeldPayment(tx, id)
+	if err != nil {
+		return model.FraudReview{}, err
+	}
+
+	sql := `UPDATE customers
+	SET held_amount = held_amount - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, held.SourceAmount, held.SenderCustomerId); err != nil {
+		return model.FraudReview{}, err
+	}
+
+	sql = `UPDATE transactions
+	SET status = $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, model.TransactionStatusRejected, held.ID); err != nil {
+		return model.FraudReview{}, err
+	}
+
+	if err := cancelPromoRedemption(tx, held.ID); err != nil {
+		return model.FraudReview{}, err
+	}
+	if held.InvoiceID != "" {
+		if err := reopenInvoice(tx, held); err != nil {
+			return model.FraudReview{}, err
+		}
+	}
+	if err := decideSubscriptionCharge(tx, held.ID, false); err != nil {
+		return model.FraudReview{}, err
+	}
+
+	return decideFraudReview(tx, id, model.FraudReviewStatusRejected, reviewerId, note)
+}
+
+// customerPaymentCount counts every payment a customer made, a split payment
+// once.
+func customerPaymentCount(q queryRower, customerId string) (int64, error) {
+	sql := `SELECT COUNT(*) FROM transactions WHERE sender_customer_id = $1 AND parent_id IS NULL`
+	var count int64
+	err := q.QueryRow(sql, customerId).Scan(&count)
+	return count, err
+}
+
+// checkScreening fails with ErrScreeningOutdated when the customer made
+// another payment after arg was screened, so the rules did not see it. The
+// customer must already be locked, which keeps concurrent payments of the
+// customer from passing screening on the same counts.
+func checkScreening(tx *sql.Tx, arg model.Transaction) error {
+	if arg.ScreenedPayments == nil {
+		return nil
+	}
+	count, err := customerPaymentCount(tx, arg.SenderCustomerId)
+	if err != nil {
+		return err
+	}
+	if count != *arg.ScreenedPayments {
+		return common.ErrScreeningOutdated
+	}
+	return nil
+}
+
+// lockHeldPayment locks a pending review and the payment it holds.
+func lockHeldPayment(tx *sql.Tx, reviewId string) (model.Transaction, error) {
+	sql := `SELECT status, transaction_id FROM fraud_reviews WHERE id = $1 FOR UPDATE`
+	var status, transactionId string
+	if err := tx.QueryRow(sql, reviewId).Scan(&status, &transactionId); err != nil {
+		return model.Transaction{}, notFound(err, "fraud review", reviewId)
+	}
+	if status != model.FraudReviewStatusPending {
+		return model.Transaction{}, fmt.Errorf("%w: fraud review %s is %s", common.ErrInvalidStatus, reviewId, status)
+	}
+
+	sql = `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	held, err := scanTransaction(tx.QueryRow(sql, transactionId))
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if held.Status != model.TransactionStatusPendingReview {
+		return model.Transaction{}, common.ErrInvalidStatus
+	}
+	return held, nil
+}
+
+// decideFraudReview closes a review and commits tx.
+func decideFraudReview(tx *sql.Tx, id string, status string, reviewerId string, note string) (model.FraudReview, error) {
+	sql := `UPDATE fraud_reviews
+	SET status = $1, reviewed_by = $2, note = $3, reviewed_at = now()
+	WHERE id = $4`
+	if _, err := tx.Exec(sql, status, reviewerId, note, id); err != nil {
+		return model.FraudReview{}, err
+	}
+
+	i, err := getFraudReview(tx, id)
+	if err != nil {
+		return model.FraudReview{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.FraudReview{}, err
+	}
+	return i, nil
+}
+
+func getFraudReview(q queryRower, id string) (model.FraudReview, error) {
+	sql := `SELECT ` + fraudReviewColumns + ` FROM fraud_reviews r
+	JOIN transactions t ON t.id = r.transaction_id
+	WHERE r.id = $1`
+	i, err := scanFraudReview(q.QueryRow(sql, id))
+	if err != nil {
+		return model.FraudReview{}, notFound(err, "fraud review", id)
+	}
+	return i, nil
+}
+
+const fraudRuleColumns = `rule, action, enabled, min_amount, account_age_hours, window_minutes, max_count, limit_percent, start_hour, end_hour, updated_at`
+
+func scanFraudRule(row rowScanner) (model.FraudRule, error) {
+	var i model.FraudRule
+	err := row.Scan(
+		&i.Rule,
+		&i.Action,
+		&i.Enabled,
+		&i.MinAmount,
+		&i.AccountAgeHours,
+		&i.WindowMinutes,
+		&i.MaxCount,
+		&i.LimitPercent,
+		&i.StartHour,
+		&i.EndHour,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const fraudReviewColumns = `r.id, r.transaction_id, r.customer_id, COALESCE(t.receiver_merchant_id, ''), t.amount, t.currency, r.reasons, r.status, r.note, COALESCE(r.reviewed_by, ''), r.reviewed_at, r.created_at`
+
+func scanFraudReview(row rowScanner) (model.FraudReview, error) {
+	var i model.FraudReview
+	var reasons []byte
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.CustomerID,
+		&i.MerchantID,
+		&i.Amount,
+		&i.Currency,
+		&reasons,
+		&i.Status,
+		&i.Note,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		return model.FraudReview{}, err
+	}
+	err = json.Unmarshal(reasons, &i.Reasons)
+	return i, err
+}
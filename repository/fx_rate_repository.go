@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/albar2305/payment-app/model"
+)
+
+type FxRateRepository interface {
+	Upsert(arg model.FxRate) (model.FxRate, error)
+	Get(baseCurrency string, quoteCurrency string) (model.FxRate, error)
+	List() ([]model.FxRate, error)
+}
+
+type fxRateRepository struct {
+	db *sql.DB
+}
+
+func NewFxRateRepository(db *sql.DB) FxRateRepository {
+	return &fxRateRepository{db: db}
+}
+
+const fxRateColumns = `base_currency, quote_currency, rate::text, COALESCE(updated_by, ''), updated_at`
+
+func scanFxRate(row rowScanner) (model.FxRate, error) {
+	var i model.FxRate
+	err := row.Scan(
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Rate,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// Upsert implements FxRateRepository.
+func (repo *fxRateRepository) Upsert(arg model.FxRate) (model.FxRate, error) {
+	sql := `
+	INSERT INTO fx_rates (
+		base_currency, quote_currency, rate, updated_by
+	  ) VALUES (
+		$1, $2, $3, NULLIF($4, '')
+	  )
+	  ON CONFLICT (base_currency, quote_currency) DO UPDATE
+	  SET rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = now()
+	  RETURNING ` + fxRateColumns
+	return scanFxRate(repo.db.QueryRow(sql, arg.BaseCurrency, arg.QuoteCurrency, arg.Rate, arg.UpdatedBy))
+}
+
+// Get implements FxRateRepository.
+func (repo *fxRateRepository) Get(baseCurrency string, quoteCurrency string) (model.FxRate, error) {
+	sql := `SELECT ` + fxRateColumns + ` FROM fx_rates
+	WHERE base_currency = $1 AND quote_currency = $2`
+	i, err := scanFxRate(repo.db.QueryRow(sql, baseCurrency, quoteCurrency))
+	if err != nil {
+		return model.FxRate{}, notFound(err, "fx rate", baseCurrency+"/"+quoteCurrency)
+	}
+	return i, nil
+}
+
+// List implements FxRateRepository.
+func (repo *fxRateRepository) List() ([]model.FxRate, error) {
+	sql := `SELECT ` + fxRateColumns + ` FROM fx_rates
+	ORDER BY base_currency, quote_currency`
+	rows, err := repo.db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.FxRate{}
+	for rows.Next() {
+		i, err := scanFxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/albar2305/payment-app/model"
+)
+
+type IdempotencyRepository interface {
+	Reserve(arg model.IdempotencyKey) (model.IdempotencyKey, bool, error)
+	Complete(userId string, key string, statusCode int, body []byte) error
+	Release(userId string, key string) error
+	DeleteExpired() (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve implements IdempotencyRepository. It returns true when the key was
+// reserved by this call, or the stored key when an earlier request owns it.
+func (repo *idempotencyRepository) Reserve(arg model.IdempotencyKey) (model.IdempotencyKey, bool, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+	defer tx.Rollback()
+
+	sql := `DELETE FROM idempotency_keys
+	WHERE user_id = $1 AND key = $2 AND expires_at < now()`
+	if _, err := tx.Exec(sql, arg.UserID, arg.Key); err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+
+	sql = `
+	INSERT INTO idempotency_keys (
+		key, user_id, request_path, request_hash, expires_at
+	  ) VALUES (
+		$1, $2, $3, $4, $5
+	  )
+	  ON CONFLICT (user_id, key) DO NOTHING`
+	result, err := tx.Exec(sql, arg.Key, arg.UserID, arg.RequestPath, arg.RequestHash, arg.ExpiresAt)
+	if err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+	if inserted == 1 {
+		if err := tx.Commit(); err != nil {
+			return model.IdempotencyKey{}, false, err
+		}
+		return arg, true, nil
+	}
+
+	sql = `SELECT key, user_id, request_path, request_hash, COALESCE(status_code, 0), COALESCE(response_body, ''), created_at, expires_at
+	FROM idempotency_keys
+	WHERE user_id = $1 AND key = $2`
+	row := tx.QueryRow(sql, arg.UserID, arg.Key)
+	var i model.IdempotencyKey
+	var body string
+	err = row.Scan(
+		&i.Key,
+		&i.UserID,
+		&i.RequestPath,
+		&i.RequestHash,
+		&i.StatusCode,
+		&body,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	if err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+	i.ResponseBody = []byte(body)
+
+	if err := tx.Commit(); err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+	return i, false, nil
+}
+
+// Complete implements IdempotencyRepository.
+func (repo *idempotencyRepository) Complete(userId string, key string, statusCode int, body []byte) error {
+	sql := `UPDATE idempotency_keys
+	SET status_code = $1, response_body = $2
+	WHERE user_id = $3 AND key = $4`
+	_, err := repo.db.Exec(sql, statusCode, string(body), userId, key)
+	return err
+}
+
+// Release implements IdempotencyRepository.
+func (repo *idempotencyRepository) Release(userId string, key string) error {
+	sql := `DELETE FROM idempotency_keys
+	WHERE user_id = $1 AND key = $2`
+	_, err := repo.db.Exec(sql, userId, key)
+	return err
+}
+
+// DeleteExpired implements IdempotencyRepository.
+func (repo *idempotencyRepository) DeleteExpired() (int64, error) {
+	sql := `DELETE FROM idempotency_keys WHERE expires_at < now()`
+	result, err := repo.db.Exec(sql)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type InvoiceRepository interface {
+	Create(arg model.Invoice) (model.Invoice, error)
+	Get(id string) (model.Invoice, error)
+	GetByCode(code string) (model.Invoice, error)
+	ListByMerchantId(merchantId string, status string, params model.PaginationParams) ([]model.Invoice, error)
+	Cancel(id string) (model.Invoice, error)
+	ExpireOverdue() (int, error)
+}
+
+type invoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+// Create implements InvoiceRepository. The invoice takes the currency of the
+// merchant.
+func (repo *invoiceRepository) Create(arg model.Invoice) (model.Invoice, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `
+	INSERT INTO invoices (
+		id, merchant_id, code, amount, currency, description, status, expires_at
+	  )
+	  SELECT $1, id, $3, $4, currency, $5, $6, $7 FROM merchants WHERE id = $2
+	  RETURNING ` + invoiceColumns
+	i, err := scanInvoice(tx.QueryRow(sql, arg.ID, arg.MerchantID, arg.Code, arg.Amount, arg.Description,
+		model.InvoiceStatusOpen, arg.ExpiresAt))
+	if err != nil {
+		return model.Invoice{}, notFound(err, "merchant", arg.MerchantID)
+	}
+
+	sql = `
+	INSERT INTO invoice_items (
+		id, invoice_id, position, description, quantity, unit_price, amount
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	  )`
+	for position, item := range arg.Items {
+		if _, err := tx.Exec(sql, item.ID, i.ID, position, item.Description, item.Quantity, item.UnitPrice, item.Amount); err != nil {
+			return model.Invoice{}, err
+		}
+	}
+	i.Items = arg.Items
+
+	if err := tx.Commit(); err != nil {
+		return model.Invoice{}, fmt.Errorf("error creating invoice: %v", err)
+	}
+	return i, nil
+}
+
+// Get implements InvoiceRepository.
+func (repo *invoiceRepository) Get(id string) (model.Invoice, error) {
+	sql := `SELECT ` + invoiceColumns + ` FROM invoices WHERE id = $1`
+	i, err := scanInvoice(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.Invoice{}, notFound(err, "invoice", id)
+	}
+	i.Items, err = repo.listItems(i.ID)
+	return i, err
+}
+
+// GetByCode implements InvoiceRepository.
+func (repo *invoiceRepository) GetByCode(code string) (model.Invoice, error) {
+	sql := `SELECT ` + invoiceColumns + ` FROM invoices WHERE code = $1`
+	i, err := scanInvoice(repo.db.QueryRow(sql, code))
+	if err != nil {
+		return model.Invoice{}, notFound(err, "invoice with code", code)
+	}
+	i.Items, err = repo.listItems(i.ID)
+	return i, err
+}
+
+// ListByMerchantId implements InvoiceRepository. An empty status lists every
+// invoice. Items are not loaded.
+func (repo *invoiceRepository) ListByMerchantId(merchantId string, status string, params model.PaginationParams) ([]model.Invoice, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", true, []any{merchantId, status})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + invoiceColumns + ` FROM invoices
+	WHERE merchant_id = $1 AND ($2 = '' OR status = $2) AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.Invoice{}
+	for rows.Next() {
+		i, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Cancel implements InvoiceRepository. Only open invoices can be cancelled.
+func (repo *invoiceRepository) Cancel(id string) (model.Invoice, error) {
+	sql := `UPDATE invoices
+	SET status = $1, updated_at = now()
+	WHERE id = $2 AND status = $3
+	RETURNING ` + invoiceColumns
+	i, err := scanInvoice(repo.db.QueryRow(sql, model.InvoiceStatusCancelled, id, model.InvoiceStatusOpen))
+	if isNoRows(err) {
+		if _, err := repo.Get(id); err != nil {
+			return model.Invoice{}, err
+		}
+		return model.Invoice{}, common.ErrInvalidStatus
+	}
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	i.Items, err = repo.listItems(i.ID)
+	return i, err
+}
+
+// ExpireOverdue implements InvoiceRepository.
+func (repo *invoiceRepository) ExpireOverdue() (int, error) {
+	sql := `UPDATE invoices
+	SET status = $1, updated_at = now()
+	WHERE status = $2 AND expires_at <= now()`
+	result, err := repo.db.Exec(sql, model.InvoiceStatusExpired, model.InvoiceStatusOpen)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (repo *invoiceRepository) listItems(invoiceId string) ([]model.InvoiceItem, error) {
+	sql := `SELECT id, description, quantity, unit_price, amount FROM invoice_items
+	WHERE invoice_id = $1
+	ORDER BY position`
+	rows, err := repo.db.Query(sql, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.InvoiceItem{}
+	for rows.Next() {
+		var i model.InvoiceItem
+		if err := rows.Scan(&i.ID, &i.Description, &i.Quantity, &i.UnitPrice, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// lockPayableInvoice locks the invoice that payment t pays and checks that it
+// is open and matches the payment.
+func lockPayableInvoice(tx *sql.Tx, t model.Transaction) error {
+	sql := `SELECT ` + invoiceColumns + ` FROM invoices WHERE id = $1 FOR UPDATE`
+	invoice, err := scanInvoice(tx.QueryRow(sql, t.InvoiceID))
+	if err != nil {
+		return notFound(err, "invoice", t.InvoiceID)
+	}
+	if invoice.Status != model.InvoiceStatusOpen {
+		return fmt.Errorf("%w: invoice %s is %s", common.ErrInvalidStatus, invoice.ID, invoice.Status)
+	}
+	if !invoice.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: invoice %s has expired", common.ErrInvalidStatus, invoice.ID)
+	}
+	if invoice.MerchantID != t.ReceiverMerchantId || invoice.Amount != t.Amount || invoice.Currency != t.Currency {
+		return fmt.Errorf("%w: payment does not match invoice %s", common.ErrInvalidAmount, invoice.ID)
+	}
+	return nil
+}
+
+// markInvoicePaid records payment t on its invoice, which must already be
+// locked.
+func markInvoicePaid(tx *sql.Tx, t model.Transaction) error {
+	sql := `UPDATE invoices
+	SET status = $1, transaction_id = $2, paid_by_customer_id = $3, paid_at = now(), updated_at = now()
+	WHERE id = $4`
+	_, err := tx.Exec(sql, model.InvoiceStatusPaid, t.ID, t.SenderCustomerId, t.InvoiceID)
+	return err
+}
+
+// markInvoiceInReview records that payment t of an invoice is held for
+// review, so the invoice takes no other payment meanwhile. The invoice must
+// already be locked.
+func markInvoiceInReview(tx *sql.Tx, t model.Transaction) error {
+	sql := `UPDATE invoices
+	SET status = $1, transaction_id = $2, updated_at = now()
+	WHERE id = $3`
+	_, err := tx.Exec(sql, model.InvoiceStatusInReview, t.ID, t.InvoiceID)
+	return err
+}
+
+// lockInvoiceInReview locks the invoice of held payment t, which must still
+// be in review for t.
+func lockInvoiceInReview(tx *sql.Tx, t model.Transaction) error {
+	sql := `SELECT ` + invoiceColumns + ` FROM invoices WHERE id = $1 FOR UPDATE`
+	invoice, err := scanInvoice(tx.QueryRow(sql, t.InvoiceID))
+	if err != nil {
+		return notFound(err, "invoice", t.InvoiceID)
+	}
+	if invoice.Status != model.InvoiceStatusInReview || invoice.TransactionID != t.ID {
+		return fmt.Errorf("%w: invoice %s is %s", common.ErrInvalidStatus, invoice.ID, invoice.Status)
+	}
+	return nil
+}
+
+// reopenInvoice makes the invoice of rejected payment t payable again. The
+// expiry job expires it if its time ran out during the review.
+func reopenInvoice(tx *sql.Tx, t model.Transaction) error {
+	sql := `UPDATE invoices
+	SET status = $1, transaction_id = NULL, updated_at = now()
+	WHERE id = $2 AND status = $3 AND transaction_id = $4`
+	_, err := tx.Exec(sql, model.InvoiceStatusOpen, t.InvoiceID, model.InvoiceStatusInReview, t.ID)
+	return err
+}
+
+const invoiceColumns = `id, merchant_id, code, amount, currency, description, status, COALESCE(transaction_id, ''), COALESCE(paid_by_customer_id, ''), expires_at, paid_at, created_at, updated_at`
+
+func scanInvoice(row rowScanner) (model.Invoice, error) {
+	var i model.Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.MerchantID,
+		&i.Code,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.Status,
+		&i.TransactionID,
+		&i.PaidByCustomerID,
+		&i.ExpiresAt,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
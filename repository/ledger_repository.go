@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type LedgerRepository interface {
+	ListAccounts(ownerType string, ownerId string) ([]model.LedgerAccount, error)
+	ListPostings(ownerType string, ownerId string, params model.PaginationParams) ([]model.Posting, error)
+	GetEntry(id string) (model.JournalEntry, error)
+	CreateAdjustment(arg model.CreateAdjustmentRequest) (model.JournalEntry, error)
+	ListBalanceMismatches() ([]model.BalanceMismatch, error)
+}
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+// ListAccounts implements LedgerRepository. An owner has one account per currency.
+func (repo *ledgerRepository) ListAccounts(ownerType string, ownerId string) ([]model.LedgerAccount, error) {
+	sql := `SELECT a.id, a.owner_type, a.owner_id, a.currency, COALESCE(SUM(p.amount), 0), a.created_at
+	FROM ledger_accounts a
+	LEFT JOIN ledger_postings p ON p.account_id = a.id
+	WHERE a.owner_type = $1 AND a.owner_id = $2
+	GROUP BY a.id
+	ORDER BY a.currency`
+	rows, err := repo.db.Query(sql, ownerType, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.LedgerAccount{}
+	for rows.Next() {
+		var i model.LedgerAccount
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerType,
+			&i.OwnerID,
+			&i.Currency,
+			&i.Balance,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListPostings implements LedgerRepository.
+func (repo *ledgerRepository) ListPostings(ownerType string, ownerId string, params model.PaginationParams) ([]model.Posting, error) {
+	cond, page, args, err := pageClauses(params, "p.created_at", "timestamptz", false, []any{ownerType, ownerId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + postingColumns + `
+	FROM ledger_postings p
+	JOIN ledger_accounts a ON a.id = p.account_id
+	WHERE a.owner_type = $1 AND a.owner_id = $2 AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanPostings(rows)
+}
+
+// GetEntry implements LedgerRepository.
+func (repo *ledgerRepository) GetEntry(id string) (model.JournalEntry, error) {
+	sql := `SELECT id, kind, COALESCE(reference_id, ''), COALESCE(description, ''), created_at
+	FROM journal_entries WHERE id = $1`
+	row := repo.db.QueryRow(sql, id)
+	var i model.JournalEntry
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.ReferenceID,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		return model.JournalEntry{}, notFound(err, "journal entry", id)
+	}
+
+	sql = `SELECT ` + postingColumns + `
+	FROM ledger_postings p
+	JOIN ledger_accounts a ON a.id = p.account_id
+	WHERE p.journal_entry_id = $1
+	ORDER BY p.amount`
+	rows, err := repo.db.Query(sql, id)
+	if err != nil {
+		return model.JournalEntry{}, err
+	}
+	defer rows.Close()
+	i.Postings, err = scanPostings(rows)
+	return i, err
+}
+
+// CreateAdjustment implements LedgerRepository.
+func (repo *ledgerRepository) CreateAdjustment(arg model.CreateAdjustmentRequest) (model.JournalEntry, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.JournalEntry{}, err
+	}
+	defer tx.Rollback()
+
+	// Adjustments respect freezes and cannot take away held funds.
+	wallet, err := lockWallet(tx, arg.OwnerType, arg.OwnerID)
+	if err != nil {
+		return model.JournalEntry{}, err
+	}
+	if wallet.frozen || (arg.Amount > 0 && wallet.frozenIncoming) {
+		return model.JournalEntry{}, frozenError(arg.OwnerType, arg.OwnerID)
+	}
+	if wallet.available+arg.Amount < 0 {
+		return model.JournalEntry{}, fmt.Errorf("adjustment is more than the available balance of %s %s: %w", arg.OwnerType, arg.OwnerID, common.ErrInsufficientFunds)
+	}
+
+	sql := `UPDATE ` + wallet.table + `
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, arg.Amount, arg.OwnerID); err != nil {
+		return model.JournalEntry{}, err
+	}
+	currency := wallet.currency
+
+	entry, err := postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindAdjustment,
+		ReferenceID: arg.OwnerID,
+		Description: arg.Description,
+		Postings: []model.Posting{
+			{OwnerType: arg.OwnerType, OwnerID: arg.OwnerID, Currency: currency, Amount: arg.Amount},
+			{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountAdjustment, Currency: currency, Amount: -arg.Amount},
+		},
+	})
+	if err != nil {
+		return model.JournalEntry{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+// ListBalanceMismatches implements LedgerRepository.
+func (repo *ledgerRepository) ListBalanceMismatches() ([]model.BalanceMismatch, error) {
+	sql := `SELECT 'customer', c.id, c.currency, c.balance, COALESCE(SUM(p.amount), 0)
+	FROM customers c
+	LEFT JOIN ledger_accounts a ON a.owner_type = 'customer' AND a.owner_id = c.id AND a.currency = c.currency
+	LEFT JOIN ledger_postings p ON p.account_id = a.id
+	GROUP BY c.id, c.currency, c.balance
+	HAVING c.balance <> COALESCE(SUM(p.amount), 0)
+	UNION ALL
+	SELECT 'merchant', m.id, m.currency, m.balance, COALESCE(SUM(p.amount), 0)
+	FROM merchants m
+	LEFT JOIN ledger_accounts a ON a.owner_type = 'merchant' AND a.owner_id = m.id AND a.currency = m.currency
+	LEFT JOIN ledger_postings p ON p.account_id = a.id
+	GROUP BY m.id, m.currency, m.balance
+	HAVING m.balance <> COALESCE(SUM(p.amount), 0)`
+	rows, err := repo.db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.BalanceMismatch{}
+	for rows.Next() {
+		var i model.BalanceMismatch
+		if err := rows.Scan(
+			&i.OwnerType,
+			&i.OwnerID,
+			&i.Currency,
+			&i.StoredBalance,
+			&i.LedgerBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// postJournal writes a balanced journal entry and its postings inside tx.
+// Every repository that changes a stored balance must call it in the same
+// transaction so the ledger stays the source of truth.
+func postJournal(tx *sql.Tx, entry model.JournalEntry) (model.JournalEntry, error) {
+	if len(entry.Postings) < 2 {
+		return model.JournalEntry{}, common.ErrUnbalancedEntry
+	}
+	totals := map[string]int64{}
+	for _, p := range entry.Postings {
+		if p.Currency == "" {
+			return model.JournalEntry{}, common.ErrUnbalancedEntry
+		}
+		totals[p.Currency] += p.Amount
+	}
+	for _, total := range totals {
+		if total != 0 {
+			return model.JournalEntry{}, common.ErrUnbalancedEntry
+		}
+	}
+
+	if entry.ID == "" {
+		entry.ID = common.GenerateID()
+	}
+	sql := `
+	INSERT INTO journal_entries (
+		id, kind, reference_id, description
+	  ) VALUES (
+		$1, $2, $3, $4
+	  ) RETURNING created_at`
+	err := tx.QueryRow(sql, entry.ID, entry.Kind, entry.ReferenceID, entry.Description).Scan(&entry.CreatedAt)
+	if err != nil {
+		return model.JournalEntry{}, err
+	}
+
+	postings := make([]model.Posting, 0, len(entry.Postings))
+	for _, p := range entry.Postings {
+		accountId, err := ensureAccount(tx, p.OwnerType, p.OwnerID, p.Currency)
+		if err != nil {
+			return model.JournalEntry{}, err
+		}
+		p.ID = common.GenerateID()
+		p.JournalEntryID = entry.ID
+		p.AccountID = accountId
+		sql := `
+		INSERT INTO ledger_postings (
+			id, journal_entry_id, account_id, amount
+		  ) VALUES (
+			$1, $2, $3, $4
+		  ) RETURNING created_at`
+		if err := tx.QueryRow(sql, p.ID, p.JournalEntryID, p.AccountID, p.Amount).Scan(&p.CreatedAt); err != nil {
+			return model.JournalEntry{}, err
+		}
+		postings = append(postings, p)
+	}
+	entry.Postings = postings
+	return entry, nil
+}
+
+// ensureAccount returns the ledger account of an owner in a currency,
+// opening it on first use.
+func ensureAccount(tx *sql.Tx, ownerType string, ownerId string, currency string) (string, error) {
+	sql := `
+	INSERT INTO ledger_accounts (
+		id, owner_type, owner_id, currency
+	  ) VALUES (
+		$1, $2, $3, $4
+	  )
+	  ON CONFLICT (owner_type, owner_id, currency) DO UPDATE SET owner_type = EXCLUDED.owner_type
+	  RETURNING id`
+	var id string
+	err := tx.QueryRow(sql, common.GenerateID(), ownerType, ownerId, currency).Scan(&id)
+	return id, err
+}
+
+const postingColumns = `p.id, p.journal_entry_id, p.account_id, a.owner_type, a.owner_id, a.currency, p.amount, p.created_at`
+
+func scanPostings(rows *sql.Rows) ([]model.Posting, error) {
+	items := []model.Posting{}
+	for rows.Next() {
+		var i model.Posting
+		if err := rows.Scan(
+			&i.ID,
+			&i.JournalEntryID,
+			&i.AccountID,
+			&i.OwnerType,
+			&i.OwnerID,
+			&i.Currency,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
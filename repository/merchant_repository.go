@@ -21,16 +21,9 @@ func NewMerchanRepository(db *sql.DB) MerchantRepository {
 	return &merchantRepository{db: db}
 }
 
-// Create implements MerchantRepository.
-func (repo *merchantRepository) Create(arg model.Merchant) (model.Merchant, error) {
-	sql := `
-	INSERT INTO merchants (
-		id, name, description, business_type, balance
-	  ) VALUES (
-		$1, $2, $3, $4, $5
-	  ) RETURNING id, name, description, business_type, balance, created_at`
+const merchantColumns = `id, name, description, business_type, balance, held_amount, currency, frozen, frozen_incoming, created_at`
 
-	row := repo.db.QueryRow(sql, arg.ID, arg.Name, arg.Description, arg.BusinesType, arg.Balance)
+func scanMerchant(row rowScanner) (model.Merchant, error) {
 	var i model.Merchant
 	err := row.Scan(
 		&i.ID,
@@ -38,12 +31,27 @@ func (repo *merchantRepository) Create(arg model.Merchant) (model.Merchant, erro
 		&i.Description,
 		&i.BusinesType,
 		&i.Balance,
+		&i.HeldAmount,
+		&i.Currency,
+		&i.Frozen,
+		&i.FrozenIncoming,
 		&i.CreatedAt,
 	)
-
 	return i, err
 }
 
+// Create implements MerchantRepository.
+func (repo *merchantRepository) Create(arg model.Merchant) (model.Merchant, error) {
+	sql := `
+	INSERT INTO merchants (
+		id, name, description, business_type, balance, currency
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6
+	  ) RETURNING ` + merchantColumns
+
+	return scanMerchant(repo.db.QueryRow(sql, arg.ID, arg.Name, arg.Description, arg.BusinesType, arg.Balance, arg.Currency))
+}
+
 // Delete implements MerchantRepository.
 func (repo *merchantRepository) Delete(id string) error {
 	sql := `
@@ -57,43 +65,33 @@ func (repo *merchantRepository) Delete(id string) error {
 
 // Get implements MerchantRepository.
 func (repo *merchantRepository) Get(id string) (model.Merchant, error) {
-	sql := `SELECT id, name, description, business_type, balance, created_at FROM merchants
-	WHERE name = $1 LIMIT 1`
-	row := repo.db.QueryRow(sql, id)
-	var i model.Merchant
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Description,
-		&i.BusinesType,
-		&i.Balance,
-		&i.CreatedAt,
-	)
-	return i, err
+	sql := `SELECT ` + merchantColumns + ` FROM merchants
+	WHERE id = $1 LIMIT 1`
+	i, err := scanMerchant(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.Merchant{}, notFound(err, "merchant", id)
+	}
+	return i, nil
 }
 
 // List implements MerchantRepository.
 func (repo *merchantRepository) List(params model.PaginationParams) ([]model.Merchant, error) {
-	sql := `SELECT id, name, description, business_type, balance, created_at FROM merchants
-	ORDER BY created_at
-	LIMIT $1
-	OFFSET $2`
-	rows, err := repo.db.Query(sql, params.Limit, params.Offset)
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + merchantColumns + ` FROM merchants
+	WHERE ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	items := []model.Merchant{}
 	for rows.Next() {
-		var i model.Merchant
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.BusinesType,
-			&i.Balance,
-			&i.CreatedAt,
-		); err != nil {
+		i, err := scanMerchant(rows)
+		if err != nil {
 			return nil, err
 		}
 		items = append(items, i)
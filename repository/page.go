@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// pageClauses returns the condition and the ORDER BY, LIMIT and OFFSET
+// clauses that select the page of params from a listing sorted by column of
+// SQL type columnType, then by id. A column qualified with a table alias,
+// like r.created_at, sorts by the id of that table. args are the arguments
+// of the query so far, and the clauses add theirs after them.
+func pageClauses(params model.PaginationParams, column string, columnType string, desc bool, args []any) (string, string, []any, error) {
+	id := "id"
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		id = column[:i+1] + id
+	}
+	order, cmp := "ASC", ">"
+	if desc {
+		order, cmp = "DESC", "<"
+	}
+
+	cond := "TRUE"
+	limit := params.Limit
+	if params.Keyset {
+		limit++
+		if c := params.Cursor; c != nil {
+			if err := checkCursorValue(c.Value, columnType); err != nil {
+				return "", "", nil, err
+			}
+			// The rows before the cursor are read backwards from it.
+			if c.Before {
+				if desc {
+					order, cmp = "ASC", ">"
+				} else {
+					order, cmp = "DESC", "<"
+				}
+			}
+			args = append(args, c.Value, c.ID)
+			cond = fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", column, id, cmp, len(args)-1, columnType, len(args))
+		}
+	}
+
+	args = append(args, limit)
+	tail := fmt.Sprintf("ORDER BY %s %s, %s %s LIMIT $%d", column, order, id, order, len(args))
+	if !params.Keyset {
+		args = append(args, params.Offset)
+		tail += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return cond, tail, args, nil
+}
+
+// checkCursorValue makes sure a cursor value from a client fits its column
+// before it reaches the query.
+func checkCursorValue(value string, columnType string) error {
+	var err error
+	switch columnType {
+	case "timestamptz":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	case "bigint":
+		_, err = strconv.ParseInt(value, 10, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: it does not belong to this listing", common.ErrInvalidCursor)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+func TestPageClauses(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     model.PaginationParams
+		column     string
+		columnType string
+		desc       bool
+		wantCond   string
+		wantTail   string
+		wantArgs   []any
+	}{
+		{
+			name:       "offset",
+			params:     model.PaginationParams{Limit: 5, Offset: 10},
+			column:     "created_at",
+			columnType: "timestamptz",
+			wantCond:   "TRUE",
+			wantTail:   "ORDER BY created_at ASC, id ASC LIMIT $2 OFFSET $3",
+			wantArgs:   []any{"c1", int32(5), int32(10)},
+		},
+		{
+			name:       "first keyset page",
+			params:     model.PaginationParams{Limit: 5, Keyset: true},
+			column:     "created_at",
+			columnType: "timestamptz",
+			desc:       true,
+			wantCond:   "TRUE",
+			wantTail:   "ORDER BY created_at DESC, id DESC LIMIT $2",
+			wantArgs:   []any{"c1", int32(6)},
+		},
+		{
+			// the id comes from the table the sort column belongs to
+			name:       "qualified column",
+			params:     model.PaginationParams{Limit: 5, Keyset: true, Cursor: &model.Cursor{Value: "2024-01-31T17:00:00Z", ID: "r9"}},
+			column:     "r.created_at",
+			columnType: "timestamptz",
+			desc:       true,
+			wantCond:   "(r.created_at, r.id) < ($2::timestamptz, $3)",
+			wantTail:   "ORDER BY r.created_at DESC, r.id DESC LIMIT $4",
+			wantArgs:   []any{"c1", "2024-01-31T17:00:00Z", "r9", int32(6)},
+		},
+		{
+			// pages before the cursor are read backwards
+			name:       "before cursor",
+			params:     model.PaginationParams{Limit: 5, Keyset: true, Cursor: &model.Cursor{Value: "2024-01-31", ID: "b2", Before: true}},
+			column:     "settlement_date",
+			columnType: "date",
+			desc:       true,
+			wantCond:   "(settlement_date, id) > ($2::date, $3)",
+			wantTail:   "ORDER BY settlement_date ASC, id ASC LIMIT $4",
+			wantArgs:   []any{"c1", "2024-01-31", "b2", int32(6)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, tail, args, err := pageClauses(tt.params, tt.column, tt.columnType, tt.desc, []any{"c1"})
+			if err != nil {
+				t.Fatalf("pageClauses: %v", err)
+			}
+			if cond != tt.wantCond || tail != tt.wantTail {
+				t.Errorf("pageClauses = %q, %q, want %q, %q", cond, tail, tt.wantCond, tt.wantTail)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPageClausesRejectsForeignCursor(t *testing.T) {
+	tests := []struct {
+		value      string
+		columnType string
+	}{
+		{"2024-01-31", "timestamptz"},
+		{"2024-01-31T17:00:00Z", "date"},
+		{"captured", "bigint"},
+	}
+	for _, tt := range tests {
+		params := model.PaginationParams{Limit: 5, Keyset: true, Cursor: &model.Cursor{Value: tt.value, ID: "x"}}
+		if _, _, _, err := pageClauses(params, "c", tt.columnType, false, nil); !errors.Is(err, common.ErrInvalidCursor) {
+			t.Errorf("cursor %q on a %s column: error = %v, want ErrInvalidCursor", tt.value, tt.columnType, err)
+		}
+	}
+}
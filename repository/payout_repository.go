@@ -0,0 +1,427 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type PayoutRepository interface {
+	Create(arg model.Payout) (model.Payout, error)
+	ListByMerchantId(merchantId string, params model.PaginationParams) ([]model.Payout, error)
+	ListPendingDates(before time.Time) ([]time.Time, error)
+	CreateBatch(id string, date time.Time) (model.SettlementBatch, error)
+	ListUnfinishedBatches() ([]string, error)
+	ListByBatchId(batchId string) ([]model.Payout, error)
+	MarkPaid(id string, providerReference string) (model.Payout, error)
+	MarkFailed(id string, reason string) (model.Payout, error)
+	CompleteBatch(id string, filePath string) (model.SettlementBatch, error)
+	GetBatch(id string) (model.SettlementBatch, error)
+	ListBatches(params model.PaginationParams) ([]model.SettlementBatch, error)
+}
+
+type payoutRepository struct {
+	db *sql.DB
+}
+
+func NewPayoutRepository(db *sql.DB) PayoutRepository {
+	return &payoutRepository{db: db}
+}
+
+// Create implements PayoutRepository. The amount leaves the merchant balance
+// immediately and waits in the pending payouts account until it is settled.
+func (repo *payoutRepository) Create(arg model.Payout) (model.Payout, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Payout{}, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(tx, model.AccountOwnerMerchant, arg.MerchantID)
+	if err != nil {
+		return model.Payout{}, err
+	}
+	if wallet.frozen {
+		return model.Payout{}, frozenError(model.AccountOwnerMerchant, arg.MerchantID)
+	}
+	if wallet.available < arg.Amount {
+		return model.Payout{}, common.ErrInsufficientFunds
+	}
+
+	sql := `
+	INSERT INTO payouts (
+		id, merchant_id, amount, currency, bank_code, account_number, account_name, status
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	  ) RETURNING ` + payoutColumns
+	i, err := scanPayout(tx.QueryRow(sql, arg.ID, arg.MerchantID, arg.Amount, wallet.currency, arg.BankCode, arg.AccountNumber, arg.AccountName, model.PayoutStatusPending))
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	sql = `UPDATE merchants
+	SET balance = balance - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, i.Amount, i.MerchantID); err != nil {
+		return model.Payout{}, err
+	}
+
+	_, err = postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindPayout,
+		ReferenceID: i.ID,
+		Description: "payout to " + i.BankCode + " " + i.AccountNumber,
+		Postings: []model.Posting{
+			{OwnerType: model.AccountOwnerMerchant, OwnerID: i.MerchantID, Currency: i.Currency, Amount: -i.Amount},
+			{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountPayoutsPending, Currency: i.Currency, Amount: i.Amount},
+		},
+	})
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Payout{}, err
+	}
+	return i, nil
+}
+
+// ListByMerchantId implements PayoutRepository.
+func (repo *payoutRepository) ListByMerchantId(merchantId string, params model.PaginationParams) ([]model.Payout, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", true, []any{merchantId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + payoutColumns + ` FROM payouts
+	WHERE merchant_id = $1 AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanPayouts(rows)
+}
+
+// ListPendingDates implements PayoutRepository. It returns the days before
+// the given date that still have payouts waiting for a batch.
+func (repo *payoutRepository) ListPendingDates(before time.Time) ([]time.Time, error) {
+	sql := `SELECT DISTINCT created_at::date FROM payouts
+	WHERE status = $1 AND created_at::date < $2::date
+	ORDER BY 1`
+	rows, err := repo.db.Query(sql, model.PayoutStatusPending, before.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []time.Time{}
+	for rows.Next() {
+		var i time.Time
+		if err := rows.Scan(&i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CreateBatch implements PayoutRepository. It claims every pending payout
+// requested on date and moves it to processing.
+func (repo *payoutRepository) CreateBatch(id string, date time.Time) (model.SettlementBatch, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `
+	INSERT INTO settlement_batches (
+		id, settlement_date, status
+	  ) VALUES (
+		$1, $2::date, $3
+	  )`
+	if _, err := tx.Exec(sql, id, date.Format(time.DateOnly), model.SettlementStatusProcessing); err != nil {
+		return model.SettlementBatch{}, err
+	}
+
+	sql = `UPDATE payouts
+	SET batch_id = $1, status = $2, updated_at = now()
+	WHERE status = $3 AND created_at::date = $4::date`
+	result, err := tx.Exec(sql, id, model.PayoutStatusProcessing, model.PayoutStatusPending, date.Format(time.DateOnly))
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	if count == 0 {
+		return model.SettlementBatch{}, common.ErrNoPendingPayouts
+	}
+
+	sql = `UPDATE settlement_batches
+	SET payout_count = $1
+	WHERE id = $2
+	RETURNING ` + settlementBatchColumns
+	i, err := scanSettlementBatch(tx.QueryRow(sql, count, id))
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.SettlementBatch{}, err
+	}
+	return i, nil
+}
+
+// ListUnfinishedBatches implements PayoutRepository.
+func (repo *payoutRepository) ListUnfinishedBatches() ([]string, error) {
+	sql := `SELECT id FROM settlement_batches
+	WHERE status = $1
+	ORDER BY settlement_date`
+	rows, err := repo.db.Query(sql, model.SettlementStatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var i string
+		if err := rows.Scan(&i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListByBatchId implements PayoutRepository.
+func (repo *payoutRepository) ListByBatchId(batchId string) ([]model.Payout, error) {
+	sql := `SELECT ` + payoutColumns + ` FROM payouts
+	WHERE batch_id = $1
+	ORDER BY created_at`
+	rows, err := repo.db.Query(sql, batchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanPayouts(rows)
+}
+
+// MarkPaid implements PayoutRepository.
+func (repo *payoutRepository) MarkPaid(id string, providerReference string) (model.Payout, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Payout{}, err
+	}
+	defer tx.Rollback()
+
+	p, err := lockProcessingPayout(tx, id)
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	sql := `UPDATE payouts
+	SET status = $1, provider_reference = $2, updated_at = now()
+	WHERE id = $3
+	RETURNING ` + payoutColumns
+	i, err := scanPayout(tx.QueryRow(sql, model.PayoutStatusPaid, providerReference, id))
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	_, err = postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindPayoutPaid,
+		ReferenceID: p.ID,
+		Description: "bank transfer " + providerReference,
+		Postings: []model.Posting{
+			{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountPayoutsPending, Currency: p.Currency, Amount: -p.Amount},
+			{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountBankSettlement, Currency: p.Currency, Amount: p.Amount},
+		},
+	})
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Payout{}, err
+	}
+	return i, nil
+}
+
+// MarkFailed implements PayoutRepository. The amount is returned to the
+// merchant balance.
+func (repo *payoutRepository) MarkFailed(id string, reason string) (model.Payout, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Payout{}, err
+	}
+	defer tx.Rollback()
+
+	p, err := lockProcessingPayout(tx, id)
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	sql := `UPDATE merchants
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, p.Amount, p.MerchantID); err != nil {
+		return model.Payout{}, err
+	}
+
+	sql = `UPDATE payouts
+	SET status = $1, failure_reason = $2, updated_at = now()
+	WHERE id = $3
+	RETURNING ` + payoutColumns
+	i, err := scanPayout(tx.QueryRow(sql, model.PayoutStatusFailed, reason, id))
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	_, err = postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindPayoutFail,
+		ReferenceID: p.ID,
+		Description: reason,
+		Postings: []model.Posting{
+			{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountPayoutsPending, Currency: p.Currency, Amount: -p.Amount},
+			{OwnerType: model.AccountOwnerMerchant, OwnerID: p.MerchantID, Currency: p.Currency, Amount: p.Amount},
+		},
+	})
+	if err != nil {
+		return model.Payout{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Payout{}, err
+	}
+	return i, nil
+}
+
+// lockProcessingPayout locks a payout that is still waiting for the bank.
+func lockProcessingPayout(tx *sql.Tx, id string) (model.Payout, error) {
+	sql := `SELECT ` + payoutColumns + ` FROM payouts WHERE id = $1 FOR UPDATE`
+	p, err := scanPayout(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.Payout{}, notFound(err, "payout", id)
+	}
+	if p.Status != model.PayoutStatusProcessing {
+		return model.Payout{}, common.ErrInvalidStatus
+	}
+	return p, nil
+}
+
+// CompleteBatch implements PayoutRepository.
+func (repo *payoutRepository) CompleteBatch(id string, filePath string) (model.SettlementBatch, error) {
+	sql := `UPDATE settlement_batches
+	SET status = $1, file_path = $2, completed_at = now()
+	WHERE id = $3
+	RETURNING ` + settlementBatchColumns
+	i, err := scanSettlementBatch(repo.db.QueryRow(sql, model.SettlementStatusCompleted, filePath, id))
+	if err != nil {
+		return model.SettlementBatch{}, notFound(err, "settlement batch", id)
+	}
+	return i, nil
+}
+
+// GetBatch implements PayoutRepository.
+func (repo *payoutRepository) GetBatch(id string) (model.SettlementBatch, error) {
+	sql := `SELECT ` + settlementBatchColumns + ` FROM settlement_batches WHERE id = $1`
+	i, err := scanSettlementBatch(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.SettlementBatch{}, notFound(err, "settlement batch", id)
+	}
+	i.Payouts, err = repo.ListByBatchId(id)
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	return i, nil
+}
+
+// ListBatches implements PayoutRepository.
+func (repo *payoutRepository) ListBatches(params model.PaginationParams) ([]model.SettlementBatch, error) {
+	cond, page, args, err := pageClauses(params, "settlement_date", "date", true, nil)
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + settlementBatchColumns + ` FROM settlement_batches
+	WHERE ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.SettlementBatch{}
+	for rows.Next() {
+		i, err := scanSettlementBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const payoutColumns = `id, merchant_id, amount, currency, bank_code, account_number, account_name, status, COALESCE(batch_id, ''), COALESCE(provider_reference, ''), COALESCE(failure_reason, ''), created_at, updated_at`
+
+func scanPayout(row rowScanner) (model.Payout, error) {
+	var i model.Payout
+	err := row.Scan(
+		&i.ID,
+		&i.MerchantID,
+		&i.Amount,
+		&i.Currency,
+		&i.BankCode,
+		&i.AccountNumber,
+		&i.AccountName,
+		&i.Status,
+		&i.BatchID,
+		&i.ProviderReference,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+func scanPayouts(rows *sql.Rows) ([]model.Payout, error) {
+	items := []model.Payout{}
+	for rows.Next() {
+		i, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const settlementBatchColumns = `id, settlement_date, status, payout_count, COALESCE(file_path, ''), created_at, completed_at`
+
+func scanSettlementBatch(row rowScanner) (model.SettlementBatch, error) {
+	var i model.SettlementBatch
+	err := row.Scan(
+		&i.ID,
+		&i.SettlementDate,
+		&i.Status,
+		&i.PayoutCount,
+		&i.FilePath,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,416 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/lib/pq"
+)
+
+type PromoRepository interface {
+	Create(arg model.PromoCampaign) (model.PromoCampaign, error)
+	Update(arg model.PromoCampaign) (model.PromoCampaign, error)
+	Get(id string) (model.PromoCampaign, error)
+	GetByCode(code string) (model.PromoCampaign, error)
+	List(params model.PaginationParams) ([]model.PromoCampaign, error)
+	End(id string) (model.PromoCampaign, error)
+	CountRedemptions(campaignId string, customerId string) (int64, error)
+	ListRedemptions(campaignId string, params model.PaginationParams) ([]model.PromoRedemption, error)
+	ListDueCashback(before time.Time) ([]string, error)
+	CreditCashback(id string) (model.PromoRedemption, error)
+}
+
+type promoRepository struct {
+	db *sql.DB
+}
+
+func NewPromoRepository(db *sql.DB) PromoRepository {
+	return &promoRepository{db: db}
+}
+
+// Create implements PromoRepository.
+func (repo *promoRepository) Create(arg model.PromoCampaign) (model.PromoCampaign, error) {
+	sql := `
+	INSERT INTO promo_campaigns (
+		id, code, name, reward_type, currency, percentage_bps, fixed_amount, max_reward, min_spend,
+		max_uses, max_uses_per_customer, merchant_ids, business_types, starts_at, ends_at, active
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+	  ) RETURNING ` + promoCampaignColumns
+	return scanPromoCampaign(repo.db.QueryRow(sql, arg.ID, arg.Code, arg.Name, arg.RewardType, arg.Currency, arg.PercentageBps,
+		arg.FixedAmount, arg.MaxReward, arg.MinSpend, arg.MaxUses, arg.MaxUsesPerCustomer, pq.Array(arg.MerchantIDs),
+		pq.Array(arg.BusinessTypes), arg.StartsAt, arg.EndsAt, arg.Active))
+}
+
+// Update implements PromoRepository. The usage count is kept.
+func (repo *promoRepository) Update(arg model.PromoCampaign) (model.PromoCampaign, error) {
+	sql := `UPDATE promo_campaigns
+	SET code = $2, name = $3, reward_type = $4, currency = $5, percentage_bps = $6, fixed_amount = $7,
+		max_reward = $8, min_spend = $9, max_uses = $10, max_uses_per_customer = $11, merchant_ids = $12,
+		business_types = $13, starts_at = $14, ends_at = $15, active = $16, updated_at = now()
+	WHERE id = $1
+	RETURNING ` + promoCampaignColumns
+	i, err := scanPromoCampaign(repo.db.QueryRow(sql, arg.ID, arg.Code, arg.Name, arg.RewardType, arg.Currency, arg.PercentageBps,
+		arg.FixedAmount, arg.MaxReward, arg.MinSpend, arg.MaxUses, arg.MaxUsesPerCustomer, pq.Array(arg.MerchantIDs),
+		pq.Array(arg.BusinessTypes), arg.StartsAt, arg.EndsAt, arg.Active))
+	if err != nil {
+		return model.PromoCampaign{}, notFound(err, "promo campaign", arg.ID)
+	}
+	return i, nil
+}
+
+// Get implements PromoRepository.
+func (repo *promoRepository) Get(id string) (model.PromoCampaign, error) {
+	sql := `SELECT ` + promoCampaignColumns + ` FROM promo_campaigns WHERE id = $1`
+	i, err := scanPromoCampaign(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.PromoCampaign{}, notFound(err, "promo campaign", id)
+	}
+	return i, nil
+}
+
+// GetByCode implements PromoRepository.
+func (repo *promoRepository) GetByCode(code string) (model.PromoCampaign, error) {
+	sql := `SELECT ` + promoCampaignColumns + ` FROM promo_campaigns WHERE code = $1`
+	i, err := scanPromoCampaign(repo.db.QueryRow(sql, code))
+	if err != nil {
+		return model.PromoCampaign{}, notFound(err, "promo code", code)
+	}
+	return i, nil
+}
+
+// List implements PromoRepository.
+func (repo *promoRepository) List(params model.PaginationParams) ([]model.PromoCampaign, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", true, nil)
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + promoCampaignColumns + ` FROM promo_campaigns
+	WHERE ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.PromoCampaign{}
+	for rows.Next() {
+		i, err := scanPromoCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// End implements PromoRepository. An ended campaign cannot be redeemed any
+// more; its redemptions are kept and pending cashback is still credited.
+func (repo *promoRepository) End(id string) (model.PromoCampaign, error) {
+	sql := `UPDATE promo_campaigns
+	SET active = false, updated_at = now()
+	WHERE id = $1
+	RETURNING ` + promoCampaignColumns
+	i, err := scanPromoCampaign(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.PromoCampaign{}, notFound(err, "promo campaign", id)
+	}
+	return i, nil
+}
+
+// CountRedemptions implements PromoRepository. Cancelled redemptions do not
+// count.
+func (repo *promoRepository) CountRedemptions(campaignId string, customerId string) (int64, error) {
+	sql := `SELECT COUNT(*) FROM promo_redemptions
+	WHERE campaign_id = $1 AND customer_id = $2 AND status <> $3`
+	var count int64
+	err := repo.db.QueryRow(sql, campaignId, customerId, model.PromoRedemptionStatusCancelled).Scan(&count)
+	return count, err
+}
+
+// ListRedemptions implements PromoRepository.
+func (repo *promoRepository) ListRedemptions(campaignId string, params model.PaginationParams) ([]model.PromoRedemption, error) {
+	cond, page, args, err := pageClauses(params, "r.created_at", "timestamptz", true, []any{campaignId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + promoRedemptionColumns + ` FROM promo_redemptions r
+	JOIN promo_campaigns c ON c.id = r.campaign_id
+	WHERE r.campaign_id = $1 AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.PromoRedemption{}
+	for rows.Next() {
+		i, err := scanPromoRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListDueCashback implements PromoRepository. It returns pending cashback
+// redeemed before the given time whose payment is no longer in review.
+func (repo *promoRepository) ListDueCashback(before time.Time) ([]string, error) {
+	sql := `SELECT r.id FROM promo_redemptions r
+	JOIN transactions t ON t.id = r.transaction_id
+	WHERE r.status = $1 AND r.created_at < $2 AND t.status <> $3
+	ORDER BY r.created_at`
+	rows, err := repo.db.Query(sql, model.PromoRedemptionStatusPending, before, model.TransactionStatusPendingReview)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CreditCashback implements PromoRepository. The cashback shrinks with the
+// share of the payment that was refunded, and is cancelled when nothing of
+// the payment is left. Cashback for a wallet frozen for incoming payments
+// stays pending until the freeze is lifted.
+func (repo *promoRepository) CreditCashback(id string) (model.PromoRedemption, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.PromoRedemption{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT status, transaction_id, amount FROM promo_redemptions WHERE id = $1 FOR UPDATE`
+	var status, transactionId string
+	var amount int64
+	if err := tx.QueryRow(sql, id).Scan(&status, &transactionId, &amount); err != nil {
+		return model.PromoRedemption{}, notFound(err, "promo redemption", id)
+	}
+	if status != model.PromoRedemptionStatusPending {
+		return model.PromoRedemption{}, fmt.Errorf("%w: promo redemption %s is %s", common.ErrInvalidStatus, id, status)
+	}
+
+	sql = `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR SHARE`
+	t, err := scanTransaction(tx.QueryRow(sql, transactionId))
+	if err != nil {
+		return model.PromoRedemption{}, err
+	}
+	if t.Status == model.TransactionStatusPendingReview {
+		return model.PromoRedemption{}, common.ErrInvalidStatus
+	}
+
+	remaining := t.CapturedAmount - t.RefundedAmount
+	if t.Status != model.TransactionStatusCaptured || remaining <= 0 {
+		if err := cancelPromoRedemption(tx, t.ID); err != nil {
+			return model.PromoRedemption{}, err
+		}
+		return commitPromoRedemption(tx, id)
+	}
+
+	wallet, err := lockWallet(tx, model.AccountOwnerCustomer, t.SenderCustomerId)
+	if err != nil {
+		return model.PromoRedemption{}, err
+	}
+	if wallet.frozenIncoming {
+		return model.PromoRedemption{}, frozenError(model.AccountOwnerCustomer, t.SenderCustomerId)
+	}
+
+	// Cashback is earned in the payment currency and paid in the customer
+	// currency at the rate of the payment.
+	earned := proportion(remaining, t.CapturedAmount, amount)
+	credited := proportion(earned, t.Amount, t.SourceAmount)
+	if credited > 0 {
+		sql = `UPDATE customers
+		SET balance = balance + $1
+		WHERE id = $2`
+		if _, err := tx.Exec(sql, credited, t.SenderCustomerId); err != nil {
+			return model.PromoRedemption{}, err
+		}
+
+		_, err = postJournal(tx, model.JournalEntry{
+			Kind:        model.JournalKindCashback,
+			ReferenceID: id,
+			Description: "cashback for transaction " + t.ID,
+			Postings: []model.Posting{
+				{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountPromotions, Currency: t.SourceCurrency, Amount: -credited},
+				{OwnerType: model.AccountOwnerCustomer, OwnerID: t.SenderCustomerId, Currency: t.SourceCurrency, Amount: credited},
+			},
+		})
+		if err != nil {
+			return model.PromoRedemption{}, err
+		}
+	}
+
+	sql = `UPDATE promo_redemptions
+	SET status = $1, credited_amount = $2, credited_at = now()
+	WHERE id = $3`
+	if _, err := tx.Exec(sql, model.PromoRedemptionStatusCredited, credited, id); err != nil {
+		return model.PromoRedemption{}, err
+	}
+	return commitPromoRedemption(tx, id)
+}
+
+func commitPromoRedemption(tx *sql.Tx, id string) (model.PromoRedemption, error) {
+	sql := `SELECT ` + promoRedemptionColumns + ` FROM promo_redemptions r
+	JOIN promo_campaigns c ON c.id = r.campaign_id
+	WHERE r.id = $1`
+	i, err := scanPromoRedemption(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.PromoRedemption{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.PromoRedemption{}, err
+	}
+	return i, nil
+}
+
+// redeemPromo records the use of a promo code by payment t, which must
+// already be stored. The campaign is locked so its usage caps hold under
+// concurrent payments.
+func redeemPromo(tx *sql.Tx, t model.Transaction) (*model.PromoRedemption, error) {
+	promo := t.Promo
+	sql := `SELECT active, max_uses, max_uses_per_customer, used_count FROM promo_campaigns WHERE id = $1 FOR UPDATE`
+	var active bool
+	var maxUses, maxUsesPerCustomer, usedCount int64
+	if err := tx.QueryRow(sql, promo.CampaignID).Scan(&active, &maxUses, &maxUsesPerCustomer, &usedCount); err != nil {
+		return nil, notFound(err, "promo campaign", promo.CampaignID)
+	}
+	if !active {
+		return nil, fmt.Errorf("%w: promo code %s has ended", common.ErrInvalidPromo, promo.Code)
+	}
+	if maxUses > 0 && usedCount >= maxUses {
+		return nil, fmt.Errorf("%w: promo code %s is used up", common.ErrInvalidPromo, promo.Code)
+	}
+	if maxUsesPerCustomer > 0 {
+		sql = `SELECT COUNT(*) FROM promo_redemptions
+		WHERE campaign_id = $1 AND customer_id = $2 AND status <> $3`
+		var used int64
+		if err := tx.QueryRow(sql, promo.CampaignID, t.SenderCustomerId, model.PromoRedemptionStatusCancelled).Scan(&used); err != nil {
+			return nil, err
+		}
+		if used >= maxUsesPerCustomer {
+			return nil, fmt.Errorf("%w: promo code %s was already used the maximum number of times", common.ErrInvalidPromo, promo.Code)
+		}
+	}
+
+	status := model.PromoRedemptionStatusApplied
+	if promo.RewardType == model.PromoRewardCashback {
+		status = model.PromoRedemptionStatusPending
+	}
+	sql = `
+	INSERT INTO promo_redemptions (
+		id, campaign_id, customer_id, transaction_id, reward_type, amount, currency, status
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	  )`
+	if _, err := tx.Exec(sql, promo.ID, promo.CampaignID, t.SenderCustomerId, t.ID, promo.RewardType, promo.Amount, t.Currency, status); err != nil {
+		return nil, err
+	}
+
+	sql = `UPDATE promo_campaigns SET used_count = used_count + 1 WHERE id = $1`
+	if _, err := tx.Exec(sql, promo.CampaignID); err != nil {
+		return nil, err
+	}
+
+	sql = `SELECT ` + promoRedemptionColumns + ` FROM promo_redemptions r
+	JOIN promo_campaigns c ON c.id = r.campaign_id
+	WHERE r.id = $1`
+	i, err := scanPromoRedemption(tx.QueryRow(sql, promo.ID))
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// cancelPromoRedemption cancels the promo code use of a payment that did not
+// go through, if any, and gives the use back to the campaign.
+func cancelPromoRedemption(tx *sql.Tx, transactionId string) error {
+	sql := `UPDATE promo_redemptions
+	SET status = $1
+	WHERE transaction_id = $2 AND status IN ($3, $4)
+	RETURNING campaign_id`
+	var campaignId string
+	err := tx.QueryRow(sql, model.PromoRedemptionStatusCancelled, transactionId,
+		model.PromoRedemptionStatusApplied, model.PromoRedemptionStatusPending).Scan(&campaignId)
+	if isNoRows(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sql = `UPDATE promo_campaigns SET used_count = used_count - 1 WHERE id = $1`
+	_, err = tx.Exec(sql, campaignId)
+	return err
+}
+
+const promoCampaignColumns = `id, code, name, reward_type, currency, percentage_bps, fixed_amount, max_reward, min_spend,
+	max_uses, max_uses_per_customer, used_count, merchant_ids, business_types, starts_at, ends_at, active, created_at, updated_at`
+
+func scanPromoCampaign(row rowScanner) (model.PromoCampaign, error) {
+	var i model.PromoCampaign
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.RewardType,
+		&i.Currency,
+		&i.PercentageBps,
+		&i.FixedAmount,
+		&i.MaxReward,
+		&i.MinSpend,
+		&i.MaxUses,
+		&i.MaxUsesPerCustomer,
+		&i.UsedCount,
+		pq.Array(&i.MerchantIDs),
+		pq.Array(&i.BusinessTypes),
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const promoRedemptionColumns = `r.id, r.campaign_id, c.code, r.customer_id, r.transaction_id, r.reward_type, r.amount, r.currency,
+	r.credited_amount, r.status, r.credited_at, r.created_at`
+
+func scanPromoRedemption(row rowScanner) (model.PromoRedemption, error) {
+	var i model.PromoRedemption
+	err := row.Scan(
+		&i.ID,
+		&i.CampaignID,
+		&i.Code,
+		&i.CustomerID,
+		&i.TransactionID,
+		&i.RewardType,
+		&i.Amount,
+		&i.Currency,
+		&i.CreditedAmount,
+		&i.Status,
+		&i.CreditedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/lib/pq"
+)
+
+type ReconciliationRepository interface {
+	Reconcile() ([]model.WalletMismatch, int, error)
+	Save(arg model.ReconciliationReport) (model.ReconciliationReport, error)
+	GetLatest() (model.ReconciliationReport, error)
+}
+
+type reconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+// walletMovements lists what each business record should have done to a
+// wallet balance. Adjustments only exist as journal entries, so their
+// postings are the record. Split payments move money through their legs;
+// the parent is only a total.
+const walletMovements = `
+	SELECT 'customer' AS owner_type, sender_customer_id AS owner_id, 'transaction' AS record_type, id AS record_id,
+		-ROUND(captured_amount::numeric * source_amount / amount)::bigint AS amount
+	FROM transactions WHERE status = 'captured' AND receiver_merchant_id IS NOT NULL
+	UNION ALL
+	SELECT 'merchant', receiver_merchant_id, 'transaction', id, captured_amount - fee
+	FROM transactions WHERE status = 'captured' AND receiver_merchant_id IS NOT NULL
+	UNION ALL
+	SELECT 'customer', t.sender_customer_id, 'refund', r.id, r.source_amount
+	FROM refunds r JOIN transactions t ON t.id = r.transaction_id
+	UNION ALL
+	SELECT 'merchant', t.receiver_merchant_id, 'refund', r.id, -(r.amount - r.fee)
+	FROM refunds r JOIN transactions t ON t.id = r.transaction_id
+	UNION ALL
+	SELECT 'customer', sender_customer_id, 'transfer', id, -amount FROM transfers
+	UNION ALL
+	SELECT 'customer', receiver_customer_id, 'transfer', id, amount FROM transfers
+	UNION ALL
+	SELECT 'merchant', merchant_id, 'payout', id, CASE WHEN status = 'failed' THEN 0 ELSE -amount END FROM payouts
+	UNION ALL
+	SELECT 'customer', customer_id, 'top_up', id, amount FROM top_up_orders WHERE status = 'paid'
+	UNION ALL
+	SELECT 'customer', customer_id, 'cashback', id, credited_amount FROM promo_redemptions WHERE status = 'credited'
+	UNION ALL
+	SELECT a.owner_type, a.owner_id, j.kind, j.id, SUM(p.amount)
+	FROM ledger_postings p
+	JOIN ledger_accounts a ON a.id = p.account_id
+	JOIN journal_entries j ON j.id = p.journal_entry_id
+	WHERE j.kind = 'adjustment' AND a.owner_type IN ('customer', 'merchant')
+	GROUP BY a.owner_type, a.owner_id, j.kind, j.id`
+
+// walletPostings sums the wallet postings of every journal entry that
+// belongs to a business record, keyed by the record it references.
+const walletPostings = `
+	SELECT a.owner_type, a.owner_id, j.reference_id AS record_id, SUM(p.amount) AS amount
+	FROM ledger_postings p
+	JOIN ledger_accounts a ON a.id = p.account_id
+	JOIN journal_entries j ON j.id = p.journal_entry_id
+	WHERE j.kind <> 'adjustment' AND a.owner_type IN ('customer', 'merchant')
+	GROUP BY a.owner_type, a.owner_id, j.reference_id`
+
+// Reconcile implements ReconciliationRepository. It reads one consistent
+// snapshot and returns the wallets whose stored, expected and ledger
+// balances disagree or that have mismatching records, together with the
+// number of wallets checked.
+func (repo *reconciliationRepository) Reconcile() ([]model.WalletMismatch, int, error) {
+	tx, err := repo.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT (SELECT COUNT(*) FROM customers) + (SELECT COUNT(*) FROM merchants)`
+	var checked int
+	if err := tx.QueryRow(sql).Scan(&checked); err != nil {
+		return nil, 0, err
+	}
+
+	sql = `WITH movements AS (` + walletMovements + `),
+	posted AS (` + walletPostings + `)
+	SELECT COALESCE(m.owner_type, p.owner_type), COALESCE(m.owner_id, p.owner_id),
+		COALESCE(m.record_type, 'unknown'), COALESCE(m.record_id, p.record_id),
+		COALESCE(m.amount, 0), COALESCE(p.amount, 0)
+	FROM (SELECT * FROM movements WHERE record_type <> 'adjustment') m
+	FULL OUTER JOIN posted p
+		ON p.owner_type = m.owner_type AND p.owner_id = m.owner_id AND p.record_id = m.record_id
+	WHERE COALESCE(m.amount, 0) <> COALESCE(p.amount, 0)
+	ORDER BY 1, 2, 4`
+	rows, err := tx.Query(sql)
+	if err != nil {
+		return nil, 0, err
+	}
+	records := map[string][]model.RecordMismatch{}
+	for rows.Next() {
+		var ownerType, ownerId string
+		var i model.RecordMismatch
+		if err := rows.Scan(
+			&ownerType,
+			&ownerId,
+			&i.RecordType,
+			&i.RecordID,
+			&i.ExpectedAmount,
+			&i.PostedAmount,
+		); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		records[ownerType+"/"+ownerId] = append(records[ownerType+"/"+ownerId], i)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	sql = `WITH movements AS (` + walletMovements + `),
+	expected AS (
+		SELECT owner_type, owner_id, SUM(amount) AS amount FROM movements GROUP BY owner_type, owner_id
+	),
+	ledger AS (
+		SELECT a.owner_type, a.owner_id, a.currency, SUM(p.amount) AS amount
+		FROM ledger_postings p JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE a.owner_type IN ('customer', 'merchant')
+		GROUP BY a.owner_type, a.owner_id, a.currency
+	),
+	wallets AS (
+		SELECT 'customer' AS owner_type, id AS owner_id, currency, balance FROM customers
+		UNION ALL
+		SELECT 'merchant', id, currency, balance FROM merchants
+	)
+	SELECT w.owner_type, w.owner_id, w.currency, w.balance, COALESCE(e.amount, 0), COALESCE(l.amount, 0)
+	FROM wallets w
+	LEFT JOIN expected e ON e.owner_type = w.owner_type AND e.owner_id = w.owner_id
+	LEFT JOIN ledger l ON l.owner_type = w.owner_type AND l.owner_id = w.owner_id AND l.currency = w.currency
+	WHERE w.balance <> COALESCE(e.amount, 0) OR COALESCE(l.amount, 0) <> COALESCE(e.amount, 0)
+		OR (w.owner_type || '/' || w.owner_id) = ANY($1)
+	ORDER BY 1, 2`
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	rows, err = tx.Query(sql, pq.Array(keys))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	items := []model.WalletMismatch{}
+	for rows.Next() {
+		var i model.WalletMismatch
+		if err := rows.Scan(
+			&i.OwnerType,
+			&i.OwnerID,
+			&i.Currency,
+			&i.StoredBalance,
+			&i.ExpectedBalance,
+			&i.LedgerBalance,
+		); err != nil {
+			return nil, 0, err
+		}
+		i.Records = records[i.OwnerType+"/"+i.OwnerID]
+		if i.Records == nil {
+			i.Records = []model.RecordMismatch{}
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, checked, nil
+}
+
+// Save implements ReconciliationRepository.
+func (repo *reconciliationRepository) Save(arg model.ReconciliationReport) (model.ReconciliationReport, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.ReconciliationReport{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `
+	INSERT INTO reconciliation_reports (
+		id, wallets_checked, mismatch_count, started_at, finished_at
+	  ) VALUES (
+		$1, $2, $3, $4, $5
+	  )`
+	if _, err := tx.Exec(sql, arg.ID, arg.WalletsChecked, arg.MismatchCount, arg.StartedAt, arg.FinishedAt); err != nil {
+		return model.ReconciliationReport{}, err
+	}
+
+	for _, m := range arg.Mismatches {
+		records, err := json.Marshal(m.Records)
+		if err != nil {
+			return model.ReconciliationReport{}, err
+		}
+		sql := `
+		INSERT INTO reconciliation_mismatches (
+			id, report_id, owner_type, owner_id, currency, stored_balance, expected_balance, ledger_balance, records
+		  ) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		  )`
+		if _, err := tx.Exec(sql, common.GenerateID(), arg.ID, m.OwnerType, m.OwnerID, m.Currency,
+			m.StoredBalance, m.ExpectedBalance, m.LedgerBalance, records); err != nil {
+			return model.ReconciliationReport{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.ReconciliationReport{}, err
+	}
+	return arg, nil
+}
+
+// GetLatest implements ReconciliationRepository.
+func (repo *reconciliationRepository) GetLatest() (model.ReconciliationReport, error) {
+	sql := `SELECT id, wallets_checked, mismatch_count, started_at, finished_at
+	FROM reconciliation_reports
+	ORDER BY started_at DESC
+	LIMIT 1`
+	var i model.ReconciliationReport
+	err := repo.db.QueryRow(sql).Scan(
+		&i.ID,
+		&i.WalletsChecked,
+		&i.MismatchCount,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	if err != nil {
+		return model.ReconciliationReport{}, notFound(err, "reconciliation report", "latest")
+	}
+
+	sql = `SELECT owner_type, owner_id, currency, stored_balance, expected_balance, ledger_balance, records
+	FROM reconciliation_mismatches
+	WHERE report_id = $1
+	ORDER BY owner_type, owner_id`
+	rows, err := repo.db.Query(sql, i.ID)
+	if err != nil {
+		return model.ReconciliationReport{}, err
+	}
+	defer rows.Close()
+	i.Mismatches = []model.WalletMismatch{}
+	for rows.Next() {
+		var m model.WalletMismatch
+		var records []byte
+		if err := rows.Scan(
+			&m.OwnerType,
+			&m.OwnerID,
+			&m.Currency,
+			&m.StoredBalance,
+			&m.ExpectedBalance,
+			&m.LedgerBalance,
+			&records,
+		); err != nil {
+			return model.ReconciliationReport{}, err
+		}
+		if err := json.Unmarshal(records, &m.Records); err != nil {
+			return model.ReconciliationReport{}, err
+		}
+		i.Mismatches = append(i.Mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return model.ReconciliationReport{}, err
+	}
+	return i, nil
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type RefundRepository interface {
+	Create(arg model.Refund) (model.Refund, error)
+	ListByTransactionId(transactionId string) ([]model.Refund, error)
+}
+
+type refundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+// Create implements RefundRepository. A zero amount refunds whatever is
+// still refundable on the transaction.
+func (repo *refundRepository) Create(arg model.Refund) (model.Refund, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Refund{}, err
+	}
+	defer tx.Rollback()
+
+	// The transaction row lock serialises refunds of the same payment.
+	sql := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	t, err := scanTransaction(tx.QueryRow(sql, arg.TransactionID))
+	if err != nil {
+		return model.Refund{}, notFound(err, "transaction", arg.TransactionID)
+	}
+	if t.Status != model.TransactionStatusCaptured {
+		return model.Refund{}, common.ErrInvalidStatus
+	}
+	if t.IsSplit() {
+		return model.Refund{}, fmt.Errorf("%w: refund the legs of split payment %s", common.ErrInvalidStatus, t.ID)
+	}
+	customerId, merchantId := t.SenderCustomerId, t.ReceiverMerchantId
+
+	remaining := t.CapturedAmount - t.RefundedAmount
+	if arg.Amount == 0 {
+		arg.Amount = remaining
+	}
+	if arg.Amount <= 0 || arg.Amount > remaining {
+		return model.Refund{}, common.ErrRefundExceeded
+	}
+	arg.SourceAmount, arg.Fee = refundShares(t, arg.Amount)
+
+	customer, err := lockWallet(tx, model.AccountOwnerCustomer, customerId)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if customer.frozenIncoming {
+		return model.Refund{}, frozenError(model.AccountOwnerCustomer, customerId)
+	}
+
+	merchant, err := lockWallet(tx, model.AccountOwnerMerchant, merchantId)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if merchant.frozen {
+		return model.Refund{}, frozenError(model.AccountOwnerMerchant, merchantId)
+	}
+	// A dispute on this payment holds what it could still charge back. The
+	// refund gives part of that back already, so it can spend the part of
+	// the hold it releases, but never what other disputes hold.
+	disputed, err := disputedAmount(tx, t.ID)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	held, kept := disputed, disputed
+	if remaining < held {
+		held = remaining
+	}
+	if remaining-arg.Amount < kept {
+		kept = remaining - arg.Amount
+	}
+	release := held - kept
+	if merchant.available+release < arg.Amount-arg.Fee {
+		return model.Refund{}, common.ErrInsufficientFunds
+	}
+
+	i, err := insertRefund(tx, t, arg)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	if release > 0 {
+		sql = `UPDATE ` + merchant.table + `
+		SET held_amount = held_amount - $1
+		WHERE id = $2`
+		if _, err := tx.Exec(sql, release, merchantId); err != nil {
+			return model.Refund{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Refund{}, err
+	}
+	return i, nil
+}
+
+// refundShares converts a refund of amount from payment t to the customer
+// currency and works out the share of the payment fee it gives back.
+func refundShares(t model.Transaction, amount int64) (sourceAmount int64, fee int64) {
+	// Convert at the rate of the original payment, cumulatively so rounding
+	// never returns more than the customer paid.
+	sourceAmount = proportion(t.RefundedAmount+amount, t.Amount, t.SourceAmount) -
+		proportion(t.RefundedAmount, t.Amount, t.SourceAmount)
+	// The fee is given back in the same share as the captured amount.
+	fee = proportion(t.RefundedAmount+amount, t.CapturedAmount, t.Fee) -
+		proportion(t.RefundedAmount, t.CapturedAmount, t.Fee)
+	return sourceAmount, fee
+}
+
+// insertRefund records refund arg of payment t and moves the money back from
+// the merchant to the customer. t and both wallets must already be locked.
+func insertRefund(tx *sql.Tx, t model.Transaction, arg model.Refund) (model.Refund, error) {
+	customerId, merchantId := t.SenderCustomerId, t.ReceiverMerchantId
+
+	sql := `
+	INSERT INTO refunds (
+		id, transaction_id, amount, fee, source_amount, reason, created_by
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, NULLIF($7, '')
+	  ) RETURNING ` + refundColumns
+	i, err := scanRefund(tx.QueryRow(sql, arg.ID, arg.TransactionID, arg.Amount, arg.Fee, arg.SourceAmount, arg.Reason, arg.CreatedBy))
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	// The parent of a split payment tracks the refunds of all its legs.
+	sql = `UPDATE transactions
+	SET refunded_amount = refunded_amount + $1
+	WHERE id = $2 OR id = NULLIF($3, '')`
+	if _, err := tx.Exec(sql, i.Amount, i.TransactionID, t.ParentID); err != nil {
+		return model.Refund{}, err
+	}
+
+	sql = `UPDATE merchants
+	SET balance = balance - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, i.Amount-i.Fee, merchantId); err != nil {
+		return model.Refund{}, err
+	}
+
+	sql = `UPDATE customers
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, i.SourceAmount, customerId); err != nil {
+		return model.Refund{}, err
+	}
+
+	postings := exchangePostings(model.AccountOwnerMerchant, merchantId, t.Currency, i.Amount, model.AccountOwnerCustomer, customerId, t.SourceCurrency, i.SourceAmount)
+	_, err = postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindRefund,
+		ReferenceID: i.ID,
+		Description: "refund of transaction " + i.TransactionID,
+		Postings:    append(postings, feePostings(merchantId, t.Currency, -i.Fee)...),
+	})
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	return i, nil
+}
+
+// ListByTransactionId implements RefundRepository.
+func (repo *refundRepository) ListByTransactionId(transactionId string) ([]model.Refund, error) {
+	sql := `SELECT ` + refundColumns + ` FROM refunds
+	WHERE transaction_id = $1
+	ORDER BY created_at`
+	rows, err := repo.db.Query(sql, transactionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.Refund{}
+	for rows.Next() {
+		i, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refundColumns = `id, transaction_id, amount, fee, source_amount, COALESCE(reason, ''), COALESCE(created_by, ''), created_at`
+
+func scanRefund(row rowScanner) (model.Refund, error) {
+	var i model.Refund
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.Amount,
+		&i.Fee,
+		&i.SourceAmount,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
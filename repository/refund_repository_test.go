@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// fakeWallet is the wallet of a customer or merchant in a refundDB.
+type fakeWallet struct {
+	balance  int64
+	held     int64
+	currency string
+}
+
+// refundDB answers the statements of refunds from captured payments,
+// wallets and disputes kept in memory.
+type refundDB struct {
+	*fakeDB
+	transactions map[string]fakeRecord
+	wallets      map[string]*fakeWallet
+	disputes     []fakeRecord
+	refunds      []fakeRecord
+}
+
+func newRefundDB(t *testing.T) *refundDB {
+	db := &refundDB{
+		fakeDB:       newFakeDB(t),
+		transactions: map[string]fakeRecord{},
+		wallets:      map[string]*fakeWallet{},
+	}
+	now := time.Now()
+
+	db.on(`^SELECT .* FROM transactions WHERE id = \$1 FOR UPDATE$`, func(args []driver.Value) []fakeRecord {
+		if t, ok := db.transactions[args[0].(string)]; ok {
+			return []fakeRecord{t}
+		}
+		return nil
+	})
+	db.on(`^SELECT .* FROM (customers|merchants) WHERE id = \$1 FOR UPDATE$`, func(args []driver.Value) []fakeRecord {
+		w, ok := db.wallets[args[0].(string)]
+		if !ok {
+			return nil
+		}
+		return []fakeRecord{{"balance - held_amount": w.balance - w.held, "currency": w.currency, "frozen": false, "frozen_incoming": false}}
+	})
+	db.on(`^SELECT amount FROM disputes WHERE transaction_id = \$1 AND status IN`, func(args []driver.Value) []fakeRecord {
+		var rows []fakeRecord
+		for _, d := range db.disputes {
+			if d["transaction_id"] == args[0] {
+				rows = append(rows, d)
+			}
+		}
+		return rows
+	})
+	db.on(`^INSERT INTO refunds`, func(args []driver.Value) []fakeRecord {
+		createdBy := args[6]
+		if createdBy == nil {
+			createdBy = ""
+		}
+		r := fakeRecord{
+			"id": args[0], "transaction_id": args[1], "amount": args[2], "fee": args[3], "source_amount": args[4],
+			"reason": args[5], "created_by": createdBy, "created_at": now,
+		}
+		db.refunds = append(db.refunds, r)
+		return []fakeRecord{r}
+	})
+	db.on(`^UPDATE transactions SET refunded_amount = refunded_amount \+ \$1 WHERE id = \$2`, func(args []driver.Value) []fakeRecord {
+		t := db.transactions[args[1].(string)]
+		t["refunded_amount"] = t["refunded_amount"].(int64) + args[0].(int64)
+		return []fakeRecord{t}
+	})
+	db.on(`^UPDATE merchants SET balance = balance - \$1 WHERE id = \$2$`, func(args []driver.Value) []fakeRecord {
+		db.wallets[args[1].(string)].balance -= args[0].(int64)
+		return []fakeRecord{{}}
+	})
+	db.on(`^UPDATE merchants SET held_amount = held_amount - \$1 WHERE id = \$2$`, func(args []driver.Value) []fakeRecord {
+		db.wallets[args[1].(string)].held -= args[0].(int64)
+		return []fakeRecord{{}}
+	})
+	db.on(`^UPDATE customers SET balance = balance \+ \$1 WHERE id = \$2$`, func(args []driver.Value) []fakeRecord {
+		db.wallets[args[1].(string)].balance += args[0].(int64)
+		return []fakeRecord{{}}
+	})
+	db.on(`^INSERT INTO (journal_entries|ledger_postings)`, func(args []driver.Value) []fakeRecord {
+		return []fakeRecord{{"created_at": now}}
+	})
+	db.on(`^INSERT INTO ledger_accounts`, func(args []driver.Value) []fakeRecord {
+		return []fakeRecord{{"id": args[1].(string) + ":" + args[2].(string) + ":" + args[3].(string)}}
+	})
+	db.on(`^SELECT .* FROM refunds WHERE transaction_id = \$1`, func(args []driver.Value) []fakeRecord {
+		var rows []fakeRecord
+		for _, r := range db.refunds {
+			if r["transaction_id"] == args[0] {
+				rows = append(rows, r)
+			}
+		}
+		return rows
+	})
+	return db
+}
+
+// payment adds a captured payment of amount in currency from customer to
+// merchant, paid with sourceAmount in sourceCurrency.
+func (db *refundDB) payment(id string, customerId string, merchantId string, amount int64, currency string, sourceAmount int64, sourceCurrency string, fee int64) {
+	db.transactions[id] = fakeRecord{
+		"id": id, "sender_customer_id": customerId, "receiver_merchant_id": merchantId,
+		"amount": amount, "currency": currency, "source_amount": sourceAmount, "source_currency": sourceCurrency, "fx_rate": "",
+		"captured_amount": amount, "fee": fee, "discount": int64(0), "refunded_amount": int64(0),
+		"status": model.TransactionStatusCaptured, "invoice_id": "", "parent_id": "", "expires_at": nil, "created_at": time.Now(),
+	}
+}
+
+// dispute adds an undecided dispute of amount on payment transactionId and
+// holds it on the merchant wallet.
+func (db *refundDB) dispute(transactionId string, amount int64) {
+	db.disputes = append(db.disputes, fakeRecord{"transaction_id": transactionId, "amount": amount})
+	db.wallets[db.transactions[transactionId]["receiver_merchant_id"].(string)].held += amount
+}
+
+func TestRefundListByTransactionId(t *testing.T) {
+	tests := []struct {
+		name     string
+		pay      func(db *refundDB)
+		amount   int64
+		want     model.Refund
+		merchant int64
+		customer int64
+	}{
+		{
+			name: "same currency",
+			pay: func(db *refundDB) {
+				db.payment("t1", "c1", "m1", 10000, "IDR", 10000, "IDR", 200)
+			},
+			amount:   4000,
+			want:     model.Refund{ID: "r1", TransactionID: "t1", Amount: 4000, Fee: 80, SourceAmount: 4000, Reason: "damaged", CreatedBy: "u1"},
+			merchant: 50000 - 3920,
+			customer: 4000,
+		},
+		{
+			// USD 10.00 paid from an IDR wallet gives back its share of the
+			// rupiah the customer paid
+			name: "other currency",
+			pay: func(db *refundDB) {
+				db.wallets["m1"].currency = "USD"
+				db.payment("t1", "c1", "m1", 1000, "USD", 150000, "IDR", 30)
+			},
+			amount:   400,
+			want:     model.Refund{ID: "r1", TransactionID: "t1", Amount: 400, Fee: 12, SourceAmount: 60000, Reason: "damaged", CreatedBy: "u1"},
+			merchant: 50000 - 388,
+			customer: 60000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newRefundDB(t)
+			db.wallets["c1"] = &fakeWallet{currency: "IDR"}
+			db.wallets["m1"] = &fakeWallet{balance: 50000, currency: "IDR"}
+			tt.pay(db)
+			repo := NewRefundRepository(db.open())
+
+			created, err := repo.Create(model.Refund{ID: "r1", TransactionID: "t1", Amount: tt.amount, Reason: "damaged", CreatedBy: "u1"})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if m, c := db.wallets["m1"].balance, db.wallets["c1"].balance; m != tt.merchant || c != tt.customer {
+				t.Errorf("balances merchant %d customer %d, want %d and %d", m, c, tt.merchant, tt.customer)
+			}
+			refunds, err := repo.ListByTransactionId("t1")
+			if err != nil {
+				t.Fatalf("ListByTransactionId: %v", err)
+			}
+			if len(refunds) != 1 {
+				t.Fatalf("ListByTransactionId = %+v, want the refund", refunds)
+			}
+			got := refunds[0]
+			if got.CreatedAt.IsZero() || !got.CreatedAt.Equal(created.CreatedAt) {
+				t.Errorf("created_at = %v, want %v", got.CreatedAt, created.CreatedAt)
+			}
+			got.CreatedAt = time.Time{}
+			if got != tt.want {
+				t.Errorf("ListByTransactionId = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefundDisputeHold(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int64
+		transaction string
+		amount      int64
+		wantErr     error
+		// wallet of the merchant afterwards
+		balanceAfter int64
+		heldAfter    int64
+	}{
+		{
+			// the hold of the dispute on t1 is not money for refunding t2
+			name:         "other payment",
+			balance:      10000,
+			transaction:  "t2",
+			amount:       5000,
+			wantErr:      common.ErrInsufficientFunds,
+			balanceAfter: 10000,
+			heldAfter:    8000,
+		},
+		{
+			// 5000 left to refund on t1 only needs 5000 of the 8000 held, so
+			// the refund can spend the 3000 it releases
+			name:         "disputed payment",
+			balance:      10000,
+			transaction:  "t1",
+			amount:       5000,
+			balanceAfter: 5000,
+			heldAfter:    5000,
+		},
+		{
+			name:         "disputed payment short of funds",
+			balance:      9000,
+			transaction:  "t1",
+			amount:       5000,
+			wantErr:      common.ErrInsufficientFunds,
+			balanceAfter: 9000,
+			heldAfter:    8000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newRefundDB(t)
+			db.wallets["c1"] = &fakeWallet{currency: "IDR"}
+			db.wallets["m1"] = &fakeWallet{balance: tt.balance, currency: "IDR"}
+			db.payment("t1", "c1", "m1", 10000, "IDR", 10000, "IDR", 0)
+			db.payment("t2", "c1", "m1", 10000, "IDR", 10000, "IDR", 0)
+			db.dispute("t1", 8000)
+			repo := NewRefundRepository(db.open())
+
+			_, err := repo.Create(model.Refund{ID: "r1", TransactionID: tt.transaction, Amount: tt.amount})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create error = %v, want %v", err, tt.wantErr)
+			}
+			if w := db.wallets["m1"]; w.balance != tt.balanceAfter || w.held != tt.heldAfter {
+				t.Errorf("merchant balance %d held %d, want %d and %d", w.balance, w.held, tt.balanceAfter, tt.heldAfter)
+			}
+		})
+	}
+}
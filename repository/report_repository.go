@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+)
+
+type ReportRepository interface {
+	// SalesReport fills the rows of a sales report for its merchant, or all
+	// merchants, period, interval and time zone. Average tickets, net amounts
+	// and totals are left to the caller.
+	SalesReport(arg model.SalesReport) (model.SalesReport, error)
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// SalesReport implements ReportRepository. Payments are counted once
+// captured, and split payments through their legs. Refunds give back their
+// share of the payment fee, so it is subtracted from the fees.
+func (repo *reportRepository) SalesReport(arg model.SalesReport) (model.SalesReport, error) {
+	sql := `WITH activity AS (
+		SELECT t.created_at, t.receiver_merchant_id AS merchant_id, t.currency,
+			1 AS sale_count, t.captured_amount AS gross, t.fee, 0 AS refund_count, 0::bigint AS refunds
+		FROM transactions t
+		WHERE t.status = $6 AND t.created_at >= $4 AND t.created_at < $5
+			AND ($1 = '' OR t.receiver_merchant_id = $1)
+		UNION ALL
+		SELECT r.created_at, t.receiver_merchant_id, t.currency,
+			0, 0::bigint, -r.fee, 1, r.amount
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.created_at >= $4 AND r.created_at < $5
+			AND ($1 = '' OR t.receiver_merchant_id = $1)
+	)
+	SELECT date_trunc($2::text, a.created_at AT TIME ZONE $3::text) AT TIME ZONE $3::text,
+		COALESCE(m.business_type, ''), a.currency,
+		SUM(a.sale_count)::bigint, SUM(a.gross)::bigint, SUM(a.refund_count)::bigint,
+		SUM(a.refunds)::bigint, SUM(a.fee)::bigint
+	FROM activity a
+	JOIN merchants m ON m.id = a.merchant_id
+	GROUP BY 1, 2, 3
+	ORDER BY 1, 2, 3`
+	rows, err := repo.db.Query(sql, arg.MerchantID, arg.Interval, arg.TimeZone, arg.PeriodStart, arg.PeriodEnd, model.TransactionStatusCaptured)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	defer rows.Close()
+	arg.Rows = []model.SalesReportRow{}
+	for rows.Next() {
+		var i model.SalesReportRow
+		i.PeriodStart = new(time.Time)
+		if err := rows.Scan(
+			i.PeriodStart,
+			&i.BusinesType,
+			&i.Currency,
+			&i.Count,
+			&i.Gross,
+			&i.RefundCount,
+			&i.Refunds,
+			&i.Fees,
+		); err != nil {
+			return model.SalesReport{}, err
+		}
+		arg.Rows = append(arg.Rows, i)
+	}
+	if err := rows.Err(); err != nil {
+		return model.SalesReport{}, err
+	}
+	return arg, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type SpendingLimitRepository interface {
+	SaveTierLimit(arg model.SpendingLimit) (model.SpendingLimit, error)
+	ListTierLimits() ([]model.SpendingLimit, error)
+	SaveCustomerLimit(arg model.SpendingLimit) (model.SpendingLimit, error)
+	DeleteCustomerLimit(customerId string) error
+	GetCustomerLimits(customerId string) (model.CustomerLimits, error)
+	SetCustomerTier(customerId string, tier string) error
+}
+
+type spendingLimitRepository struct {
+	db            *sql.DB
+	limitLocation *time.Location
+}
+
+func NewSpendingLimitRepository(db *sql.DB, limitLocation *time.Location) SpendingLimitRepository {
+	return &spendingLimitRepository{db: db, limitLocation: limitLocation}
+}
+
+// SaveTierLimit implements SpendingLimitRepository. It replaces the limits of
+// the tier in the currency.
+func (repo *spendingLimitRepository) SaveTierLimit(arg model.SpendingLimit) (model.SpendingLimit, error) {
+	sql := `
+	INSERT INTO spending_limits (
+		id, tier, currency, max_single_amount, daily_amount, monthly_amount, hourly_count
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	  )
+	  ON CONFLICT (tier, currency) WHERE tier IS NOT NULL DO UPDATE
+	  SET max_single_amount = EXCLUDED.max_single_amount,
+		daily_amount = EXCLUDED.daily_amount,
+		monthly_amount = EXCLUDED.monthly_amount,
+		hourly_count = EXCLUDED.hourly_count,
+		updated_at = now()
+	  RETURNING ` + spendingLimitColumns
+	return scanSpendingLimit(repo.db.QueryRow(sql, arg.ID, arg.Tier, arg.Currency, arg.MaxSingleAmount, arg.DailyAmount,
+		arg.MonthlyAmount, arg.HourlyCount))
+}
+
+// ListTierLimits implements SpendingLimitRepository.
+func (repo *spendingLimitRepository) ListTierLimits() ([]model.SpendingLimit, error) {
+	sql := `SELECT ` + spendingLimitColumns + ` FROM spending_limits
+	WHERE tier IS NOT NULL
+	ORDER BY tier, currency`
+	rows, err := repo.db.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.SpendingLimit{}
+	for rows.Next() {
+		i, err := scanSpendingLimit(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SaveCustomerLimit implements SpendingLimitRepository. The override takes
+// the currency of the customer wallet.
+func (repo *spendingLimitRepository) SaveCustomerLimit(arg model.SpendingLimit) (model.SpendingLimit, error) {
+	sql := `
+	INSERT INTO spending_limits (
+		id, customer_id, currency, max_single_amount, daily_amount, monthly_amount, hourly_count
+	  )
+	  SELECT $1, id, currency, $3, $4, $5, $6 FROM customers WHERE id = $2
+	  ON CONFLICT (customer_id) WHERE customer_id IS NOT NULL DO UPDATE
+	  SET max_single_amount = EXCLUDED.max_single_amount,
+		daily_amount = EXCLUDED.daily_amount,
+		monthly_amount = EXCLUDED.monthly_amount,
+		hourly_count = EXCLUDED.hourly_count,
+		updated_at = now()
+	  RETURNING ` + spendingLimitColumns
+	i, err := scanSpendingLimit(repo.db.QueryRow(sql, arg.ID, arg.CustomerID, arg.MaxSingleAmount, arg.DailyAmount,
+		arg.MonthlyAmount, arg.HourlyCount))
+	if err != nil {
+		return model.SpendingLimit{}, notFound(err, "customer", arg.CustomerID)
+	}
+	return i, nil
+}
+
+// DeleteCustomerLimit implements SpendingLimitRepository.
+func (repo *spendingLimitRepository) DeleteCustomerLimit(customerId string) error {
+	sql := `DELETE FROM spending_limits WHERE customer_id = $1`
+	result, err := repo.db.Exec(sql, customerId)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("spending limit of customer %s: %w", customerId, common.ErrRecordNotFound)
+	}
+	return err
+}
+
+// GetCustomerLimits implements SpendingLimitRepository.
+func (repo *spendingLimitRepository) GetCustomerLimits(customerId string) (model.CustomerLimits, error) {
+	i, err := effectiveLimits(repo.db, customerId)
+	if err != nil {
+		return model.CustomerLimits{}, err
+	}
+	if i.Payments, err = limitUsage(repo.db, repo.limitLocation, customerId, model.LimitKindPayment); err != nil {
+		return model.CustomerLimits{}, err
+	}
+	if i.TopUps, err = limitUsage(repo.db, repo.limitLocation, customerId, model.LimitKindTopUp); err != nil {
+		return model.CustomerLimits{}, err
+	}
+	return i, nil
+}
+
+// SetCustomerTier implements SpendingLimitRepository.
+func (repo *spendingLimitRepository) SetCustomerTier(customerId string, tier string) error {
+	sql := `UPDATE customers SET tier = $1 WHERE id = $2`
+	result, err := repo.db.Exec(sql, tier, customerId)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("customer %s: %w", customerId, common.ErrRecordNotFound)
+	}
+	return err
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// effectiveLimits merges the override of a customer over the defaults of its
+// tier.
+func effectiveLimits(q queryRower, customerId string) (model.CustomerLimits, error) {
+	sql := `SELECT c.id, c.tier, c.currency, o.id IS NOT NULL,
+		COALESCE(o.max_single_amount, t.max_single_amount),
+		COALESCE(o.daily_amount, t.daily_amount),
+		COALESCE(o.monthly_amount, t.monthly_amount),
+		COALESCE(o.hourly_count, t.hourly_count)
+	FROM customers c
+	LEFT JOIN spending_limits t ON t.tier = c.tier AND t.currency = c.currency
+	LEFT JOIN spending_limits o ON o.customer_id = c.id
+	WHERE c.id = $1`
+	var i model.CustomerLimits
+	err := q.QueryRow(sql, customerId).Scan(
+		&i.CustomerID,
+		&i.Tier,
+		&i.Limits.Currency,
+		&i.Overridden,
+		&i.Limits.MaxSingleAmount,
+		&i.Limits.DailyAmount,
+		&i.Limits.MonthlyAmount,
+		&i.Limits.HourlyCount,
+	)
+	if err != nil {
+		return model.CustomerLimits{}, notFound(err, "customer", customerId)
+	}
+	return i, nil
+}
+
+// limitUsage sums what a customer paid or topped up today and this month,
+// in the wallet currency, and counts the operations of the last hour. Days
+// and months start at midnight in loc.
+// Pending authorizations, payments in fraud review and top-up orders count;
+// voided, expired, rejected and failed ones do not. Split payments count
+// once, through their parent.
+func limitUsage(q queryRower, loc *time.Location, customerId string, kind string) (model.LimitUsage, error) {
+	// The rows go back to the start of the month, or an hour when that is
+	// earlier, so the hourly count still sees the end of last month.
+	sql := `SELECT
+		COALESCE(SUM(amount) FILTER (WHERE created_at >= date_trunc('day', now() AT TIME ZONE $3::text) AT TIME ZONE $3::text), 0),
+		COALESCE(SUM(amount) FILTER (WHERE created_at >= date_trunc('month', now() AT TIME ZONE $3::text) AT TIME ZONE $3::text), 0),
+		COUNT(*) FILTER (WHERE created_at >= now() - interval '1 hour')
+	FROM (
+		SELECT source_amount AS amount, created_at FROM transactions
+		WHERE $2 = 'payment' AND sender_customer_id = $1 AND parent_id IS NULL
+			AND status IN ('authorized', 'captured', 'pending_review')
+			AND created_at >= LEAST(date_trunc('month', now() AT TIME ZONE $3::text) AT TIME ZONE $3::text, now() - interval '1 hour')
+		UNION ALL
+		SELECT amount, created_at FROM top_up_orders
+		WHERE $2 = 'top_up' AND customer_id = $1
+			AND status IN ('pending', 'paid')
+			AND created_at >= LEAST(date_trunc('month', now() AT TIME ZONE $3::text) AT TIME ZONE $3::text, now() - interval '1 hour')
+	) usage`
+	var i model.LimitUsage
+	err := q.QueryRow(sql, customerId, kind, loc.String()).Scan(&i.DailyAmount, &i.MonthlyAmount, &i.HourlyCount)
+	return i, err
+}
+
+// checkSpendingLimits rejects an operation of kind and amount, in the wallet
+// currency, that would break a limit of the customer, counting days and
+// months in loc. The customer row must
+// already be locked so concurrent operations are counted one after another.
+func checkSpendingLimits(tx *sql.Tx, loc *time.Location, customerId string, kind string, amount int64) error {
+	limits, err := effectiveLimits(tx, customerId)
+	if err != nil {
+		return err
+	}
+	l := limits.Limits
+	if l.MaxSingleAmount != nil && amount > *l.MaxSingleAmount {
+		return &common.LimitError{Code: common.LimitCodeSingleAmount, Kind: kind, Limit: *l.MaxSingleAmount, Amount: amount}
+	}
+	if l.DailyAmount == nil && l.MonthlyAmount == nil && l.HourlyCount == nil {
+		return nil
+	}
+
+	usage, err := limitUsage(tx, loc, customerId, kind)
+	if err != nil {
+		return err
+	}
+	if l.HourlyCount != nil && usage.HourlyCount >= *l.HourlyCount {
+		return &common.LimitError{Code: common.LimitCodeHourlyCount, Kind: kind, Limit: *l.HourlyCount, Used: usage.HourlyCount, Amount: amount}
+	}
+	if l.DailyAmount != nil && usage.DailyAmount+amount > *l.DailyAmount {
+		return &common.LimitError{Code: common.LimitCodeDailyAmount, Kind: kind, Limit: *l.DailyAmount, Used: usage.DailyAmount, Amount: amount}
+	}
+	if l.MonthlyAmount != nil && usage.MonthlyAmount+amount > *l.MonthlyAmount {
+		return &common.LimitError{Code: common.LimitCodeMonthlyAmount, Kind: kind, Limit: *l.MonthlyAmount, Used: usage.MonthlyAmount, Amount: amount}
+	}
+	return nil
+}
+
+const spendingLimitColumns = `id, COALESCE(customer_id, ''), COALESCE(tier, ''), currency, max_single_amount, daily_amount, monthly_amount, hourly_count, updated_at`
+
+func scanSpendingLimit(row rowScanner) (model.SpendingLimit, error) {
+	var i model.SpendingLimit
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.Tier,
+		&i.Currency,
+		&i.MaxSingleAmount,
+		&i.DailyAmount,
+		&i.MonthlyAmount,
+		&i.HourlyCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+)
+
+type StatementRepository interface {
+	// Activity fills the opening balance and lines of a statement for its
+	// customer, currency and period. Running balances are left to the caller.
+	Activity(arg model.Statement) (model.Statement, error)
+	Create(arg model.Statement) (model.Statement, error)
+	Get(id string) (model.Statement, error)
+	ListByCustomer(customerId string, params model.PaginationParams) ([]model.Statement, error)
+	ListCustomersWithoutStatement(periodStart time.Time, periodEnd time.Time, afterId string, limit int) ([]string, error)
+}
+
+type statementRepository struct {
+	db *sql.DB
+}
+
+func NewStatementRepository(db *sql.DB) StatementRepository {
+	return &statementRepository{db: db}
+}
+
+// Activity implements StatementRepository. The opening balance and the lines
+// are read from one snapshot so they always add up.
+func (repo *statementRepository) Activity(arg model.Statement) (model.Statement, error) {
+	tx, err := repo.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return model.Statement{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT COALESCE(SUM(p.amount), 0)
+	FROM ledger_postings p
+	JOIN ledger_accounts a ON a.id = p.account_id
+	WHERE a.owner_type = $1 AND a.owner_id = $2 AND a.currency = $3 AND p.created_at < $4`
+	if err := tx.QueryRow(sql, model.AccountOwnerCustomer, arg.CustomerID, arg.Currency, arg.PeriodStart).Scan(&arg.OpeningBalance); err != nil {
+		return model.Statement{}, err
+	}
+
+	// The counterparty of a payment or refund is the merchant, and of a
+	// transfer the other customer.
+	sql = `SELECT p.created_at, j.kind, COALESCE(j.reference_id, ''),
+		COALESCE(pm.name, rm.name, tc.name, ''), COALESCE(j.description, ''), p.amount
+	FROM ledger_postings p
+	JOIN ledger_accounts a ON a.id = p.account_id
+	JOIN journal_entries j ON j.id = p.journal_entry_id
+	LEFT JOIN transactions pt ON j.kind = 'payment' AND pt.id = j.reference_id
+	LEFT JOIN merchants pm ON pm.id = pt.receiver_merchant_id
+	LEFT JOIN refunds r ON j.kind = 'refund' AND r.id = j.reference_id
+	LEFT JOIN transactions rt ON rt.id = r.transaction_id
+	LEFT JOIN merchants rm ON rm.id = rt.receiver_merchant_id
+	LEFT JOIN transfers tr ON j.kind = 'transfer' AND tr.id = j.reference_id
+	LEFT JOIN customers tc ON tc.id = CASE WHEN tr.sender_customer_id = a.owner_id THEN tr.receiver_customer_id ELSE tr.sender_customer_id END
+	WHERE a.owner_type = $1 AND a.owner_id = $2 AND a.currency = $3 AND p.created_at >= $4 AND p.created_at < $5
+	ORDER BY p.created_at, p.id`
+	rows, err := tx.Query(sql, model.AccountOwnerCustomer, arg.CustomerID, arg.Currency, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return model.Statement{}, err
+	}
+	defer rows.Close()
+	arg.Lines = []model.StatementLine{}
+	for rows.Next() {
+		var i model.StatementLine
+		if err := rows.Scan(
+			&i.Date,
+			&i.Kind,
+			&i.ReferenceID,
+			&i.Counterparty,
+			&i.Description,
+			&i.Amount,
+		); err != nil {
+			return model.Statement{}, err
+		}
+		arg.Lines = append(arg.Lines, i)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Statement{}, err
+	}
+	return arg, nil
+}
+
+// Create implements StatementRepository.
+func (repo *statementRepository) Create(arg model.Statement) (model.Statement, error) {
+	sql := `WITH s AS (
+	INSERT INTO statements (
+		id, customer_id, currency, period_start, period_end, opening_balance, total_in, total_out, closing_balance, csv_path, pdf_path
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	  ) RETURNING *
+	)
+	SELECT ` + statementColumns + ` FROM s
+	JOIN customers c ON c.id = s.customer_id`
+	return scanStatement(repo.db.QueryRow(sql, arg.ID, arg.CustomerID, arg.Currency, arg.PeriodStart, arg.PeriodEnd,
+		arg.OpeningBalance, arg.TotalIn, arg.TotalOut, arg.ClosingBalance, arg.CSVPath, arg.PDFPath))
+}
+
+// Get implements StatementRepository.
+func (repo *statementRepository) Get(id string) (model.Statement, error) {
+	sql := `SELECT ` + statementColumns + ` FROM statements s
+	JOIN customers c ON c.id = s.customer_id
+	WHERE s.id = $1`
+	i, err := scanStatement(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.Statement{}, notFound(err, "statement", id)
+	}
+	return i, nil
+}
+
+// ListByCustomer implements StatementRepository. The latest period comes
+// first.
+func (repo *statementRepository) ListByCustomer(customerId string, params model.PaginationParams) ([]model.Statement, error) {
+	cond, page, args, err := pageClauses(params, "s.period_start", "timestamptz", true, []any{customerId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + statementColumns + ` FROM statements s
+	JOIN customers c ON c.id = s.customer_id
+	WHERE s.customer_id = $1 AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.Statement{}
+	for rows.Next() {
+		i, err := scanStatement(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListCustomersWithoutStatement implements StatementRepository. It returns
+// customers that existed during the period and have no statement for it yet,
+// by id from after afterId, so customers whose statement failed are not
+// listed again.
+func (repo *statementRepository) ListCustomersWithoutStatement(periodStart time.Time, periodEnd time.Time, afterId string, limit int) ([]string, error) {
+	sql := `SELECT c.id FROM customers c
+	WHERE c.created_at < $2 AND c.id > $3
+	AND NOT EXISTS (
+		SELECT 1 FROM statements s
+		WHERE s.customer_id = c.id AND s.period_start = $1 AND s.period_end = $2
+	)
+	ORDER BY c.id
+	LIMIT $4`
+	rows, err := repo.db.Query(sql, periodStart, periodEnd, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+const statementColumns = `s.id, s.customer_id, c.name, s.currency, s.period_start, s.period_end, s.opening_balance, s.total_in,
+	s.total_out, s.closing_balance, s.csv_path, s.pdf_path, s.created_at`
+
+func scanStatement(row rowScanner) (model.Statement, error) {
+	var i model.Statement
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.CustomerName,
+		&i.Currency,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.OpeningBalance,
+		&i.TotalIn,
+		&i.TotalOut,
+		&i.ClosingBalance,
+		&i.CSVPath,
+		&i.PDFPath,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/lib/pq"
+)
+
+type SubscriptionRepository interface {
+	Create(arg model.Subscription) (model.Subscription, error)
+	Get(id string) (model.Subscription, error)
+	ListByCustomerId(customerId string, params model.PaginationParams) ([]model.Subscription, error)
+	SetStatus(id string, status string, from []string) (model.Subscription, error)
+	Resume(id string, nextChargeAt time.Time, retryAt *time.Time) (model.Subscription, error)
+	SetNextAmount(id string, amount int64) (model.Subscription, error)
+	ClaimDue(lease time.Duration, limit int) ([]model.Subscription, error)
+	RecordCharge(charge model.SubscriptionCharge, next model.Subscription) (model.SubscriptionCharge, error)
+	ListCharges(subscriptionId string) ([]model.SubscriptionCharge, error)
+}
+
+type subscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+// Create implements SubscriptionRepository.
+func (repo *subscriptionRepository) Create(arg model.Subscription) (model.Subscription, error) {
+	sql := `
+	INSERT INTO subscriptions (
+		id, customer_id, merchant_id, interval, amount_type, amount, max_amount, status, started_at, next_charge_at
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $9
+	  ) RETURNING ` + subscriptionColumns
+	return scanSubscription(repo.db.QueryRow(sql, arg.ID, arg.CustomerID, arg.MerchantID, arg.Interval, arg.AmountType,
+		arg.Amount, arg.MaxAmount, model.SubscriptionStatusActive, arg.StartedAt))
+}
+
+// Get implements SubscriptionRepository.
+func (repo *subscriptionRepository) Get(id string) (model.Subscription, error) {
+	sql := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
+	i, err := scanSubscription(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.Subscription{}, notFound(err, "subscription", id)
+	}
+	return i, nil
+}
+
+// ListByCustomerId implements SubscriptionRepository.
+func (repo *subscriptionRepository) ListByCustomerId(customerId string, params model.PaginationParams) ([]model.Subscription, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", true, []any{customerId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + subscriptionColumns + ` FROM subscriptions
+	WHERE customer_id = $1 AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanSubscriptions(rows)
+}
+
+// SetStatus implements SubscriptionRepository. The subscription must be in
+// one of the from statuses.
+func (repo *subscriptionRepository) SetStatus(id string, status string, from []string) (model.Subscription, error) {
+	sql := `UPDATE subscriptions
+	SET status = $1, updated_at = now()
+	WHERE id = $2 AND status = ANY($3)
+	RETURNING ` + subscriptionColumns
+	i, err := scanSubscription(repo.db.QueryRow(sql, status, id, pq.Array(from)))
+	return i, repo.statusError(err, id)
+}
+
+// Resume implements SubscriptionRepository.
+func (repo *subscriptionRepository) Resume(id string, nextChargeAt time.Time, retryAt *time.Time) (model.Subscription, error) {
+	sql := `UPDATE subscriptions
+	SET status = $1, next_charge_at = $2, retry_at = $3, retry_count = 0, updated_at = now()
+	WHERE id = $4 AND status IN ($5, $6)
+	RETURNING ` + subscriptionColumns
+	i, err := scanSubscription(repo.db.QueryRow(sql, model.SubscriptionStatusActive, nextChargeAt, retryAt, id,
+		model.SubscriptionStatusPaused, model.SubscriptionStatusPastDue))
+	return i, repo.statusError(err, id)
+}
+
+// SetNextAmount implements SubscriptionRepository.
+func (repo *subscriptionRepository) SetNextAmount(id string, amount int64) (model.Subscription, error) {
+	sql := `UPDATE subscriptions
+	SET next_amount = $1, updated_at = now()
+	WHERE id = $2 AND amount_type = $3 AND status <> $4
+	RETURNING ` + subscriptionColumns
+	i, err := scanSubscription(repo.db.QueryRow(sql, amount, id, model.SubscriptionAmountVariable, model.SubscriptionStatusCancelled))
+	return i, repo.statusError(err, id)
+}
+
+// statusError tells a missing subscription apart from one whose state does
+// not allow the update.
+func (repo *subscriptionRepository) statusError(err error, id string) error {
+	if !isNoRows(err) {
+		return err
+	}
+	if _, err := repo.Get(id); err != nil {
+		return err
+	}
+	return common.ErrInvalidStatus
+}
+
+// ClaimDue implements SubscriptionRepository. Claimed subscriptions get a
+// retry_at lease so another scheduler does not charge them at the same time;
+// recording the charge replaces the lease. Subscriptions with a charge in
+// fraud review are left alone until it is decided.
+func (repo *subscriptionRepository) ClaimDue(lease time.Duration, limit int) ([]model.Subscription, error) {
+	sql := `UPDATE subscriptions
+	SET retry_at = now() + $1 * interval '1 second'
+	WHERE id IN (
+		SELECT id FROM subscriptions
+		WHERE status = $2 AND COALESCE(retry_at, next_charge_at) <= now()
+			AND NOT EXISTS (
+				SELECT 1 FROM subscription_charges c
+				WHERE c.subscription_id = subscriptions.id AND c.status = $4
+			)
+		ORDER BY COALESCE(retry_at, next_charge_at)
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + subscriptionColumns
+	rows, err := repo.db.Query(sql, int64(lease/time.Second), model.SubscriptionStatusActive, limit, model.SubscriptionChargePending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanSubscriptions(rows)
+}
+
+// RecordCharge implements SubscriptionRepository. It stores the attempt and
+// moves the subscription to its next state, unless it was paused or
+// cancelled while the charge was running.
+func (repo *subscriptionRepository) RecordCharge(charge model.SubscriptionCharge, next model.Subscription) (model.SubscriptionCharge, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.SubscriptionCharge{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `
+	INSERT INTO subscription_charges (
+		id, subscription_id, transaction_id, amount, status, attempt, failure_reason
+	  ) VALUES (
+		$1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, '')
+	  ) RETURNING ` + subscriptionChargeColumns
+	i, err := scanSubscriptionCharge(tx.QueryRow(sql, charge.ID, charge.SubscriptionID, charge.TransactionID,
+		charge.Amount, charge.Status, charge.Attempt, charge.FailureReason))
+	if err != nil {
+		return model.SubscriptionCharge{}, err
+	}
+
+	sql = `UPDATE subscriptions
+	SET status = $1, next_charge_at = $2, retry_at = $3, retry_count = $4, last_error = NULLIF($5, ''), next_amount = $6, updated_at = now()
+	WHERE id = $7 AND status = $8`
+	if _, err := tx.Exec(sql, next.Status, next.NextChargeAt, next.RetryAt, next.RetryCount, next.LastError, next.NextAmount,
+		next.ID, model.SubscriptionStatusActive); err != nil {
+		return model.SubscriptionCharge{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.SubscriptionCharge{}, fmt.Errorf("error recording charge of subscription %v: %v", next.ID, err)
+	}
+	return i, nil
+}
+
+// lockChargeableSubscription locks a subscription a payment is about to
+// charge. It must still be active, so a subscription paused or cancelled while
+// the charge was running is not paid.
+func lockChargeableSubscription(tx *sql.Tx, id string) error {
+	sql := `SELECT status FROM subscriptions WHERE id = $1 FOR UPDATE`
+	var status string
+	if err := tx.QueryRow(sql, id).Scan(&status); err != nil {
+		return notFound(err, "subscription", id)
+	}
+	if status != model.SubscriptionStatusActive {
+		return fmt.Errorf("%w: subscription %s is %s", common.ErrInvalidStatus, id, status)
+	}
+	return nil
+}
+
+// recordSubscriptionPayment stores the charge paid by t and moves its
+// subscription to the next period. A payment held for review leaves a
+// pending charge instead, and the period moves on once it is approved. The
+// subscription must already be locked.
+func recordSubscriptionPayment(tx *sql.Tx, t model.Transaction) (model.SubscriptionCharge, error) {
+	charge := *t.SubscriptionCharge
+	status := model.SubscriptionChargeSucceeded
+	if t.Status == model.TransactionStatusPendingReview {
+		status = model.SubscriptionChargePending
+	}
+	sql := `
+	INSERT INTO subscription_charges (
+		id, subscription_id, transaction_id, amount, status, attempt, next_charge_at
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	  ) RETURNING ` + subscriptionChargeColumns
+	i, err := scanSubscriptionCharge(tx.QueryRow(sql, charge.ID, charge.SubscriptionID, t.ID,
+		charge.Amount, status, charge.Attempt, charge.NextChargeAt))
+	if err != nil {
+		return model.SubscriptionCharge{}, err
+	}
+
+	if status == model.SubscriptionChargePending {
+		// drop the scheduler lease; a pending charge keeps the subscription
+		// from being claimed
+		sql = `UPDATE subscriptions SET retry_at = NULL, updated_at = now() WHERE id = $1`
+		_, err = tx.Exec(sql, charge.SubscriptionID)
+		return i, err
+	}
+	return i, advanceSubscription(tx, charge.SubscriptionID, charge.NextChargeAt)
+}
+
+// decideSubscriptionCharge settles the pending charge of a payment decided in
+// fraud review, if it has one. An approved charge succeeds and moves the
+// subscription to the next period; a rejected one fails and makes the
+// subscription past due until the customer resumes it.
+func decideSubscriptionCharge(tx *sql.Tx, transactionId string, approved bool) error {
+	status, reason := model.SubscriptionChargeSucceeded, ""
+	if !approved {
+		status, reason = model.SubscriptionChargeFailed, "payment was rejected in fraud review"
+	}
+	sql := `UPDATE subscription_charges
+	SET status = $1, failure_reason = NULLIF($2, '')
+	WHERE transaction_id = $3 AND status = $4
+	RETURNING subscription_id, next_charge_at`
+	var subscriptionId string
+	var nextChargeAt time.Time
+	err := tx.QueryRow(sql, status, reason, transactionId, model.SubscriptionChargePending).Scan(&subscriptionId, &nextChargeAt)
+	if isNoRows(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if approved {
+		return advanceSubscription(tx, subscriptionId, nextChargeAt)
+	}
+	sql = `UPDATE subscriptions
+	SET status = $1, retry_at = NULL, last_error = $2, updated_at = now()
+	WHERE id = $3 AND status = $4`
+	_, err = tx.Exec(sql, model.SubscriptionStatusPastDue, reason, subscriptionId, model.SubscriptionStatusActive)
+	return err
+}
+
+// advanceSubscription moves a paid subscription to the billing date of its
+// next period.
+func advanceSubscription(tx *sql.Tx, id string, nextChargeAt time.Time) error {
+	sql := `UPDATE subscriptions
+	SET next_charge_at = $1, retry_at = NULL, retry_count = 0, last_error = NULL, next_amount = NULL, updated_at = now()
+	WHERE id = $2`
+	_, err := tx.Exec(sql, nextChargeAt, id)
+	return err
+}
+
+// ListCharges implements SubscriptionRepository.
+func (repo *subscriptionRepository) ListCharges(subscriptionId string) ([]model.SubscriptionCharge, error) {
+	sql := `SELECT ` + subscriptionChargeColumns + ` FROM subscription_charges
+	WHERE subscription_id = $1
+	ORDER BY created_at DESC`
+	rows, err := repo.db.Query(sql, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.SubscriptionCharge{}
+	for rows.Next() {
+		i, err := scanSubscriptionCharge(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const subscriptionColumns = `id, customer_id, merchant_id, interval, amount_type, amount, max_amount, next_amount, status, started_at, next_charge_at, retry_at, retry_count, COALESCE(last_error, ''), created_at, updated_at`
+
+func scanSubscription(row rowScanner) (model.Subscription, error) {
+	var i model.Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.MerchantID,
+		&i.Interval,
+		&i.AmountType,
+		&i.Amount,
+		&i.MaxAmount,
+		&i.NextAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.NextChargeAt,
+		&i.RetryAt,
+		&i.RetryCount,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]model.Subscription, error) {
+	items := []model.Subscription{}
+	for rows.Next() {
+		i, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const subscriptionChargeColumns = `id, subscription_id, COALESCE(transaction_id, ''), amount, status, attempt, COALESCE(failure_reason, ''), created_at`
+
+func scanSubscriptionCharge(row rowScanner) (model.SubscriptionCharge, error) {
+	var i model.SubscriptionCharge
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.TransactionID,
+		&i.Amount,
+		&i.Status,
+		&i.Attempt,
+		&i.FailureReason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type TopUpRepository interface {
+	Create(arg model.TopUpOrder) (model.TopUpOrder, error)
+	SetCharge(id string, reference string, checkoutURL string) (model.TopUpOrder, error)
+	Get(id string) (model.TopUpOrder, error)
+	GetByReference(reference string) (model.TopUpOrder, error)
+	ListByCustomerId(customerId string, params model.PaginationParams) ([]model.TopUpOrder, error)
+	ListExpired() ([]string, error)
+	MarkPaid(id string) (model.TopUpOrder, error)
+	Close(id string, status string, reason string) (model.TopUpOrder, error)
+}
+
+type topUpRepository struct {
+	db            *sql.DB
+	limitLocation *time.Location
+}
+
+func NewTopUpRepository(db *sql.DB, limitLocation *time.Location) TopUpRepository {
+	return &topUpRepository{db: db, limitLocation: limitLocation}
+}
+
+// Create implements TopUpRepository. The order takes the currency of the
+// customer wallet and counts against the top-up limits of the customer.
+func (repo *topUpRepository) Create(arg model.TopUpOrder) (model.TopUpOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(tx, model.AccountOwnerCustomer, arg.CustomerID)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+	if wallet.frozenIncoming {
+		return model.TopUpOrder{}, frozenError(model.AccountOwnerCustomer, arg.CustomerID)
+	}
+	if err := checkSpendingLimits(tx, repo.limitLocation, arg.CustomerID, model.LimitKindTopUp, arg.Amount); err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	sql := `
+	INSERT INTO top_up_orders (
+		id, customer_id, amount, currency, status, expires_at
+	  )
+	  SELECT $1, id, $3, currency, $4, $5 FROM customers WHERE id = $2
+	  RETURNING ` + topUpColumns
+	i, err := scanTopUp(tx.QueryRow(sql, arg.ID, arg.CustomerID, arg.Amount, model.TopUpStatusPending, arg.ExpiresAt))
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.TopUpOrder{}, err
+	}
+	return i, nil
+}
+
+// SetCharge implements TopUpRepository.
+func (repo *topUpRepository) SetCharge(id string, reference string, checkoutURL string) (model.TopUpOrder, error) {
+	sql := `UPDATE top_up_orders
+	SET gateway_reference = $1, checkout_url = $2, updated_at = now()
+	WHERE id = $3
+	RETURNING ` + topUpColumns
+	i, err := scanTopUp(repo.db.QueryRow(sql, reference, checkoutURL, id))
+	if err != nil {
+		return model.TopUpOrder{}, notFound(err, "top-up order", id)
+	}
+	return i, nil
+}
+
+// Get implements TopUpRepository.
+func (repo *topUpRepository) Get(id string) (model.TopUpOrder, error) {
+	sql := `SELECT ` + topUpColumns + ` FROM top_up_orders WHERE id = $1`
+	i, err := scanTopUp(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.TopUpOrder{}, notFound(err, "top-up order", id)
+	}
+	return i, nil
+}
+
+// GetByReference implements TopUpRepository.
+func (repo *topUpRepository) GetByReference(reference string) (model.TopUpOrder, error) {
+	sql := `SELECT ` + topUpColumns + ` FROM top_up_orders WHERE gateway_reference = $1`
+	i, err := scanTopUp(repo.db.QueryRow(sql, reference))
+	if err != nil {
+		return model.TopUpOrder{}, notFound(err, "top-up order with reference", reference)
+	}
+	return i, nil
+}
+
+// ListByCustomerId implements TopUpRepository.
+func (repo *topUpRepository) ListByCustomerId(customerId string, params model.PaginationParams) ([]model.TopUpOrder, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", true, []any{customerId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT ` + topUpColumns + ` FROM top_up_orders
+	WHERE customer_id = $1 AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.TopUpOrder{}
+	for rows.Next() {
+		i, err := scanTopUp(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListExpired implements TopUpRepository.
+func (repo *topUpRepository) ListExpired() ([]string, error) {
+	sql := `SELECT id FROM top_up_orders
+	WHERE status = $1 AND expires_at < now()
+	ORDER BY expires_at`
+	rows, err := repo.db.Query(sql, model.TopUpStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MarkPaid implements TopUpRepository. It credits the wallet once; a repeated
+// confirmation returns the paid order unchanged. Payments confirmed after the
+// order failed or expired, or after the wallet was frozen, are still credited
+// because the gateway collected the money.
+func (repo *topUpRepository) MarkPaid(id string) (model.TopUpOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT ` + topUpColumns + ` FROM top_up_orders WHERE id = $1 FOR UPDATE`
+	order, err := scanTopUp(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.TopUpOrder{}, notFound(err, "top-up order", id)
+	}
+	if order.Status == model.TopUpStatusPaid {
+		return order, nil
+	}
+
+	wallet, err := lockWallet(tx, model.AccountOwnerCustomer, order.CustomerID)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+	if wallet.currency != order.Currency {
+		return model.TopUpOrder{}, common.ErrCurrencyMismatch
+	}
+
+	sql = `UPDATE customers
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, order.Amount, order.CustomerID); err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	// Money that reaches a wallet frozen for incoming payments stays on hold
+	// until support releases it.
+	if wallet.frozenIncoming {
+		_, err := insertWalletHold(tx, wallet.table, model.WalletHold{
+			ID:        common.GenerateID(),
+			OwnerType: model.AccountOwnerCustomer,
+			OwnerID:   order.CustomerID,
+			Amount:    order.Amount,
+			Currency:  order.Currency,
+			Reason:    "top-up " + order.ID + " to a frozen wallet",
+			CreatedBy: model.WalletActorSystem,
+		})
+		if err != nil {
+			return model.TopUpOrder{}, err
+		}
+	}
+
+	sql = `UPDATE top_up_orders
+	SET status = $1, failure_reason = NULL, paid_at = now(), updated_at = now()
+	WHERE id = $2
+	RETURNING ` + topUpColumns
+	i, err := scanTopUp(tx.QueryRow(sql, model.TopUpStatusPaid, id))
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	_, err = postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindTopUp,
+		ReferenceID: i.ID,
+		Description: "wallet top-up " + i.GatewayReference,
+		Postings: []model.Posting{
+			{OwnerType: model.AccountOwnerCustomer, OwnerID: i.CustomerID, Currency: i.Currency, Amount: i.Amount},
+			{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountTopUp, Currency: i.Currency, Amount: -i.Amount},
+		},
+	})
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.TopUpOrder{}, err
+	}
+	return i, nil
+}
+
+// Close implements TopUpRepository. Only pending orders can fail or expire.
+func (repo *topUpRepository) Close(id string, status string, reason string) (model.TopUpOrder, error) {
+	sql := `UPDATE top_up_orders
+	SET status = $1, failure_reason = NULLIF($2, ''), updated_at = now()
+	WHERE id = $3 AND status = $4
+	RETURNING ` + topUpColumns
+	i, err := scanTopUp(repo.db.QueryRow(sql, status, reason, id, model.TopUpStatusPending))
+	if isNoRows(err) {
+		if _, err := repo.Get(id); err != nil {
+			return model.TopUpOrder{}, err
+		}
+		return model.TopUpOrder{}, common.ErrInvalidStatus
+	}
+	return i, err
+}
+
+const topUpColumns = `id, customer_id, amount, currency, status, COALESCE(gateway_reference, ''), COALESCE(checkout_url, ''), COALESCE(failure_reason, ''), expires_at, paid_at, created_at, updated_at`
+
+func scanTopUp(row rowScanner) (model.TopUpOrder, error) {
+	var i model.TopUpOrder
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.GatewayReference,
+		&i.CheckoutURL,
+		&i.FailureReason,
+		&i.ExpiresAt,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
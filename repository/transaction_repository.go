@@ -2,118 +2,594 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
 
 	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/lib/pq"
 )
 
 type TransactionRepository interface {
 	Create(arg model.Transaction) (model.Transaction, error)
-	GetByCustomerId(id string, params model.PaginationParams) ([]model.Transaction, error)
-	List(params model.PaginationParams) ([]model.Transaction, error)
+	CreateSplit(parent model.Transaction, legs []model.Transaction) (model.Transaction, error)
+	ListLegs(parentId string) ([]model.Transaction, error)
+	Authorize(arg model.Transaction) (model.Transaction, error)
+	Capture(id string, amount int64, fee int64) (model.Transaction, error)
+	Void(id string) (model.Transaction, error)
+	Expire(id string) (model.Transaction, error)
+	ListExpiredAuthorizations() ([]string, error)
+	GetById(id string) (model.Transaction, error)
+	List(filter model.TransactionFilter, params model.PaginationParams) ([]model.Transaction, error)
 }
 
 type transactionRepository struct {
-	db *sql.DB
+	db            *sql.DB
+	limitLocation *time.Location
 }
 
-func NewTransactionRepository(db *sql.DB) TransactionRepository {
-	return &transactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB, limitLocation *time.Location) TransactionRepository {
+	return &transactionRepository{db: db, limitLocation: limitLocation}
 }
 
-// Create implements TransactionRepository.
+// Create implements TransactionRepository. The payment is captured
+// immediately. A payment with an InvoiceID pays that invoice, and one with a
+// SubscriptionCharge records the charge, in the same database transaction.
 func (repo *transactionRepository) Create(arg model.Transaction) (model.Transaction, error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return model.Transaction{}, err
 	}
-	sql := `
-	INSERT INTO transactions (
-		id,
-		sender_customer_id,
-		receiver_merchant_id,
-		amount
-	  ) VALUES (
-		$1, $2, $3, $4
-	  ) RETURNING id, sender_customer_id, receiver_merchant_id, amount, created_at`
-	row := tx.QueryRow(sql, arg.ID, arg.SenderCustomerId, arg.ReceiverMerchantId, arg.Amount)
-	var i model.Transaction
-	err = row.Scan(
-		&i.ID,
-		&i.SenderCustomerId,
-		&i.ReceiverMerchantId,
-		&i.Amount,
-		&i.CreatedAt,
-	)
-	sql = `UPDATE merchants
-	SET balance = balance + $1
+	defer tx.Rollback()
+
+	if arg.InvoiceID != "" {
+		if err := lockPayableInvoice(tx, arg); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	if arg.SubscriptionCharge != nil {
+		if err := lockChargeableSubscription(tx, arg.SubscriptionCharge.SubscriptionID); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	available, err := lockPaymentParties(tx, arg)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if err := checkScreening(tx, arg); err != nil {
+		return model.Transaction{}, err
+	}
+	if available < arg.SourceAmount {
+		return model.Transaction{}, common.ErrInsufficientFunds
+	}
+	if err := checkSpendingLimits(tx, repo.limitLocation, arg.SenderCustomerId, model.LimitKindPayment, arg.SourceAmount); err != nil {
+		return model.Transaction{}, err
+	}
+
+	arg.Status = model.TransactionStatusCaptured
+	arg.CapturedAmount = arg.Amount
+	arg.ExpiresAt = nil
+	i, err := insertTransaction(tx, arg)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if arg.Promo != nil {
+		i.Promo = arg.Promo
+		if i.Promo, err = redeemPromo(tx, i); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	if err := settlePayment(tx, i, i.SourceAmount); err != nil {
+		return model.Transaction{}, err
+	}
+
+	if i.InvoiceID != "" {
+		if err := markInvoicePaid(tx, i); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	if arg.SubscriptionCharge != nil {
+		i.SubscriptionCharge = arg.SubscriptionCharge
+		if *i.SubscriptionCharge, err = recordSubscriptionPayment(tx, i); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return i, nil
+}
+
+// CreateSplit implements TransactionRepository. Every leg is captured and
+// settled like a single payment, in one database transaction so either all
+// merchants are paid or none. The source amount of the parent is shared
+// between the legs by amount.
+func (repo *transactionRepository) CreateSplit(parent model.Transaction, legs []model.Transaction) (model.Transaction, error) {
+	var allocated, allocatedSource int64
+	for k := range legs {
+		// cumulative, so the legs add up to the parent exactly
+		allocated += legs[k].Amount
+		legs[k].SourceAmount = proportion(allocated, parent.Amount, parent.SourceAmount) - allocatedSource
+		if legs[k].SourceAmount <= 0 {
+			return model.Transaction{}, common.ErrInvalidAmount
+		}
+		allocatedSource += legs[k].SourceAmount
+	}
+	if allocated != parent.Amount {
+		return model.Transaction{}, common.ErrInvalidSplit
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	// Lock the merchants in a fixed order so concurrent checkouts do not
+	// deadlock.
+	locking := append([]model.Transaction{}, legs...)
+	sort.Slice(locking, func(i, j int) bool {
+		return locking[i].ReceiverMerchantId < locking[j].ReceiverMerchantId
+	})
+	var available int64
+	for _, leg := range locking {
+		if available, err = lockPaymentParties(tx, leg); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	if err := checkScreening(tx, parent); err != nil {
+		return model.Transaction{}, err
+	}
+	if available < parent.SourceAmount {
+		return model.Transaction{}, common.ErrInsufficientFunds
+	}
+	if err := checkSpendingLimits(tx, repo.limitLocation, parent.SenderCustomerId, model.LimitKindPayment, parent.SourceAmount); err != nil {
+		return model.Transaction{}, err
+	}
+
+	parent.Status = model.TransactionStatusCaptured
+	parent.CapturedAmount = parent.Amount
+	parent.ExpiresAt = nil
+	i, err := insertTransaction(tx, parent)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	for _, leg := range legs {
+		leg.ParentID = i.ID
+		leg.Status = model.TransactionStatusCaptured
+		leg.CapturedAmount = leg.Amount
+		leg.ExpiresAt = nil
+		inserted, err := insertTransaction(tx, leg)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		if err := settlePayment(tx, inserted, inserted.SourceAmount); err != nil {
+			return model.Transaction{}, err
+		}
+		i.Legs = append(i.Legs, inserted)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return i, nil
+}
+
+// ListLegs implements TransactionRepository.
+func (repo *transactionRepository) ListLegs(parentId string) ([]model.Transaction, error) {
+	sql := `SELECT ` + transactionColumns + ` from transactions WHERE parent_id = $1
+	ORDER BY receiver_merchant_id`
+	rows, err := repo.db.Query(sql, parentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTransactions(rows)
+}
+
+// Authorize implements TransactionRepository. Funds stay in the customer
+// wallet but are held until the transaction is captured, voided or expires.
+func (repo *transactionRepository) Authorize(arg model.Transaction) (model.Transaction, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	available, err := lockPaymentParties(tx, arg)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if err := checkScreening(tx, arg); err != nil {
+		return model.Transaction{}, err
+	}
+	if available < arg.SourceAmount {
+		return model.Transaction{}, common.ErrInsufficientFunds
+	}
+	if err := checkSpendingLimits(tx, repo.limitLocation, arg.SenderCustomerId, model.LimitKindPayment, arg.SourceAmount); err != nil {
+		return model.Transaction{}, err
+	}
+
+	arg.Status = model.TransactionStatusAuthorized
+	arg.CapturedAmount = 0
+	i, err := insertTransaction(tx, arg)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	sql := `UPDATE customers
+	SET held_amount = held_amount + $1
 	WHERE id = $2`
-	_, _ = tx.Exec(sql, arg.Amount, arg.ReceiverMerchantId)
+	if _, err := tx.Exec(sql, i.SourceAmount, i.SenderCustomerId); err != nil {
+		return model.Transaction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return i, nil
+}
+
+// Capture implements TransactionRepository. A zero amount captures the full
+// authorization; any uncaptured remainder is released back to the customer.
+// The fee must be quoted for the captured amount.
+func (repo *transactionRepository) Capture(id string, amount int64, fee int64) (model.Transaction, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	authorization, err := scanTransaction(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.Transaction{}, notFound(err, "transaction", id)
+	}
+	if authorization.Status != model.TransactionStatusAuthorized ||
+		(authorization.ExpiresAt != nil && authorization.ExpiresAt.Before(time.Now())) {
+		return model.Transaction{}, common.ErrInvalidStatus
+	}
+	if amount == 0 {
+		amount = authorization.Amount
+	}
+	if amount > authorization.Amount {
+		return model.Transaction{}, common.ErrCaptureExceeded
+	}
+	if fee > amount {
+		return model.Transaction{}, common.ErrInvalidAmount
+	}
+
+	if _, err := lockPaymentParties(tx, authorization); err != nil {
+		return model.Transaction{}, err
+	}
 
 	sql = `UPDATE customers
-	SET balance = balance + $1
-	WHERE user_id = $2`
+	SET held_amount = held_amount - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, authorization.SourceAmount, authorization.SenderCustomerId); err != nil {
+		return model.Transaction{}, err
+	}
 
-	_, _ = tx.Exec(sql, -arg.Amount, arg.SenderCustomerId)
+	sql = `UPDATE transactions
+	SET status = $1, captured_amount = $2, fee = $3
+	WHERE id = $4
+	RETURNING ` + transactionColumns
+	i, err := scanTransaction(tx.QueryRow(sql, model.TransactionStatusCaptured, amount, fee, id))
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	if err := settlePayment(tx, i, proportion(i.CapturedAmount, i.Amount, i.SourceAmount)); err != nil {
+		return model.Transaction{}, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return model.Transaction{}, err
 	}
-	return i, err
+	return i, nil
+}
+
+// Void implements TransactionRepository.
+func (repo *transactionRepository) Void(id string) (model.Transaction, error) {
+	return repo.releaseAuthorization(id, model.TransactionStatusVoided)
+}
 
+// Expire implements TransactionRepository.
+func (repo *transactionRepository) Expire(id string) (model.Transaction, error) {
+	return repo.releaseAuthorization(id, model.TransactionStatusExpired)
 }
 
-// Get implements TransactionRepository.
-func (repo *transactionRepository) GetByCustomerId(id string, params model.PaginationParams) ([]model.Transaction, error) {
-	sql := `SELECT id, sender_customer_id,receiver_merchant_id,amount,created_at from transactions WHERE sender_customer_id = $1
-	ORDER BY created_at
-	LIMIT $2
-	OFFSET $3`
-	rows, err := repo.db.Query(sql, id, params.Limit, params.Offset)
+// ListExpiredAuthorizations implements TransactionRepository.
+func (repo *transactionRepository) ListExpiredAuthorizations() ([]string, error) {
+	sql := `SELECT id FROM transactions
+	WHERE status = $1 AND expires_at < now()
+	ORDER BY expires_at`
+	rows, err := repo.db.Query(sql, model.TransactionStatusAuthorized)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []model.Transaction{}
+	ids := []string{}
 	for rows.Next() {
-		var i model.Transaction
-		if err := rows.Scan(
-			&i.ID,
-			&i.SenderCustomerId,
-			&i.ReceiverMerchantId,
-			&i.Amount,
-			&i.CreatedAt,
-		); err != nil {
+		var id string
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		ids = append(ids, id)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return items, nil
+	return ids, nil
+}
+
+func (repo *transactionRepository) releaseAuthorization(id string, status string) (model.Transaction, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	authorization, err := scanTransaction(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.Transaction{}, notFound(err, "transaction", id)
+	}
+	if authorization.Status != model.TransactionStatusAuthorized {
+		return model.Transaction{}, common.ErrInvalidStatus
+	}
+
+	sql = `UPDATE customers
+	SET held_amount = held_amount - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, authorization.SourceAmount, authorization.SenderCustomerId); err != nil {
+		return model.Transaction{}, err
+	}
+
+	sql = `UPDATE transactions
+	SET status = $1
+	WHERE id = $2
+	RETURNING ` + transactionColumns
+	i, err := scanTransaction(tx.QueryRow(sql, status, id))
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return i, nil
+}
+
+// lockPaymentParties locks the customer before the merchant so concurrent
+// payments always acquire row locks in the same order, checks both wallets
+// still use the currencies of the transaction and are not frozen, and
+// returns the customer balance that is not held by pending authorizations
+// or holds.
+func lockPaymentParties(tx *sql.Tx, arg model.Transaction) (int64, error) {
+	sql := `SELECT balance - held_amount, currency, frozen FROM customers WHERE id = $1 FOR UPDATE`
+	var available int64
+	var currency string
+	var frozen bool
+	if err := tx.QueryRow(sql, arg.SenderCustomerId).Scan(&available, &currency, &frozen); err != nil {
+		return 0, notFound(err, "customer", arg.SenderCustomerId)
+	}
+	if frozen {
+		return 0, frozenError(model.AccountOwnerCustomer, arg.SenderCustomerId)
+	}
+	if currency != arg.SourceCurrency {
+		return 0, common.ErrCurrencyMismatch
+	}
+
+	sql = `SELECT currency, frozen_incoming FROM merchants WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(sql, arg.ReceiverMerchantId).Scan(&currency, &frozen); err != nil {
+		return 0, notFound(err, "merchant", arg.ReceiverMerchantId)
+	}
+	if frozen {
+		return 0, frozenError(model.AccountOwnerMerchant, arg.ReceiverMerchantId)
+	}
+	if currency != arg.Currency {
+		return 0, common.ErrCurrencyMismatch
+	}
+	return available, nil
+}
+
+func insertTransaction(tx *sql.Tx, arg model.Transaction) (model.Transaction, error) {
+	sql := `
+	INSERT INTO transactions (
+		id,
+		sender_customer_id,
+		receiver_merchant_id,
+		amount,
+		currency,
+		source_amount,
+		source_currency,
+		fx_rate,
+		status,
+		captured_amount,
+		fee,
+		discount,
+		invoice_id,
+		parent_id,
+		expires_at
+	  ) VALUES (
+		$1, $2, NULLIF($3, ''), $4, $5, $6, $7, NULLIF($8, '')::numeric, $9, $10, $11, $12, NULLIF($13, ''), NULLIF($14, ''), $15
+	  ) RETURNING ` + transactionColumns
+	return scanTransaction(tx.QueryRow(sql, arg.ID, arg.SenderCustomerId, arg.ReceiverMerchantId, arg.Amount, arg.Currency,
+		arg.SourceAmount, arg.SourceCurrency, arg.FxRate, arg.Status, arg.CapturedAmount, arg.Fee, arg.Discount, arg.InvoiceID, arg.ParentID, arg.ExpiresAt))
+}
+
+// settlePayment moves the captured amount of t from the customer to the
+// merchant, less the fee which goes to the system fees account, and records
+// it in the ledger. sourceAmount is the captured amount in the customer
+// currency. Both rows must already be locked.
+func settlePayment(tx *sql.Tx, t model.Transaction, sourceAmount int64) error {
+	sql := `UPDATE merchants
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, t.CapturedAmount-t.Fee, t.ReceiverMerchantId); err != nil {
+		return err
+	}
+
+	sql = `UPDATE customers
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, -sourceAmount, t.SenderCustomerId); err != nil {
+		return err
+	}
+
+	postings := exchangePostings(model.AccountOwnerCustomer, t.SenderCustomerId, t.SourceCurrency, sourceAmount, model.AccountOwnerMerchant, t.ReceiverMerchantId, t.Currency, t.CapturedAmount)
+	_, err := postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindPayment,
+		ReferenceID: t.ID,
+		Description: "payment to merchant",
+		Postings:    append(postings, feePostings(t.ReceiverMerchantId, t.Currency, t.Fee)...),
+	})
+	return err
+}
+
+// feePostings moves fee from the merchant to the system fees account. A
+// negative fee gives it back.
+func feePostings(merchantId string, currency string, fee int64) []model.Posting {
+	if fee == 0 {
+		return nil
+	}
+	return []model.Posting{
+		{OwnerType: model.AccountOwnerMerchant, OwnerID: merchantId, Currency: currency, Amount: -fee},
+		{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountFees, Currency: currency, Amount: fee},
+	}
+}
+
+// exchangePostings moves fromAmount out of one account and toAmount into
+// another. When the currencies differ the system FX account takes both sides
+// so each currency still balances.
+func exchangePostings(fromType string, fromId string, fromCurrency string, fromAmount int64, toType string, toId string, toCurrency string, toAmount int64) []model.Posting {
+	if fromCurrency == toCurrency {
+		return []model.Posting{
+			{OwnerType: fromType, OwnerID: fromId, Currency: fromCurrency, Amount: -fromAmount},
+			{OwnerType: toType, OwnerID: toId, Currency: toCurrency, Amount: toAmount},
+		}
+	}
+	return []model.Posting{
+		{OwnerType: fromType, OwnerID: fromId, Currency: fromCurrency, Amount: -fromAmount},
+		{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountFx, Currency: fromCurrency, Amount: fromAmount},
+		{OwnerType: model.AccountOwnerSystem, OwnerID: model.SystemAccountFx, Currency: toCurrency, Amount: -toAmount},
+		{OwnerType: toType, OwnerID: toId, Currency: toCurrency, Amount: toAmount},
+	}
+}
+
+// proportion returns part/total of value rounded half up, used to carry the
+// exchange rate of a transaction over to partial captures and refunds.
+func proportion(part int64, total int64, value int64) int64 {
+	if total == 0 || part == total {
+		return value
+	}
+	n := new(big.Int).Mul(big.NewInt(part), big.NewInt(value))
+	n.Mul(n, big.NewInt(2))
+	n.Add(n, big.NewInt(total))
+	n.Quo(n, new(big.Int).Mul(big.NewInt(total), big.NewInt(2)))
+	return n.Int64()
 }
 
-// List implements TransactionRepository.
-func (repo *transactionRepository) List(params model.PaginationParams) ([]model.Transaction, error) {
-	sql := `SELECT id, sender_customer_id,receiver_merchant_id,amount,created_at from transactions
-	ORDER BY created_at
-	LIMIT $1
-	OFFSET $2`
-	rows, err := repo.db.Query(sql, params.Limit, params.Offset)
+// GetById implements TransactionRepository.
+func (repo *transactionRepository) GetById(id string) (model.Transaction, error) {
+	sql := `SELECT ` + transactionColumns + ` from transactions WHERE id = $1`
+	i, err := scanTransaction(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.Transaction{}, notFound(err, "transaction", id)
+	}
+	return i, nil
+}
+
+// transactionSortColumns maps the sort fields of a listing to their column
+// and its SQL type.
+var transactionSortColumns = map[string]struct{ column, sqlType string }{
+	model.TransactionSortCreatedAt:      {"created_at", "timestamptz"},
+	model.TransactionSortAmount:         {"amount", "bigint"},
+	model.TransactionSortCapturedAmount: {"captured_amount", "bigint"},
+	model.TransactionSortStatus:         {"status", "varchar"},
+}
+
+// List implements TransactionRepository. Legs of split payments are listed
+// with their parent only. The sort field and order are checked against
+// fixed lists before they go into the query; everything else is a parameter.
+func (repo *transactionRepository) List(filter model.TransactionFilter, params model.PaginationParams) ([]model.Transaction, error) {
+	sortColumn, ok := transactionSortColumns[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot sort by %q", common.ErrInvalidFilter, filter.SortBy)
+	}
+	if params.Cursor != nil && params.Cursor.Sort != filter.SortKey() {
+		return nil, fmt.Errorf("%w: it was made for another sorting", common.ErrInvalidCursor)
+	}
+
+	args := []any{filter.CustomerID, filter.MerchantID, pq.Array(filter.Statuses),
+		filter.From, filter.To, filter.MinAmount, filter.MaxAmount}
+	cond, page, args, err := pageClauses(params, sortColumn.column, sortColumn.sqlType, filter.SortOrder == model.SortOrderDesc, args)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := `SELECT ` + transactionColumns + ` from transactions t
+	WHERE parent_id IS NULL
+		AND ($1 = '' OR sender_customer_id = $1)
+		AND ($2 = '' OR receiver_merchant_id = $2
+			OR EXISTS (SELECT 1 FROM transactions l WHERE l.parent_id = t.id AND l.receiver_merchant_id = $2))
+		AND (COALESCE(cardinality($3::varchar[]), 0) = 0 OR status = ANY($3))
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at < $5)
+		AND ($6::bigint IS NULL OR amount >= $6)
+		AND ($7::bigint IS NULL OR amount <= $7)
+		AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	return scanTransactions(rows)
+}
+
+const transactionColumns = `id, sender_customer_id, COALESCE(receiver_merchant_id, ''), amount, currency, source_amount, source_currency, COALESCE(fx_rate::text, ''), captured_amount, fee, discount, refunded_amount, status, COALESCE(invoice_id, ''), COALESCE(parent_id, ''), expires_at, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTransaction(row rowScanner) (model.Transaction, error) {
+	var i model.Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.SenderCustomerId,
+		&i.ReceiverMerchantId,
+		&i.Amount,
+		&i.Currency,
+		&i.SourceAmount,
+		&i.SourceCurrency,
+		&i.FxRate,
+		&i.CapturedAmount,
+		&i.Fee,
+		&i.Discount,
+		&i.RefundedAmount,
+		&i.Status,
+		&i.InvoiceID,
+		&i.ParentID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func scanTransactions(rows *sql.Rows) ([]model.Transaction, error) {
 	items := []model.Transaction{}
 	for rows.Next() {
-		var i model.Transaction
-		if err := rows.Scan(
-			&i.ID,
-			&i.SenderCustomerId,
-			&i.ReceiverMerchantId,
-			&i.Amount,
-			&i.CreatedAt,
-		); err != nil {
+		i, err := scanTransaction(rows)
+		if err != nil {
 			return nil, err
 		}
 		items = append(items, i)
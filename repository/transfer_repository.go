@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type TransferRepository interface {
+	Create(arg model.Transfer) (model.Transfer, error)
+	ListByCustomerId(customerId string, params model.PaginationParams) ([]model.Transfer, error)
+}
+
+type transferRepository struct {
+	db *sql.DB
+}
+
+func NewTransferRepository(db *sql.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+// Create implements TransferRepository.
+func (repo *transferRepository) Create(arg model.Transfer) (model.Transfer, error) {
+	if arg.SenderCustomerId == arg.ReceiverCustomerId {
+		return model.Transfer{}, common.ErrSelfTransfer
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	// Lock both wallets in ID order so opposite transfers between the same
+	// two customers cannot deadlock.
+	first, second := arg.SenderCustomerId, arg.ReceiverCustomerId
+	if second < first {
+		first, second = second, first
+	}
+	wallets := map[string]lockedWallet{}
+	for _, id := range []string{first, second} {
+		wallet, err := lockWallet(tx, model.AccountOwnerCustomer, id)
+		if err != nil {
+			return model.Transfer{}, err
+		}
+		wallets[id] = wallet
+	}
+	if wallets[arg.SenderCustomerId].frozen {
+		return model.Transfer{}, frozenError(model.AccountOwnerCustomer, arg.SenderCustomerId)
+	}
+	if wallets[arg.ReceiverCustomerId].frozenIncoming {
+		return model.Transfer{}, frozenError(model.AccountOwnerCustomer, arg.ReceiverCustomerId)
+	}
+	currency := wallets[arg.SenderCustomerId].currency
+	if currency != wallets[arg.ReceiverCustomerId].currency {
+		return model.Transfer{}, common.ErrCurrencyMismatch
+	}
+	if wallets[arg.SenderCustomerId].available < arg.Amount {
+		return model.Transfer{}, common.ErrInsufficientFunds
+	}
+
+	sql := `
+	INSERT INTO transfers (
+		id, sender_customer_id, receiver_customer_id, amount, currency, note
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6
+	  ) RETURNING id, sender_customer_id, receiver_customer_id, amount, currency, COALESCE(note, ''), created_at`
+	row := tx.QueryRow(sql, arg.ID, arg.SenderCustomerId, arg.ReceiverCustomerId, arg.Amount, currency, arg.Note)
+	var i model.Transfer
+	err = row.Scan(
+		&i.ID,
+		&i.SenderCustomerId,
+		&i.ReceiverCustomerId,
+		&i.Amount,
+		&i.Currency,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+
+	sql = `UPDATE customers
+	SET balance = balance + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, -i.Amount, i.SenderCustomerId); err != nil {
+		return model.Transfer{}, err
+	}
+	if _, err := tx.Exec(sql, i.Amount, i.ReceiverCustomerId); err != nil {
+		return model.Transfer{}, err
+	}
+
+	_, err = postJournal(tx, model.JournalEntry{
+		Kind:        model.JournalKindTransfer,
+		ReferenceID: i.ID,
+		Description: "transfer between customers",
+		Postings: []model.Posting{
+			{OwnerType: model.AccountOwnerCustomer, OwnerID: i.SenderCustomerId, Currency: currency, Amount: -i.Amount},
+			{OwnerType: model.AccountOwnerCustomer, OwnerID: i.ReceiverCustomerId, Currency: currency, Amount: i.Amount},
+		},
+	})
+	if err != nil {
+		return model.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Transfer{}, err
+	}
+	return i, nil
+}
+
+// ListByCustomerId implements TransferRepository. It returns transfers the
+// customer sent as well as received.
+func (repo *transferRepository) ListByCustomerId(customerId string, params model.PaginationParams) ([]model.Transfer, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", false, []any{customerId})
+	if err != nil {
+		return nil, err
+	}
+	sql := `SELECT id, sender_customer_id, receiver_customer_id, amount, currency, COALESCE(note, ''), created_at
+	FROM transfers
+	WHERE (sender_customer_id = $1 OR receiver_customer_id = $1) AND ` + cond + `
+	` + page
+	rows, err := repo.db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.Transfer{}
+	for rows.Next() {
+		var i model.Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.SenderCustomerId,
+			&i.ReceiverCustomerId,
+			&i.Amount,
+			&i.Currency,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -106,16 +106,22 @@ func (u *userRepository) Get(username string) (model.User, error) {
 		&i.Role,
 		&i.CreatedAt,
 	)
-	return i, err
+	if err != nil {
+		return model.User{}, notFound(err, "user", username)
+	}
+	return i, nil
 }
 
 // List implements UserRepository.
 func (u *userRepository) List(params model.PaginationParams) ([]model.UserResponse, error) {
+	cond, page, args, err := pageClauses(params, "created_at", "timestamptz", false, nil)
+	if err != nil {
+		return nil, err
+	}
 	sql := `SELECT id, email, username, created_at from users
-	ORDER BY created_at
-	LIMIT $1
-	OFFSET $2`
-	rows, err := u.db.Query(sql, params.Limit, params.Offset)
+	WHERE ` + cond + `
+	` + page
+	rows, err := u.db.Query(sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type WalletRepository interface {
+	Freeze(arg model.WalletFreeze) (model.WalletFreeze, error)
+	Unfreeze(ownerType string, ownerId string, liftedBy string, reason string) (model.WalletFreeze, error)
+	ListFreezes(ownerType string, ownerId string) ([]model.WalletFreeze, error)
+	PlaceHold(arg model.WalletHold) (model.WalletHold, error)
+	ReleaseHold(id string, releasedBy string, reason string) (model.WalletHold, error)
+	GetHold(id string) (model.WalletHold, error)
+	ListHolds(ownerType string, ownerId string) ([]model.WalletHold, error)
+}
+
+type walletRepository struct {
+	db *sql.DB
+}
+
+func NewWalletRepository(db *sql.DB) WalletRepository {
+	return &walletRepository{db: db}
+}
+
+// Freeze implements WalletRepository. A wallet has one active freeze at most.
+func (repo *walletRepository) Freeze(arg model.WalletFreeze) (model.WalletFreeze, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.WalletFreeze{}, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(tx, arg.OwnerType, arg.OwnerID)
+	if err != nil {
+		return model.WalletFreeze{}, err
+	}
+	if wallet.frozen {
+		return model.WalletFreeze{}, fmt.Errorf("%w: %s %s is already frozen", common.ErrInvalidStatus, arg.OwnerType, arg.OwnerID)
+	}
+
+	sql := `
+	INSERT INTO wallet_freezes (
+		id, owner_type, owner_id, block_incoming, reason, frozen_by
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6
+	  ) RETURNING ` + walletFreezeColumns
+	i, err := scanWalletFreeze(tx.QueryRow(sql, arg.ID, arg.OwnerType, arg.OwnerID, arg.BlockIncoming, arg.Reason, arg.FrozenBy))
+	if err != nil {
+		return model.WalletFreeze{}, err
+	}
+
+	sql = `UPDATE ` + wallet.table + `
+	SET frozen = true, frozen_incoming = $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, i.BlockIncoming, i.OwnerID); err != nil {
+		return model.WalletFreeze{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.WalletFreeze{}, err
+	}
+	return i, nil
+}
+
+// Unfreeze implements WalletRepository.
+func (repo *walletRepository) Unfreeze(ownerType string, ownerId string, liftedBy string, reason string) (model.WalletFreeze, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.WalletFreeze{}, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(tx, ownerType, ownerId)
+	if err != nil {
+		return model.WalletFreeze{}, err
+	}
+	if !wallet.frozen {
+		return model.WalletFreeze{}, fmt.Errorf("%w: %s %s is not frozen", common.ErrInvalidStatus, ownerType, ownerId)
+	}
+
+	sql := `UPDATE wallet_freezes
+	SET lift_reason = $1, lifted_by = $2, lifted_at = now()
+	WHERE owner_type = $3 AND owner_id = $4 AND lifted_at IS NULL
+	RETURNING ` + walletFreezeColumns
+	i, err := scanWalletFreeze(tx.QueryRow(sql, reason, liftedBy, ownerType, ownerId))
+	if err != nil {
+		return model.WalletFreeze{}, err
+	}
+
+	sql = `UPDATE ` + wallet.table + `
+	SET frozen = false, frozen_incoming = false
+	WHERE id = $1`
+	if _, err := tx.Exec(sql, ownerId); err != nil {
+		return model.WalletFreeze{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.WalletFreeze{}, err
+	}
+	return i, nil
+}
+
+// ListFreezes implements WalletRepository. It returns every freeze of the
+// wallet, lifted or not, newest first.
+func (repo *walletRepository) ListFreezes(ownerType string, ownerId string) ([]model.WalletFreeze, error) {
+	sql := `SELECT ` + walletFreezeColumns + ` FROM wallet_freezes
+	WHERE owner_type = $1 AND owner_id = $2
+	ORDER BY created_at DESC`
+	rows, err := repo.db.Query(sql, ownerType, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.WalletFreeze{}
+	for rows.Next() {
+		i, err := scanWalletFreeze(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PlaceHold implements WalletRepository. Only money that is not spent or
+// already held can be put on hold.
+func (repo *walletRepository) PlaceHold(arg model.WalletHold) (model.WalletHold, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(tx, arg.OwnerType, arg.OwnerID)
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+	if wallet.available < arg.Amount {
+		return model.WalletHold{}, common.ErrInsufficientFunds
+	}
+	arg.Currency = wallet.currency
+
+	i, err := insertWalletHold(tx, wallet.table, arg)
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.WalletHold{}, err
+	}
+	return i, nil
+}
+
+// ReleaseHold implements WalletRepository.
+func (repo *walletRepository) ReleaseHold(id string, releasedBy string, reason string) (model.WalletHold, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+	defer tx.Rollback()
+
+	sql := `SELECT ` + walletHoldColumns + ` FROM wallet_holds WHERE id = $1 FOR UPDATE`
+	hold, err := scanWalletHold(tx.QueryRow(sql, id))
+	if err != nil {
+		return model.WalletHold{}, notFound(err, "hold", id)
+	}
+	if hold.Status != model.WalletHoldStatusActive {
+		return model.WalletHold{}, fmt.Errorf("%w: hold %s is %s", common.ErrInvalidStatus, id, hold.Status)
+	}
+
+	wallet, err := lockWallet(tx, hold.OwnerType, hold.OwnerID)
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+	sql = `UPDATE ` + wallet.table + `
+	SET held_amount = held_amount - $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, hold.Amount, hold.OwnerID); err != nil {
+		return model.WalletHold{}, err
+	}
+
+	sql = `UPDATE wallet_holds
+	SET release_reason = $1, released_by = $2, released_at = now()
+	WHERE id = $3
+	RETURNING ` + walletHoldColumns
+	i, err := scanWalletHold(tx.QueryRow(sql, reason, releasedBy, id))
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.WalletHold{}, err
+	}
+	return i, nil
+}
+
+// GetHold implements WalletRepository.
+func (repo *walletRepository) GetHold(id string) (model.WalletHold, error) {
+	sql := `SELECT ` + walletHoldColumns + ` FROM wallet_holds WHERE id = $1`
+	i, err := scanWalletHold(repo.db.QueryRow(sql, id))
+	if err != nil {
+		return model.WalletHold{}, notFound(err, "hold", id)
+	}
+	return i, nil
+}
+
+// ListHolds implements WalletRepository. It returns every hold of the wallet,
+// released or not, newest first.
+func (repo *walletRepository) ListHolds(ownerType string, ownerId string) ([]model.WalletHold, error) {
+	sql := `SELECT ` + walletHoldColumns + ` FROM wallet_holds
+	WHERE owner_type = $1 AND owner_id = $2
+	ORDER BY created_at DESC`
+	rows, err := repo.db.Query(sql, ownerType, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []model.WalletHold{}
+	for rows.Next() {
+		i, err := scanWalletHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type lockedWallet struct {
+	table          string
+	available      int64
+	currency       string
+	frozen         bool
+	frozenIncoming bool
+}
+
+// lockWallet locks a customer or merchant wallet and returns the part of its
+// balance that is not held.
+func lockWallet(tx *sql.Tx, ownerType string, ownerId string) (lockedWallet, error) {
+	var i lockedWallet
+	switch ownerType {
+	case model.AccountOwnerCustomer:
+		i.table = "customers"
+	case model.AccountOwnerMerchant:
+		i.table = "merchants"
+	default:
+		return lockedWallet{}, fmt.Errorf("unsupported account owner type %s", ownerType)
+	}
+
+	sql := `SELECT balance - held_amount, currency, frozen, frozen_incoming FROM ` + i.table + ` WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(sql, ownerId).Scan(&i.available, &i.currency, &i.frozen, &i.frozenIncoming); err != nil {
+		return lockedWallet{}, notFound(err, ownerType, ownerId)
+	}
+	return i, nil
+}
+
+// insertWalletHold records a hold and adds it to the held amount of the
+// wallet, which must already be locked.
+func insertWalletHold(tx *sql.Tx, table string, arg model.WalletHold) (model.WalletHold, error) {
+	sql := `
+	INSERT INTO wallet_holds (
+		id, owner_type, owner_id, amount, currency, reason, created_by
+	  ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	  ) RETURNING ` + walletHoldColumns
+	i, err := scanWalletHold(tx.QueryRow(sql, arg.ID, arg.OwnerType, arg.OwnerID, arg.Amount, arg.Currency, arg.Reason, arg.CreatedBy))
+	if err != nil {
+		return model.WalletHold{}, err
+	}
+
+	sql = `UPDATE ` + table + `
+	SET held_amount = held_amount + $1
+	WHERE id = $2`
+	if _, err := tx.Exec(sql, i.Amount, i.OwnerID); err != nil {
+		return model.WalletHold{}, err
+	}
+	return i, nil
+}
+
+// frozenError names the frozen wallet that stopped an operation.
+func frozenError(ownerType string, ownerId string) error {
+	return fmt.Errorf("%w: %s %s", common.ErrWalletFrozen, ownerType, ownerId)
+}
+
+const walletFreezeColumns = `id, owner_type, owner_id, block_incoming, reason, frozen_by, COALESCE(lift_reason, ''), COALESCE(lifted_by, ''), lifted_at, created_at`
+
+func scanWalletFreeze(row rowScanner) (model.WalletFreeze, error) {
+	var i model.WalletFreeze
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerType,
+		&i.OwnerID,
+		&i.BlockIncoming,
+		&i.Reason,
+		&i.FrozenBy,
+		&i.LiftReason,
+		&i.LiftedBy,
+		&i.LiftedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const walletHoldColumns = `id, owner_type, owner_id, amount, currency, reason,
+	CASE WHEN released_at IS NULL THEN 'active' ELSE 'released' END,
+	created_by, COALESCE(release_reason, ''), COALESCE(released_by, ''), released_at, created_at`
+
+func scanWalletHold(row rowScanner) (model.WalletHold, error) {
+	var i model.WalletHold
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerType,
+		&i.OwnerID,
+		&i.Amount,
+		&i.Currency,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedBy,
+		&i.ReleaseReason,
+		&i.ReleasedBy,
+		&i.ReleasedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
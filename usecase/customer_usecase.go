@@ -13,7 +13,6 @@ type CustomerUseCase interface {
 	GetCustomerById(id string) (model.CustomerResponse, error)
 	GetCustomerByUserId(userId string) (model.CustomerResponse, error)
 	ListCustomer(params model.PaginationParams) ([]model.CustomerResponse, error)
-	AddCustomerBalance(id string, amount int64) (model.CustomerResponse, error)
 	DeleteCustomer(id string) error
 }
 
@@ -30,6 +29,26 @@ func NewCustomerUseCase(repo repository.CustomerRepository, userUseCase UserUseC
 	}
 }
 
+func newCustomerResponse(customer model.Customer, user model.User) model.CustomerResponse {
+	return model.CustomerResponse{
+		ID: customer.ID,
+		User: model.UserResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			Username:  user.Username,
+			CreatedAt: user.CreatedAt,
+		},
+		Name:           customer.Name,
+		Balance:        customer.Balance,
+		HeldAmount:     customer.HeldAmount,
+		Currency:       customer.Currency,
+		Tier:           customer.Tier,
+		Frozen:         customer.Frozen,
+		FrozenIncoming: customer.FrozenIncoming,
+		CreatedAt:      customer.CreatedAt,
+	}
+}
+
 // DeleteCustomer implements CustomerUseCase.
 func (usecase *customerUseCase) DeleteCustomer(id string) error {
 	customer, err := usecase.GetCustomerById(id)
@@ -45,34 +64,6 @@ func (usecase *customerUseCase) DeleteCustomer(id string) error {
 
 }
 
-// AddCustomerBalance implements CustomerUseCase.
-func (usecase *customerUseCase) AddCustomerBalance(id string, amount int64) (model.CustomerResponse, error) {
-
-	customer, err := usecase.repo.AddCustomerBalance(id, amount)
-	if err != nil {
-		return model.CustomerResponse{}, err
-	}
-
-	user, err := usecase.userUseCase.GetUserById(customer.UserID)
-	if err != nil {
-		return model.CustomerResponse{}, err
-	}
-
-	customerResponse := model.CustomerResponse{
-		ID: customer.ID,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-		},
-		Name:      customer.Name,
-		Balance:   customer.Balance,
-		CreatedAt: customer.CreatedAt,
-	}
-	return customerResponse, err
-}
-
 // GetCustomer implements CustomerUseCase.
 func (usecase *customerUseCase) GetCustomerByUserId(userId string) (model.CustomerResponse, error) {
 	customer, err := usecase.repo.GetByUserId(userId)
@@ -85,18 +76,7 @@ func (usecase *customerUseCase) GetCustomerByUserId(userId string) (model.Custom
 		return model.CustomerResponse{}, fmt.Errorf("error getting user %v: %v", customer.UserID, err)
 	}
 
-	customerResponse := model.CustomerResponse{
-		ID: customer.ID,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-		},
-		Name:      customer.Name,
-		Balance:   customer.Balance,
-		CreatedAt: customer.CreatedAt,
-	}
+	customerResponse := newCustomerResponse(customer, user)
 	return customerResponse, err
 }
 
@@ -106,23 +86,12 @@ func (usecase *customerUseCase) GetCustomerById(id string) (model.CustomerRespon
 		return model.CustomerResponse{}, err
 	}
 
-	user, err := usecase.userUseCase.GetUserById(customer.ID)
+	user, err := usecase.userUseCase.GetUserById(customer.UserID)
 	if err != nil {
 		return model.CustomerResponse{}, err
 	}
 
-	customerResponse := model.CustomerResponse{
-		ID: customer.ID,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-		},
-		Name:      customer.Name,
-		Balance:   customer.Balance,
-		CreatedAt: customer.CreatedAt,
-	}
+	customerResponse := newCustomerResponse(customer, user)
 	return customerResponse, err
 }
 
@@ -139,18 +108,7 @@ func (usecase *customerUseCase) ListCustomer(params model.PaginationParams) ([]m
 		if err != nil {
 			return []model.CustomerResponse{}, err
 		}
-		customerResponse := model.CustomerResponse{
-			ID: customer.ID,
-			User: model.UserResponse{
-				ID:        user.ID,
-				Email:     user.Email,
-				Username:  user.Username,
-				CreatedAt: user.CreatedAt,
-			},
-			Name:      customer.Name,
-			Balance:   customer.Balance,
-			CreatedAt: customer.CreatedAt,
-		}
+		customerResponse := newCustomerResponse(customer, user)
 		customerResponses = append(customerResponses, customerResponse)
 	}
 
@@ -159,11 +117,19 @@ func (usecase *customerUseCase) ListCustomer(params model.PaginationParams) ([]m
 
 // RegisterNewCustomer implements CustomerUseCase.
 func (usecase *customerUseCase) RegisterNewCustomer(payload model.CreateCustomerRequest) (model.CustomerResponse, error) {
+	if payload.Currency == "" {
+		payload.Currency = model.DefaultCurrency
+	}
+	if !model.IsSupportedCurrency(payload.Currency) {
+		return model.CustomerResponse{}, common.ErrUnsupportedCurrency
+	}
+
 	customer := model.Customer{
-		ID:      common.GenerateID(),
-		UserID:  payload.UserID,
-		Name:    payload.Name,
-		Balance: 0,
+		ID:       common.GenerateID(),
+		UserID:   payload.UserID,
+		Name:     payload.Name,
+		Balance:  0,
+		Currency: payload.Currency,
 	}
 
 	user, err := usecase.userUseCase.GetUserById(customer.UserID)
@@ -176,17 +142,6 @@ func (usecase *customerUseCase) RegisterNewCustomer(payload model.CreateCustomer
 		return model.CustomerResponse{}, err
 	}
 
-	customerResponse := model.CustomerResponse{
-		ID: result.ID,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-		},
-		Name:      result.Name,
-		Balance:   result.Balance,
-		CreatedAt: result.CreatedAt,
-	}
+	customerResponse := newCustomerResponse(result, user)
 	return customerResponse, err
 }
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// maxEvidenceSize is the largest evidence file accepted, in bytes.
+const maxEvidenceSize = 10 << 20
+
+// evidenceContentTypes are the kinds of evidence files accepted.
+var evidenceContentTypes = []string{"application/pdf", "image/png", "image/jpeg", "text/plain"}
+
+type DisputeUseCase interface {
+	OpenDispute(payload model.OpenDisputeRequest) (model.Dispute, error)
+	// GetDispute returns a dispute of the customer of userId, or any dispute
+	// when userId is empty.
+	GetDispute(userId string, id string) (model.Dispute, error)
+	ListDisputes(userId string, status string, params model.PaginationParams) ([]model.Dispute, error)
+	RespondDispute(id string, payload model.RespondDisputeRequest) (model.Dispute, error)
+	ReviewDispute(id string) (model.Dispute, error)
+	ResolveDispute(id string, resolvedBy string, payload model.ResolveDisputeRequest) (model.Dispute, error)
+	AddEvidence(userId string, arg model.DisputeEvidence, file io.Reader) (model.DisputeEvidence, error)
+	GetEvidence(userId string, disputeId string, id string) (model.DisputeEvidence, error)
+	EscalateOverdueDisputes() (int, error)
+}
+
+type disputeUseCase struct {
+	repo           repository.DisputeRepository
+	customerUC     CustomerUseCase
+	evidencePath   string
+	filingWindow   time.Duration
+	responseWindow time.Duration
+}
+
+func NewDisputeUseCase(repo repository.DisputeRepository, customerUC CustomerUseCase, evidencePath string, filingWindow time.Duration, responseWindow time.Duration) DisputeUseCase {
+	return &disputeUseCase{
+		repo:           repo,
+		customerUC:     customerUC,
+		evidencePath:   evidencePath,
+		filingWindow:   filingWindow,
+		responseWindow: responseWindow,
+	}
+}
+
+// OpenDispute implements DisputeUseCase.
+func (usecase *disputeUseCase) OpenDispute(payload model.OpenDisputeRequest) (model.Dispute, error) {
+	if payload.Amount < 0 {
+		return model.Dispute{}, common.ErrInvalidAmount
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(payload.UserID)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+
+	now := time.Now()
+	return usecase.repo.Open(model.Dispute{
+		ID:            common.GenerateID(),
+		TransactionID: payload.TransactionID,
+		CustomerID:    customer.ID,
+		Reason:        payload.Reason,
+		Description:   payload.Description,
+		Amount:        payload.Amount,
+		ResponseDueAt: now.Add(usecase.responseWindow),
+	}, now.Add(-usecase.filingWindow))
+}
+
+// GetDispute implements DisputeUseCase.
+func (usecase *disputeUseCase) GetDispute(userId string, id string) (model.Dispute, error) {
+	dispute, err := usecase.repo.Get(id)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	if userId == "" {
+		return dispute, nil
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	if dispute.CustomerID != customer.ID {
+		return model.Dispute{}, fmt.Errorf("dispute %s: %w", id, common.ErrRecordNotFound)
+	}
+	return dispute, nil
+}
+
+// ListDisputes implements DisputeUseCase. Customers only see their own
+// disputes; admins see every dispute when userId is empty.
+func (usecase *disputeUseCase) ListDisputes(userId string, status string, params model.PaginationParams) ([]model.Dispute, error) {
+	customerId := ""
+	if userId != "" {
+		customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+		if err != nil {
+			return nil, err
+		}
+		customerId = customer.ID
+	}
+	return usecase.repo.List(customerId, status, params)
+}
+
+// RespondDispute implements DisputeUseCase.
+func (usecase *disputeUseCase) RespondDispute(id string, payload model.RespondDisputeRequest) (model.Dispute, error) {
+	return usecase.repo.Respond(id, payload.Response)
+}
+
+// ReviewDispute implements DisputeUseCase.
+func (usecase *disputeUseCase) ReviewDispute(id string) (model.Dispute, error) {
+	return usecase.repo.StartReview(id)
+}
+
+// ResolveDispute implements DisputeUseCase.
+func (usecase *disputeUseCase) ResolveDispute(id string, resolvedBy string, payload model.ResolveDisputeRequest) (model.Dispute, error) {
+	if payload.Outcome != model.DisputeStatusWon && payload.Outcome != model.DisputeStatusLost {
+		return model.Dispute{}, fmt.Errorf("%w: outcome must be won or lost", common.ErrInvalidDispute)
+	}
+	return usecase.repo.Resolve(id, payload.Outcome, payload.Note, resolvedBy, common.GenerateID())
+}
+
+// AddEvidence implements DisputeUseCase. The file is stored under the
+// evidence path, in a directory per dispute, until the dispute is decided.
+func (usecase *disputeUseCase) AddEvidence(userId string, arg model.DisputeEvidence, file io.Reader) (model.DisputeEvidence, error) {
+	if arg.Party != model.DisputePartyCustomer && arg.Party != model.DisputePartyMerchant {
+		return model.DisputeEvidence{}, fmt.Errorf("%w: party must be customer or merchant", common.ErrInvalidDispute)
+	}
+
+	dispute, err := usecase.GetDispute(userId, arg.DisputeID)
+	if err != nil {
+		return model.DisputeEvidence{}, err
+	}
+	if dispute.Status == model.DisputeStatusWon || dispute.Status == model.DisputeStatusLost {
+		return model.DisputeEvidence{}, fmt.Errorf("%w: dispute %s is %s", common.ErrInvalidStatus, dispute.ID, dispute.Status)
+	}
+
+	r := bufio.NewReader(file)
+	head, err := r.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return model.DisputeEvidence{}, err
+	}
+	arg.ContentType = http.DetectContentType(head)
+	if !evidenceContentTypeAllowed(arg.ContentType) {
+		return model.DisputeEvidence{}, fmt.Errorf("%w: evidence of type %s is not accepted", common.ErrInvalidDispute, arg.ContentType)
+	}
+
+	arg.ID = common.GenerateID()
+	arg.FileName = filepath.Base(arg.FileName)
+	dir := filepath.Join(usecase.evidencePath, "disputes", dispute.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return model.DisputeEvidence{}, err
+	}
+	arg.Path = filepath.Join(dir, arg.ID+strings.ToLower(filepath.Ext(arg.FileName)))
+
+	arg.Size, err = writeEvidenceFile(arg.Path, r)
+	if err != nil {
+		return model.DisputeEvidence{}, err
+	}
+
+	evidence, err := usecase.repo.AddEvidence(arg)
+	if err != nil {
+		os.Remove(arg.Path)
+		return model.DisputeEvidence{}, err
+	}
+	return evidence, nil
+}
+
+// GetEvidence implements DisputeUseCase.
+func (usecase *disputeUseCase) GetEvidence(userId string, disputeId string, id string) (model.DisputeEvidence, error) {
+	if _, err := usecase.GetDispute(userId, disputeId); err != nil {
+		return model.DisputeEvidence{}, err
+	}
+	return usecase.repo.GetEvidence(disputeId, id)
+}
+
+// EscalateOverdueDisputes implements DisputeUseCase. Disputes the merchant
+// did not respond to in time go to review without a response.
+func (usecase *disputeUseCase) EscalateOverdueDisputes() (int, error) {
+	ids, err := usecase.repo.ListOverdue(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, id := range ids {
+		_, err := usecase.repo.StartReview(id)
+		if errors.Is(err, common.ErrInvalidStatus) {
+			// responded or reviewed since it was listed
+			continue
+		}
+		if err != nil {
+			return escalated, fmt.Errorf("error escalating dispute %v: %v", id, err)
+		}
+		escalated++
+	}
+	return escalated, nil
+}
+
+func evidenceContentTypeAllowed(contentType string) bool {
+	for _, allowed := range evidenceContentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEvidenceFile copies an uploaded file to path, removing it again if it
+// is empty or larger than maxEvidenceSize.
+func writeEvidenceFile(path string, r io.Reader) (int64, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(file, io.LimitReader(r, maxEvidenceSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && (size == 0 || size > maxEvidenceSize) {
+		err = fmt.Errorf("%w: evidence must be between 1 byte and %d MB", common.ErrInvalidDispute, maxEvidenceSize>>20)
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return size, nil
+}
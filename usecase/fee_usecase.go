@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type FeeUseCase interface {
+	SetRule(payload model.CreateFeeRuleRequest) (model.FeeRule, error)
+	GetRule(id string) (model.FeeRule, error)
+	ListRules() ([]model.FeeRule, error)
+	DeleteRule(id string) error
+	QuoteFee(merchant model.Merchant, amount int64) (model.FeeQuote, error)
+}
+
+type feeUseCase struct {
+	repo       repository.FeeRuleRepository
+	merchantUC MerchantUseCase
+}
+
+func NewFeeUseCase(repo repository.FeeRuleRepository, merchantUC MerchantUseCase) FeeUseCase {
+	return &feeUseCase{
+		repo:       repo,
+		merchantUC: merchantUC,
+	}
+}
+
+// SetRule implements FeeUseCase.
+func (usecase *feeUseCase) SetRule(payload model.CreateFeeRuleRequest) (model.FeeRule, error) {
+	if (payload.MerchantID == "") == (payload.BusinessType == "") {
+		return model.FeeRule{}, fmt.Errorf("%w: set either a merchant id or a business type", common.ErrInvalidFeeRule)
+	}
+	if payload.MaxFee > 0 && payload.MaxFee < payload.MinFee {
+		return model.FeeRule{}, fmt.Errorf("%w: max fee is lower than min fee", common.ErrInvalidFeeRule)
+	}
+	if payload.MerchantID != "" {
+		if _, err := usecase.merchantUC.GetMerchant(payload.MerchantID); err != nil {
+			return model.FeeRule{}, err
+		}
+	}
+
+	tiers := append([]model.FeeTier{}, payload.Tiers...)
+	sort.Slice(tiers, func(a, b int) bool { return tiers[a].MinMonthlyVolume < tiers[b].MinMonthlyVolume })
+	for n := 1; n < len(tiers); n++ {
+		if tiers[n].MinMonthlyVolume == tiers[n-1].MinMonthlyVolume {
+			return model.FeeRule{}, fmt.Errorf("%w: duplicate tier for monthly volume %d", common.ErrInvalidFeeRule, tiers[n].MinMonthlyVolume)
+		}
+	}
+
+	rule := model.FeeRule{
+		ID:            common.GenerateID(),
+		MerchantID:    payload.MerchantID,
+		BusinessType:  payload.BusinessType,
+		PercentageBps: payload.PercentageBps,
+		FixedFee:      payload.FixedFee,
+		MinFee:        payload.MinFee,
+		MaxFee:        payload.MaxFee,
+		Tiers:         tiers,
+	}
+	return usecase.repo.Save(rule)
+}
+
+// GetRule implements FeeUseCase.
+func (usecase *feeUseCase) GetRule(id string) (model.FeeRule, error) {
+	return usecase.repo.Get(id)
+}
+
+// ListRules implements FeeUseCase.
+func (usecase *feeUseCase) ListRules() ([]model.FeeRule, error) {
+	return usecase.repo.List()
+}
+
+// DeleteRule implements FeeUseCase.
+func (usecase *feeUseCase) DeleteRule(id string) error {
+	return usecase.repo.Delete(id)
+}
+
+// QuoteFee implements FeeUseCase. Merchants without a rule pay no fee.
+func (usecase *feeUseCase) QuoteFee(merchant model.Merchant, amount int64) (model.FeeQuote, error) {
+	quote := model.FeeQuote{
+		MerchantID: merchant.ID,
+		Amount:     amount,
+		Net:        amount,
+		Currency:   merchant.Currency,
+	}
+
+	rule, err := usecase.repo.FindForMerchant(merchant.ID, merchant.BusinesType)
+	if errors.Is(err, common.ErrRecordNotFound) {
+		return quote, nil
+	}
+	if err != nil {
+		return model.FeeQuote{}, err
+	}
+
+	var volume int64
+	if len(rule.Tiers) > 0 {
+		now := time.Now()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		volume, err = usecase.repo.MonthlyVolume(merchant.ID, monthStart)
+		if err != nil {
+			return model.FeeQuote{}, err
+		}
+	}
+
+	quote.Fee = calculateFee(rule, amount, volume)
+	quote.Net = amount - quote.Fee
+	quote.FeeRuleID = rule.ID
+	return quote, nil
+}
+
+// calculateFee prices amount with the rule, or with the highest tier the
+// monthly volume reaches. The result is clamped to the rule's caps and never
+// exceeds the amount.
+func calculateFee(rule model.FeeRule, amount int64, monthlyVolume int64) int64 {
+	bps, fixed := rule.PercentageBps, rule.FixedFee
+	for _, tier := range rule.Tiers {
+		if monthlyVolume >= tier.MinMonthlyVolume {
+			bps, fixed = tier.PercentageBps, tier.FixedFee
+		}
+	}
+
+	// amount * bps / 10000, rounded half up
+	n := new(big.Int).Mul(big.NewInt(amount), big.NewInt(bps))
+	n.Add(n, big.NewInt(5000))
+	n.Quo(n, big.NewInt(10000))
+	fee := n.Int64() + fixed
+
+	if fee < rule.MinFee {
+		fee = rule.MinFee
+	}
+	if rule.MaxFee > 0 && fee > rule.MaxFee {
+		fee = rule.MaxFee
+	}
+	if fee > amount {
+		fee = amount
+	}
+	return fee
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type FraudUseCase interface {
+	ListRules() ([]model.FraudRule, error)
+	SetRule(rule string, payload model.SetFraudRuleRequest) (model.FraudRule, error)
+	// Screen evaluates the rules against a payment before it is stored. Legs
+	// are the legs of a split payment.
+	Screen(t model.Transaction, legs []model.Transaction) (model.FraudAssessment, error)
+	Hold(t model.Transaction, assessment model.FraudAssessment) (model.Transaction, error)
+	GetReview(id string) (model.FraudReview, error)
+	ListReviews(status string, params model.PaginationParams) ([]model.FraudReview, error)
+	ApproveReview(id string, reviewerId string, payload model.DecideFraudReviewRequest) (model.FraudReview, error)
+	RejectReview(id string, reviewerId string, payload model.DecideFraudReviewRequest) (model.FraudReview, error)
+}
+
+type fraudUseCase struct {
+	repo             repository.FraudRepository
+	fxRateUC         FxRateUseCase
+	authorizationTTL time.Duration
+	location         *time.Location
+}
+
+func NewFraudUseCase(repo repository.FraudRepository, fxRateUC FxRateUseCase, authorizationTTL time.Duration, location *time.Location) FraudUseCase {
+	return &fraudUseCase{
+		repo:             repo,
+		fxRateUC:         fxRateUC,
+		authorizationTTL: authorizationTTL,
+		location:         location,
+	}
+}
+
+// ListRules implements FraudUseCase.
+func (usecase *fraudUseCase) ListRules() ([]model.FraudRule, error) {
+	return usecase.repo.ListRules()
+}
+
+// SetRule implements FraudUseCase.
+func (usecase *fraudUseCase) SetRule(rule string, payload model.SetFraudRuleRequest) (model.FraudRule, error) {
+	switch rule {
+	case model.FraudRuleNewAccountLargeAmount:
+		if payload.AccountAgeHours <= 0 {
+			return model.FraudRule{}, fmt.Errorf("%w: account_age_hours must be greater than zero", common.ErrInvalidFraudRule)
+		}
+	case model.FraudRuleRapidRepeatMerchant:
+		if payload.WindowMinutes <= 0 || payload.MaxCount <= 0 {
+			return model.FraudRule{}, fmt.Errorf("%w: window_minutes and max_count must be greater than zero", common.ErrInvalidFraudRule)
+		}
+	case model.FraudRuleNearLimit:
+		if payload.LimitPercent <= 0 {
+			return model.FraudRule{}, fmt.Errorf("%w: limit_percent must be greater than zero", common.ErrInvalidFraudRule)
+		}
+	case model.FraudRuleUnusualHours:
+		if payload.StartHour == payload.EndHour {
+			return model.FraudRule{}, fmt.Errorf("%w: start_hour and end_hour must differ", common.ErrInvalidFraudRule)
+		}
+	default:
+		return model.FraudRule{}, fmt.Errorf("%w: unknown rule %s", common.ErrInvalidFraudRule, rule)
+	}
+
+	return usecase.repo.SaveRule(model.FraudRule{
+		Rule:            rule,
+		Action:          payload.Action,
+		Enabled:         *payload.Enabled,
+		MinAmount:       payload.MinAmount,
+		AccountAgeHours: payload.AccountAgeHours,
+		WindowMinutes:   payload.WindowMinutes,
+		MaxCount:        payload.MaxCount,
+		LimitPercent:    payload.LimitPercent,
+		StartHour:       payload.StartHour,
+		EndHour:         payload.EndHour,
+	})
+}
+
+// Screen implements FraudUseCase. The decision is the strictest action of
+// the rules that match, or allow when none does.
+func (usecase *fraudUseCase) Screen(t model.Transaction, legs []model.Transaction) (model.FraudAssessment, error) {
+	rules, err := usecase.repo.ListRules()
+	if err != nil {
+		return model.FraudAssessment{}, err
+	}
+
+	merchantIds := []string{}
+	since := time.Now()
+	for _, rule := range rules {
+		if rule.Enabled && rule.Rule == model.FraudRuleRapidRepeatMerchant {
+			since = since.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+			if t.ReceiverMerchantId != "" {
+				merchantIds = append(merchantIds, t.ReceiverMerchantId)
+			}
+			for _, leg := range legs {
+				merchantIds = append(merchantIds, leg.ReceiverMerchantId)
+			}
+		}
+	}
+	signals, err := usecase.repo.Signals(t.SenderCustomerId, merchantIds, since)
+	if err != nil {
+		return model.FraudAssessment{}, err
+	}
+
+	// Rule amounts are in the default currency.
+	amount, _, err := usecase.fxRateUC.Convert(t.SourceAmount, t.SourceCurrency, model.DefaultCurrency)
+	if err != nil {
+		return model.FraudAssessment{}, err
+	}
+
+	assessment := model.FraudAssessment{Decision: model.FraudDecisionAllow, Reasons: []model.FraudReason{}, PaymentCount: signals.PaymentCount}
+	now := time.Now().In(usecase.location)
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		detail, matched := matchFraudRule(rule, t, amount, merchantIds, signals, now)
+		if !matched {
+			continue
+		}
+		assessment.Reasons = append(assessment.Reasons, model.FraudReason{Rule: rule.Rule, Action: rule.Action, Detail: detail})
+		if rule.Action == model.FraudDecisionBlock || assessment.Decision == model.FraudDecisionAllow {
+			assessment.Decision = rule.Action
+		}
+	}
+	return assessment, nil
+}
+
+// matchFraudRule reports whether rule matches payment t and why. amount is
+// the payment in the default currency and now is the local time.
+func matchFraudRule(rule model.FraudRule, t model.Transaction, amount int64, merchantIds []string, signals model.FraudSignals, now time.Time) (string, bool) {
+	switch rule.Rule {
+	case model.FraudRuleNewAccountLargeAmount:
+		age := now.Sub(signals.AccountCreatedAt)
+		if age < time.Duration(rule.AccountAgeHours)*time.Hour && amount >= rule.MinAmount {
+			return fmt.Sprintf("amount %d from an account created %s ago", amount, age.Truncate(time.Minute)), true
+		}
+	case model.FraudRuleRapidRepeatMerchant:
+		for _, merchantId := range merchantIds {
+			if count := signals.MerchantPayments[merchantId]; count >= int64(rule.MaxCount) {
+				return fmt.Sprintf("%d payments to merchant %s in the last %d minutes", count, merchantId, rule.WindowMinutes), true
+			}
+		}
+	case model.FraudRuleNearLimit:
+		limits := []struct {
+			name  string
+			limit *int64
+			total int64
+		}{
+			{"max single amount", signals.Limits.MaxSingleAmount, t.SourceAmount},
+			{"daily amount", signals.Limits.DailyAmount, signals.Usage.DailyAmount + t.SourceAmount},
+			{"monthly amount", signals.Limits.MonthlyAmount, signals.Usage.MonthlyAmount + t.SourceAmount},
+		}
+		for _, l := range limits {
+			if l.limit != nil && l.total <= *l.limit && l.total*100 >= int64(rule.LimitPercent)*(*l.limit) {
+				return fmt.Sprintf("%d of the %s limit of %d", l.total, l.name, *l.limit), true
+			}
+		}
+	case model.FraudRuleUnusualHours:
+		hour := now.Hour()
+		inWindow := hour >= rule.StartHour && hour < rule.EndHour
+		if rule.StartHour > rule.EndHour {
+			// the window wraps around midnight
+			inWindow = hour >= rule.StartHour || hour < rule.EndHour
+		}
+		if inWindow && amount >= rule.MinAmount {
+			return fmt.Sprintf("amount %d at %s", amount, now.Format("15:04 MST")), true
+		}
+	}
+	return "", false
+}
+
+// Hold implements FraudUseCase.
+func (usecase *fraudUseCase) Hold(t model.Transaction, assessment model.FraudAssessment) (model.Transaction, error) {
+	return usecase.repo.Hold(t, model.FraudReview{
+		ID:      common.GenerateID(),
+		Reasons: assessment.Reasons,
+	})
+}
+
+// GetReview implements FraudUseCase.
+func (usecase *fraudUseCase) GetReview(id string) (model.FraudReview, error) {
+	return usecase.repo.GetReview(id)
+}
+
+// ListReviews implements FraudUseCase.
+func (usecase *fraudUseCase) ListReviews(status string, params model.PaginationParams) ([]model.FraudReview, error) {
+	return usecase.repo.ListReviews(status, params)
+}
+
+// ApproveReview implements FraudUseCase. An approved authorization can be
+// captured for the usual authorization period from now.
+func (usecase *fraudUseCase) ApproveReview(id string, reviewerId string, payload model.DecideFraudReviewRequest) (model.FraudReview, error) {
+	return usecase.repo.Approve(id, reviewerId, payload.Note, time.Now().Add(usecase.authorizationTTL))
+}
+
+// RejectReview implements FraudUseCase.
+func (usecase *fraudUseCase) RejectReview(id string, reviewerId string, payload model.DecideFraudReviewRequest) (model.FraudReview, error) {
+	return usecase.repo.Reject(id, reviewerId, payload.Note)
+}
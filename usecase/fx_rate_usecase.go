@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// decimalRate matches a plain positive decimal like 16250 or 0.0000615.
+var decimalRate = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+type FxRateUseCase interface {
+	SetRate(payload model.SetFxRateRequest) (model.FxRate, error)
+	ListRates() ([]model.FxRate, error)
+	// Convert converts an amount in minor units of from into minor units of
+	// to and returns the rate that was applied (units of to per unit of from).
+	Convert(amount int64, from string, to string) (int64, string, error)
+}
+
+type fxRateUseCase struct {
+	repo repository.FxRateRepository
+}
+
+func NewFxRateUseCase(repo repository.FxRateRepository) FxRateUseCase {
+	return &fxRateUseCase{repo: repo}
+}
+
+// SetRate implements FxRateUseCase.
+func (usecase *fxRateUseCase) SetRate(payload model.SetFxRateRequest) (model.FxRate, error) {
+	if !model.IsSupportedCurrency(payload.BaseCurrency) || !model.IsSupportedCurrency(payload.QuoteCurrency) {
+		return model.FxRate{}, common.ErrUnsupportedCurrency
+	}
+	if payload.BaseCurrency == payload.QuoteCurrency {
+		return model.FxRate{}, fmt.Errorf("%w: base and quote currency must differ", common.ErrInvalidFxRate)
+	}
+	// big.Rat also reads fractions and exponents, which a rate must not be.
+	if !decimalRate.MatchString(payload.Rate) {
+		return model.FxRate{}, fmt.Errorf("%w: rate must be a positive decimal number", common.ErrInvalidFxRate)
+	}
+	rate, ok := new(big.Rat).SetString(payload.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return model.FxRate{}, fmt.Errorf("%w: rate must be a positive decimal number", common.ErrInvalidFxRate)
+	}
+
+	return usecase.repo.Upsert(model.FxRate{
+		BaseCurrency:  payload.BaseCurrency,
+		QuoteCurrency: payload.QuoteCurrency,
+		Rate:          rate.FloatString(10),
+		UpdatedBy:     payload.UpdatedBy,
+	})
+}
+
+// ListRates implements FxRateUseCase.
+func (usecase *fxRateUseCase) ListRates() ([]model.FxRate, error) {
+	return usecase.repo.List()
+}
+
+// Convert implements FxRateUseCase. A missing direct rate falls back to the
+// inverse of the opposite pair.
+func (usecase *fxRateUseCase) Convert(amount int64, from string, to string) (int64, string, error) {
+	if from == to {
+		return amount, "", nil
+	}
+
+	var rate *big.Rat
+	stored, err := usecase.repo.Get(from, to)
+	switch {
+	case err == nil:
+		rate, _ = new(big.Rat).SetString(stored.Rate)
+	case errors.Is(err, common.ErrRecordNotFound):
+		inverse, err := usecase.repo.Get(to, from)
+		if errors.Is(err, common.ErrRecordNotFound) {
+			return 0, "", fmt.Errorf("%s to %s: %w", from, to, common.ErrFxRateNotFound)
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		rate, _ = new(big.Rat).SetString(inverse.Rate)
+		rate.Inv(rate)
+	default:
+		return 0, "", err
+	}
+
+	return convertMinorUnits(amount, rate, from, to), rate.FloatString(10), nil
+}
+
+// convertMinorUnits applies a major-unit rate to an amount in minor units,
+// rounding half away from zero.
+func convertMinorUnits(amount int64, rate *big.Rat, from string, to string) int64 {
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+	shift := model.CurrencyExponents[to] - model.CurrencyExponents[from]
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		v.Mul(v, scale)
+	} else {
+		v.Quo(v, scale)
+	}
+
+	num, den := v.Num(), v.Denom()
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	return q.Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
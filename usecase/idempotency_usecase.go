@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+)
+
+var (
+	ErrIdempotencyKeyConflict   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+type IdempotencyUseCase interface {
+	// Begin reserves the key for a new request. When the key was used
+	// before it returns the stored key and replay is true.
+	Begin(payload model.IdempotencyKey) (stored model.IdempotencyKey, replay bool, err error)
+	Complete(userId string, key string, statusCode int, body []byte) error
+	Release(userId string, key string) error
+	PurgeExpired() (int64, error)
+}
+
+type idempotencyUseCase struct {
+	repo repository.IdempotencyRepository
+}
+
+func NewIdempotencyUseCase(repo repository.IdempotencyRepository) IdempotencyUseCase {
+	return &idempotencyUseCase{repo: repo}
+}
+
+// Begin implements IdempotencyUseCase.
+func (usecase *idempotencyUseCase) Begin(payload model.IdempotencyKey) (model.IdempotencyKey, bool, error) {
+	stored, reserved, err := usecase.repo.Reserve(payload)
+	if err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+	if reserved {
+		return stored, false, nil
+	}
+
+	if stored.RequestPath != payload.RequestPath || stored.RequestHash != payload.RequestHash {
+		return model.IdempotencyKey{}, false, ErrIdempotencyKeyConflict
+	}
+	if stored.StatusCode == 0 {
+		return model.IdempotencyKey{}, false, ErrIdempotencyKeyInProgress
+	}
+	return stored, true, nil
+}
+
+// Complete implements IdempotencyUseCase.
+func (usecase *idempotencyUseCase) Complete(userId string, key string, statusCode int, body []byte) error {
+	return usecase.repo.Complete(userId, key, statusCode, body)
+}
+
+// Release implements IdempotencyUseCase.
+func (usecase *idempotencyUseCase) Release(userId string, key string) error {
+	return usecase.repo.Release(userId, key)
+}
+
+// PurgeExpired implements IdempotencyUseCase.
+func (usecase *idempotencyUseCase) PurgeExpired() (int64, error) {
+	return usecase.repo.DeleteExpired()
+}
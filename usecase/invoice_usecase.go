@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// defaultInvoiceTTL is how long an invoice stays payable when the merchant
+// does not set an expiry.
+const defaultInvoiceTTL = 7 * 24 * time.Hour
+
+const invoiceCodeLength = 10
+
+type InvoiceUseCase interface {
+	CreateInvoice(payload model.CreateInvoiceRequest) (model.Invoice, error)
+	GetInvoice(id string) (model.Invoice, error)
+	GetInvoiceByCode(code string) (model.Invoice, error)
+	ListInvoices(merchantId string, status string, params model.PaginationParams) ([]model.Invoice, error)
+	CancelInvoice(id string) (model.Invoice, error)
+	PayInvoice(userId string, code string) (model.Invoice, error)
+	ExpireInvoices() (int, error)
+}
+
+type invoiceUseCase struct {
+	repo          repository.InvoiceRepository
+	merchantUC    MerchantUseCase
+	transactionUC TransactionUseCase
+	baseURL       string
+}
+
+func NewInvoiceUseCase(repo repository.InvoiceRepository, merchantUC MerchantUseCase, transactionUC TransactionUseCase, baseURL string) InvoiceUseCase {
+	return &invoiceUseCase{
+		repo:          repo,
+		merchantUC:    merchantUC,
+		transactionUC: transactionUC,
+		baseURL:       strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// CreateInvoice implements InvoiceUseCase.
+func (usecase *invoiceUseCase) CreateInvoice(payload model.CreateInvoiceRequest) (model.Invoice, error) {
+	merchant, err := usecase.merchantUC.GetMerchant(payload.MerchantID)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	items := []model.InvoiceItem{}
+	var total int64
+	for _, item := range payload.Items {
+		if item.Quantity <= 0 || item.UnitPrice <= 0 {
+			return model.Invoice{}, common.ErrInvalidAmount
+		}
+		// both are positive, so these catch any int64 overflow
+		if item.Quantity > math.MaxInt64/item.UnitPrice {
+			return model.Invoice{}, fmt.Errorf("%w: item %q costs too much", common.ErrInvalidInvoice, item.Description)
+		}
+		amount := item.Quantity * item.UnitPrice
+		if total > math.MaxInt64-amount {
+			return model.Invoice{}, fmt.Errorf("%w: the items cost too much", common.ErrInvalidInvoice)
+		}
+		items = append(items, model.InvoiceItem{
+			ID:          common.GenerateID(),
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Amount:      amount,
+		})
+		total += amount
+	}
+	amount := payload.Amount
+	if len(items) > 0 {
+		if amount != 0 && amount != total {
+			return model.Invoice{}, fmt.Errorf("%w: amount %d does not match the item total %d", common.ErrInvalidAmount, amount, total)
+		}
+		amount = total
+	}
+	if amount <= 0 {
+		return model.Invoice{}, common.ErrInvalidAmount
+	}
+
+	expiresAt := time.Now().Add(defaultInvoiceTTL)
+	if payload.ExpiresAt != nil {
+		if !payload.ExpiresAt.After(time.Now()) {
+			return model.Invoice{}, fmt.Errorf("%w: expires_at must be in the future", common.ErrInvalidInvoice)
+		}
+		expiresAt = *payload.ExpiresAt
+	}
+
+	invoice, err := usecase.repo.Create(model.Invoice{
+		ID:          common.GenerateID(),
+		MerchantID:  merchant.ID,
+		Code:        common.GenerateCode(invoiceCodeLength),
+		Amount:      amount,
+		Description: payload.Description,
+		Items:       items,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	return usecase.withLink(invoice), nil
+}
+
+// GetInvoice implements InvoiceUseCase.
+func (usecase *invoiceUseCase) GetInvoice(id string) (model.Invoice, error) {
+	invoice, err := usecase.repo.Get(id)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	return usecase.withLink(invoice), nil
+}
+
+// GetInvoiceByCode implements InvoiceUseCase.
+func (usecase *invoiceUseCase) GetInvoiceByCode(code string) (model.Invoice, error) {
+	invoice, err := usecase.repo.GetByCode(strings.ToUpper(code))
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	return usecase.withLink(invoice), nil
+}
+
+// ListInvoices implements InvoiceUseCase.
+func (usecase *invoiceUseCase) ListInvoices(merchantId string, status string, params model.PaginationParams) ([]model.Invoice, error) {
+	switch status {
+	case "", model.InvoiceStatusOpen, model.InvoiceStatusInReview, model.InvoiceStatusPaid, model.InvoiceStatusExpired, model.InvoiceStatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: unknown invoice status %q", common.ErrInvalidInvoice, status)
+	}
+
+	invoices, err := usecase.repo.ListByMerchantId(merchantId, status, params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range invoices {
+		invoices[i] = usecase.withLink(invoices[i])
+	}
+	return invoices, nil
+}
+
+// CancelInvoice implements InvoiceUseCase.
+func (usecase *invoiceUseCase) CancelInvoice(id string) (model.Invoice, error) {
+	invoice, err := usecase.repo.Cancel(id)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	return usecase.withLink(invoice), nil
+}
+
+// PayInvoice implements InvoiceUseCase. The payment is a normal transaction
+// from the customer of userId to the merchant of the invoice.
+func (usecase *invoiceUseCase) PayInvoice(userId string, code string) (model.Invoice, error) {
+	invoice, err := usecase.repo.GetByCode(strings.ToUpper(code))
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	if invoice.Status != model.InvoiceStatusOpen {
+		return model.Invoice{}, fmt.Errorf("%w: invoice %s is %s", common.ErrInvalidStatus, invoice.ID, invoice.Status)
+	}
+
+	_, err = usecase.transactionUC.RegisterNewTransaction(model.CreateTransactionRequest{
+		UserId:             userId,
+		ReceiverMerchantId: invoice.MerchantID,
+		Amount:             invoice.Amount,
+		InvoiceID:          invoice.ID,
+	})
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	return usecase.GetInvoice(invoice.ID)
+}
+
+// ExpireInvoices implements InvoiceUseCase.
+func (usecase *invoiceUseCase) ExpireInvoices() (int, error) {
+	return usecase.repo.ExpireOverdue()
+}
+
+func (usecase *invoiceUseCase) withLink(invoice model.Invoice) model.Invoice {
+	invoice.PaymentLink = usecase.baseURL + "/api/v1/pay/" + invoice.Code
+	return invoice
+}
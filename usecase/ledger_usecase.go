@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+)
+
+type LedgerUseCase interface {
+	ListAccounts(ownerType string, ownerId string) ([]model.LedgerAccount, error)
+	ListAccountPostings(ownerType string, ownerId string, params model.PaginationParams) ([]model.Posting, error)
+	GetJournalEntry(id string) (model.JournalEntry, error)
+	RegisterAdjustment(payload model.CreateAdjustmentRequest) (model.JournalEntry, error)
+	VerifyBalances() ([]model.BalanceMismatch, error)
+}
+
+type ledgerUseCase struct {
+	repo repository.LedgerRepository
+}
+
+func NewLedgerUseCase(repo repository.LedgerRepository) LedgerUseCase {
+	return &ledgerUseCase{repo: repo}
+}
+
+// ListAccounts implements LedgerUseCase.
+func (usecase *ledgerUseCase) ListAccounts(ownerType string, ownerId string) ([]model.LedgerAccount, error) {
+	return usecase.repo.ListAccounts(ownerType, ownerId)
+}
+
+// ListAccountPostings implements LedgerUseCase.
+func (usecase *ledgerUseCase) ListAccountPostings(ownerType string, ownerId string, params model.PaginationParams) ([]model.Posting, error) {
+	return usecase.repo.ListPostings(ownerType, ownerId, params)
+}
+
+// GetJournalEntry implements LedgerUseCase.
+func (usecase *ledgerUseCase) GetJournalEntry(id string) (model.JournalEntry, error) {
+	return usecase.repo.GetEntry(id)
+}
+
+// RegisterAdjustment implements LedgerUseCase.
+func (usecase *ledgerUseCase) RegisterAdjustment(payload model.CreateAdjustmentRequest) (model.JournalEntry, error) {
+	if payload.Amount == 0 {
+		return model.JournalEntry{}, fmt.Errorf("adjustment amount must not be zero")
+	}
+	return usecase.repo.CreateAdjustment(payload)
+}
+
+// VerifyBalances implements LedgerUseCase.
+func (usecase *ledgerUseCase) VerifyBalances() ([]model.BalanceMismatch, error) {
+	return usecase.repo.ListBalanceMismatches()
+}
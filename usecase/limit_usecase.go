@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type LimitUseCase interface {
+	SetTierLimit(tier string, payload model.SetSpendingLimitRequest) (model.SpendingLimit, error)
+	ListTierLimits() ([]model.SpendingLimit, error)
+	SetCustomerLimit(customerId string, payload model.SetSpendingLimitRequest) (model.SpendingLimit, error)
+	DeleteCustomerLimit(customerId string) error
+	GetCustomerLimits(customerId string) (model.CustomerLimits, error)
+	GetMyLimits(userId string) (model.CustomerLimits, error)
+	SetCustomerTier(customerId string, payload model.SetCustomerTierRequest) (model.CustomerLimits, error)
+}
+
+type limitUseCase struct {
+	repo       repository.SpendingLimitRepository
+	customerUC CustomerUseCase
+}
+
+func NewLimitUseCase(repo repository.SpendingLimitRepository, customerUC CustomerUseCase) LimitUseCase {
+	return &limitUseCase{
+		repo:       repo,
+		customerUC: customerUC,
+	}
+}
+
+// SetTierLimit implements LimitUseCase. The limits apply to every customer of
+// the tier with a wallet in the currency.
+func (usecase *limitUseCase) SetTierLimit(tier string, payload model.SetSpendingLimitRequest) (model.SpendingLimit, error) {
+	if !model.IsCustomerTier(tier) {
+		return model.SpendingLimit{}, fmt.Errorf("%w: unknown tier %s", common.ErrInvalidLimit, tier)
+	}
+	if payload.Currency == "" {
+		payload.Currency = model.DefaultCurrency
+	}
+	if !model.IsSupportedCurrency(payload.Currency) {
+		return model.SpendingLimit{}, common.ErrUnsupportedCurrency
+	}
+	if err := validateSpendingLimit(payload); err != nil {
+		return model.SpendingLimit{}, err
+	}
+
+	return usecase.repo.SaveTierLimit(model.SpendingLimit{
+		ID:              common.GenerateID(),
+		Tier:            tier,
+		Currency:        payload.Currency,
+		MaxSingleAmount: payload.MaxSingleAmount,
+		DailyAmount:     payload.DailyAmount,
+		MonthlyAmount:   payload.MonthlyAmount,
+		HourlyCount:     payload.HourlyCount,
+	})
+}
+
+// ListTierLimits implements LimitUseCase.
+func (usecase *limitUseCase) ListTierLimits() ([]model.SpendingLimit, error) {
+	return usecase.repo.ListTierLimits()
+}
+
+// SetCustomerLimit implements LimitUseCase. Limits left empty in the
+// override fall back to the tier of the customer.
+func (usecase *limitUseCase) SetCustomerLimit(customerId string, payload model.SetSpendingLimitRequest) (model.SpendingLimit, error) {
+	customer, err := usecase.customerUC.GetCustomerById(customerId)
+	if err != nil {
+		return model.SpendingLimit{}, err
+	}
+	if payload.Currency != "" && payload.Currency != customer.Currency {
+		return model.SpendingLimit{}, common.ErrCurrencyMismatch
+	}
+	if err := validateSpendingLimit(payload); err != nil {
+		return model.SpendingLimit{}, err
+	}
+
+	return usecase.repo.SaveCustomerLimit(model.SpendingLimit{
+		ID:              common.GenerateID(),
+		CustomerID:      customer.ID,
+		MaxSingleAmount: payload.MaxSingleAmount,
+		DailyAmount:     payload.DailyAmount,
+		MonthlyAmount:   payload.MonthlyAmount,
+		HourlyCount:     payload.HourlyCount,
+	})
+}
+
+// DeleteCustomerLimit implements LimitUseCase.
+func (usecase *limitUseCase) DeleteCustomerLimit(customerId string) error {
+	return usecase.repo.DeleteCustomerLimit(customerId)
+}
+
+// GetCustomerLimits implements LimitUseCase.
+func (usecase *limitUseCase) GetCustomerLimits(customerId string) (model.CustomerLimits, error) {
+	return usecase.repo.GetCustomerLimits(customerId)
+}
+
+// GetMyLimits implements LimitUseCase.
+func (usecase *limitUseCase) GetMyLimits(userId string) (model.CustomerLimits, error) {
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return model.CustomerLimits{}, err
+	}
+	return usecase.repo.GetCustomerLimits(customer.ID)
+}
+
+// SetCustomerTier implements LimitUseCase.
+func (usecase *limitUseCase) SetCustomerTier(customerId string, payload model.SetCustomerTierRequest) (model.CustomerLimits, error) {
+	if !model.IsCustomerTier(payload.Tier) {
+		return model.CustomerLimits{}, fmt.Errorf("%w: unknown tier %s", common.ErrInvalidLimit, payload.Tier)
+	}
+	if err := usecase.repo.SetCustomerTier(customerId, payload.Tier); err != nil {
+		return model.CustomerLimits{}, err
+	}
+	return usecase.repo.GetCustomerLimits(customerId)
+}
+
+func validateSpendingLimit(payload model.SetSpendingLimitRequest) error {
+	if payload.MaxSingleAmount == nil && payload.DailyAmount == nil && payload.MonthlyAmount == nil && payload.HourlyCount == nil {
+		return fmt.Errorf("%w: set at least one limit", common.ErrInvalidLimit)
+	}
+	if payload.DailyAmount != nil && payload.MonthlyAmount != nil && *payload.MonthlyAmount < *payload.DailyAmount {
+		return fmt.Errorf("%w: monthly amount is lower than daily amount", common.ErrInvalidLimit)
+	}
+	if payload.MaxSingleAmount != nil && payload.DailyAmount != nil && *payload.DailyAmount < *payload.MaxSingleAmount {
+		return fmt.Errorf("%w: daily amount is lower than max single amount", common.ErrInvalidLimit)
+	}
+	return nil
+}
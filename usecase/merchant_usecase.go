@@ -40,12 +40,20 @@ func (usecase *merchantUseCase) ListMerchant(params model.PaginationParams) ([]m
 
 // RegisterNewMerchants implements MerchantUseCase.
 func (usecase *merchantUseCase) RegisterNewMerchant(payload model.CreateMerchantRequest) (model.Merchant, error) {
+	if payload.Currency == "" {
+		payload.Currency = model.DefaultCurrency
+	}
+	if !model.IsSupportedCurrency(payload.Currency) {
+		return model.Merchant{}, common.ErrUnsupportedCurrency
+	}
+
 	merchant := model.Merchant{
 		ID:          common.GenerateID(),
 		Name:        payload.Name,
 		Description: payload.Description,
 		BusinesType: payload.BusinesType,
 		Balance:     0,
+		Currency:    payload.Currency,
 	}
 
 	return usecase.repo.Create(merchant)
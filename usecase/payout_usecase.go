@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/bank"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type PayoutUseCase interface {
+	RequestPayout(payload model.CreatePayoutRequest) (model.Payout, error)
+	GetPayoutsByMerchantId(merchantId string, params model.PaginationParams) ([]model.Payout, error)
+	RunSettlement(date time.Time) (model.SettlementBatch, error)
+	SettlePendingPayouts() error
+	GetSettlementBatch(id string) (model.SettlementBatch, error)
+	ListSettlementBatches(params model.PaginationParams) ([]model.SettlementBatch, error)
+}
+
+type payoutUseCase struct {
+	repo           repository.PayoutRepository
+	provider       bank.Provider
+	settlementPath string
+}
+
+func NewPayoutUseCase(repo repository.PayoutRepository, provider bank.Provider, settlementPath string) PayoutUseCase {
+	return &payoutUseCase{
+		repo:           repo,
+		provider:       provider,
+		settlementPath: settlementPath,
+	}
+}
+
+// RequestPayout implements PayoutUseCase.
+func (usecase *payoutUseCase) RequestPayout(payload model.CreatePayoutRequest) (model.Payout, error) {
+	if payload.Amount <= 0 {
+		return model.Payout{}, common.ErrInvalidAmount
+	}
+
+	payout := model.Payout{
+		ID:            common.GenerateID(),
+		MerchantID:    payload.MerchantID,
+		Amount:        payload.Amount,
+		BankCode:      payload.BankCode,
+		AccountNumber: payload.AccountNumber,
+		AccountName:   payload.AccountName,
+	}
+	return usecase.repo.Create(payout)
+}
+
+// GetPayoutsByMerchantId implements PayoutUseCase.
+func (usecase *payoutUseCase) GetPayoutsByMerchantId(merchantId string, params model.PaginationParams) ([]model.Payout, error) {
+	return usecase.repo.ListByMerchantId(merchantId, params)
+}
+
+// RunSettlement implements PayoutUseCase. It batches the pending payouts
+// requested on date, sends them to the bank and writes the settlement file.
+func (usecase *payoutUseCase) RunSettlement(date time.Time) (model.SettlementBatch, error) {
+	batch, err := usecase.repo.CreateBatch(common.GenerateID(), date)
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	return usecase.processBatch(batch.ID)
+}
+
+// SettlePendingPayouts implements PayoutUseCase. Batches interrupted by an
+// earlier run are finished first, then every past day with pending payouts
+// gets its own batch.
+func (usecase *payoutUseCase) SettlePendingPayouts() error {
+	unfinished, err := usecase.repo.ListUnfinishedBatches()
+	if err != nil {
+		return err
+	}
+	for _, id := range unfinished {
+		if _, err := usecase.processBatch(id); err != nil {
+			return err
+		}
+	}
+
+	dates, err := usecase.repo.ListPendingDates(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, date := range dates {
+		if _, err := usecase.RunSettlement(date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processBatch sends every processing payout of a batch to the bank. When
+// the provider cannot give an outcome the batch is left unfinished so the
+// next run retries it with the same references.
+func (usecase *payoutUseCase) processBatch(id string) (model.SettlementBatch, error) {
+	batch, err := usecase.repo.GetBatch(id)
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+
+	for _, payout := range batch.Payouts {
+		if payout.Status != model.PayoutStatusProcessing {
+			continue
+		}
+		result, err := usecase.provider.Transfer(bank.TransferRequest{
+			Reference:     payout.ID,
+			BankCode:      payout.BankCode,
+			AccountNumber: payout.AccountNumber,
+			AccountName:   payout.AccountName,
+			Amount:        payout.Amount,
+			Currency:      payout.Currency,
+		})
+		if err != nil {
+			return model.SettlementBatch{}, fmt.Errorf("transfer payout %s: %w", payout.ID, err)
+		}
+		if result.Status == bank.TransferStatusSuccess {
+			_, err = usecase.repo.MarkPaid(payout.ID, result.ProviderReference)
+		} else {
+			_, err = usecase.repo.MarkFailed(payout.ID, result.FailureReason)
+		}
+		if err != nil {
+			return model.SettlementBatch{}, err
+		}
+	}
+
+	payouts, err := usecase.repo.ListByBatchId(id)
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	batch.Payouts = payouts
+	filePath, err := usecase.writeSettlementFile(batch)
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+
+	batch, err = usecase.repo.CompleteBatch(id, filePath)
+	if err != nil {
+		return model.SettlementBatch{}, err
+	}
+	batch.Payouts = payouts
+	return batch, nil
+}
+
+// writeSettlementFile writes the CSV finance reviews for a batch.
+func (usecase *payoutUseCase) writeSettlementFile(batch model.SettlementBatch) (string, error) {
+	if err := os.MkdirAll(usecase.settlementPath, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("settlement_%s_%s.csv", batch.SettlementDate.Format(time.DateOnly), batch.ID)
+	filePath := filepath.Join(usecase.settlementPath, name)
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"payout_id", "merchant_id", "amount", "currency", "bank_code", "account_number", "account_name", "status", "provider_reference", "failure_reason", "requested_at"})
+	for _, p := range batch.Payouts {
+		w.Write([]string{
+			p.ID,
+			p.MerchantID,
+			strconv.FormatInt(p.Amount, 10),
+			p.Currency,
+			p.BankCode,
+			p.AccountNumber,
+			p.AccountName,
+			p.Status,
+			p.ProviderReference,
+			p.FailureReason,
+			p.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return filePath, file.Close()
+}
+
+// GetSettlementBatch implements PayoutUseCase.
+func (usecase *payoutUseCase) GetSettlementBatch(id string) (model.SettlementBatch, error) {
+	return usecase.repo.GetBatch(id)
+}
+
+// ListSettlementBatches implements PayoutUseCase.
+func (usecase *payoutUseCase) ListSettlementBatches(params model.PaginationParams) ([]model.SettlementBatch, error) {
+	return usecase.repo.ListBatches(params)
+}
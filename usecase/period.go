@@ -0,0 +1,26 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// parsePeriod turns the days from and to, both inclusive and given as
+// YYYY-MM-DD in loc, into the period [start, end) of at most maxDays days.
+func parsePeriod(from string, to string, loc *time.Location, maxDays int) (start time.Time, end time.Time, err error) {
+	start, err = time.ParseInLocation(time.DateOnly, from, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a date like 2006-01-02", common.ErrInvalidPeriod)
+	}
+	last, err := time.ParseInLocation(time.DateOnly, to, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a date like 2006-01-02", common.ErrInvalidPeriod)
+	}
+	end = last.AddDate(0, 0, 1)
+	if !end.After(start) || end.After(start.AddDate(0, 0, maxDays)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to, and a period covers at most %d days", common.ErrInvalidPeriod, maxDays)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type PromoUseCase interface {
+	CreateCampaign(payload model.PromoCampaignRequest) (model.PromoCampaign, error)
+	UpdateCampaign(id string, payload model.PromoCampaignRequest) (model.PromoCampaign, error)
+	GetCampaign(id string) (model.PromoCampaign, error)
+	ListCampaigns(params model.PaginationParams) ([]model.PromoCampaign, error)
+	EndCampaign(id string) (model.PromoCampaign, error)
+	ListRedemptions(id string, params model.PaginationParams) ([]model.PromoRedemption, error)
+	// Apply works out the reward of a promo code for a payment of amount to
+	// merchant. The redemption is recorded when the payment is stored.
+	Apply(code string, customerId string, merchant model.Merchant, amount int64) (*model.PromoRedemption, error)
+	CreditDueCashback() (int, error)
+}
+
+type promoUseCase struct {
+	repo          repository.PromoRepository
+	cashbackDelay time.Duration
+}
+
+func NewPromoUseCase(repo repository.PromoRepository, cashbackDelay time.Duration) PromoUseCase {
+	return &promoUseCase{
+		repo:          repo,
+		cashbackDelay: cashbackDelay,
+	}
+}
+
+// CreateCampaign implements PromoUseCase. Codes are case-insensitive and
+// stored upper-case.
+func (usecase *promoUseCase) CreateCampaign(payload model.PromoCampaignRequest) (model.PromoCampaign, error) {
+	campaign, err := newPromoCampaign(payload)
+	if err != nil {
+		return model.PromoCampaign{}, err
+	}
+	if _, err := usecase.repo.GetByCode(campaign.Code); err == nil {
+		return model.PromoCampaign{}, fmt.Errorf("%w: promo code %s already exists", common.ErrInvalidPromo, campaign.Code)
+	} else if !errors.Is(err, common.ErrRecordNotFound) {
+		return model.PromoCampaign{}, err
+	}
+
+	campaign.ID = common.GenerateID()
+	return usecase.repo.Create(campaign)
+}
+
+// UpdateCampaign implements PromoUseCase.
+func (usecase *promoUseCase) UpdateCampaign(id string, payload model.PromoCampaignRequest) (model.PromoCampaign, error) {
+	campaign, err := newPromoCampaign(payload)
+	if err != nil {
+		return model.PromoCampaign{}, err
+	}
+	if existing, err := usecase.repo.GetByCode(campaign.Code); err == nil && existing.ID != id {
+		return model.PromoCampaign{}, fmt.Errorf("%w: promo code %s already exists", common.ErrInvalidPromo, campaign.Code)
+	} else if err != nil && !errors.Is(err, common.ErrRecordNotFound) {
+		return model.PromoCampaign{}, err
+	}
+
+	campaign.ID = id
+	return usecase.repo.Update(campaign)
+}
+
+// GetCampaign implements PromoUseCase.
+func (usecase *promoUseCase) GetCampaign(id string) (model.PromoCampaign, error) {
+	return usecase.repo.Get(id)
+}
+
+// ListCampaigns implements PromoUseCase.
+func (usecase *promoUseCase) ListCampaigns(params model.PaginationParams) ([]model.PromoCampaign, error) {
+	return usecase.repo.List(params)
+}
+
+// EndCampaign implements PromoUseCase.
+func (usecase *promoUseCase) EndCampaign(id string) (model.PromoCampaign, error) {
+	return usecase.repo.End(id)
+}
+
+// ListRedemptions implements PromoUseCase.
+func (usecase *promoUseCase) ListRedemptions(id string, params model.PaginationParams) ([]model.PromoRedemption, error) {
+	if _, err := usecase.repo.Get(id); err != nil {
+		return nil, err
+	}
+	return usecase.repo.ListRedemptions(id, params)
+}
+
+// Apply implements PromoUseCase. The usage caps are checked again when the
+// redemption is recorded, so concurrent payments cannot exceed them.
+func (usecase *promoUseCase) Apply(code string, customerId string, merchant model.Merchant, amount int64) (*model.PromoRedemption, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	campaign, err := usecase.repo.GetByCode(code)
+	if errors.Is(err, common.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: promo code %s does not exist", common.ErrInvalidPromo, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !campaign.Active || now.Before(campaign.StartsAt) || (campaign.EndsAt != nil && !now.Before(*campaign.EndsAt)) {
+		return nil, fmt.Errorf("%w: promo code %s is not active", common.ErrInvalidPromo, code)
+	}
+	if campaign.Currency != merchant.Currency {
+		return nil, fmt.Errorf("%w: promo code %s only applies to payments in %s", common.ErrInvalidPromo, code, campaign.Currency)
+	}
+	if amount < campaign.MinSpend {
+		return nil, fmt.Errorf("%w: promo code %s needs a minimum spend of %d", common.ErrInvalidPromo, code, campaign.MinSpend)
+	}
+	if !promoEligible(campaign, merchant) {
+		return nil, fmt.Errorf("%w: promo code %s does not apply to merchant %s", common.ErrInvalidPromo, code, merchant.ID)
+	}
+	if campaign.MaxUses > 0 && campaign.UsedCount >= campaign.MaxUses {
+		return nil, fmt.Errorf("%w: promo code %s is used up", common.ErrInvalidPromo, code)
+	}
+	if campaign.MaxUsesPerCustomer > 0 {
+		used, err := usecase.repo.CountRedemptions(campaign.ID, customerId)
+		if err != nil {
+			return nil, err
+		}
+		if used >= campaign.MaxUsesPerCustomer {
+			return nil, fmt.Errorf("%w: promo code %s was already used the maximum number of times", common.ErrInvalidPromo, code)
+		}
+	}
+
+	reward := promoReward(campaign, amount)
+	if reward <= 0 {
+		return nil, fmt.Errorf("%w: promo code %s gives no reward for this payment", common.ErrInvalidPromo, code)
+	}
+	if campaign.RewardType == model.PromoRewardDiscount && reward >= amount {
+		return nil, fmt.Errorf("%w: promo code %s cannot cover the whole payment", common.ErrInvalidPromo, code)
+	}
+
+	return &model.PromoRedemption{
+		ID:         common.GenerateID(),
+		CampaignID: campaign.ID,
+		Code:       campaign.Code,
+		CustomerID: customerId,
+		RewardType: campaign.RewardType,
+		Amount:     reward,
+		Currency:   campaign.Currency,
+	}, nil
+}
+
+// CreditDueCashback implements PromoUseCase. Cashback is credited once the
+// payment has had the cashback delay to settle.
+func (usecase *promoUseCase) CreditDueCashback() (int, error) {
+	ids, err := usecase.repo.ListDueCashback(time.Now().Add(-usecase.cashbackDelay))
+	if err != nil {
+		return 0, err
+	}
+
+	credited := 0
+	for _, id := range ids {
+		redemption, err := usecase.repo.CreditCashback(id)
+		if errors.Is(err, common.ErrInvalidStatus) || errors.Is(err, common.ErrWalletFrozen) {
+			// decided since it was listed, or waiting for the freeze to lift
+			continue
+		}
+		if err != nil {
+			return credited, fmt.Errorf("error crediting cashback %v: %v", id, err)
+		}
+		if redemption.Status == model.PromoRedemptionStatusCredited {
+			credited++
+		}
+	}
+	return credited, nil
+}
+
+func newPromoCampaign(payload model.PromoCampaignRequest) (model.PromoCampaign, error) {
+	if payload.Currency == "" {
+		payload.Currency = model.DefaultCurrency
+	}
+	if !model.IsSupportedCurrency(payload.Currency) {
+		return model.PromoCampaign{}, common.ErrUnsupportedCurrency
+	}
+	if payload.PercentageBps == 0 && payload.FixedAmount == 0 {
+		return model.PromoCampaign{}, fmt.Errorf("%w: percentage_bps or fixed_amount must be set", common.ErrInvalidPromo)
+	}
+
+	startsAt := time.Now()
+	if payload.StartsAt != nil {
+		startsAt = *payload.StartsAt
+	}
+	if payload.EndsAt != nil && !payload.EndsAt.After(startsAt) {
+		return model.PromoCampaign{}, fmt.Errorf("%w: ends_at must be after starts_at", common.ErrInvalidPromo)
+	}
+	active := true
+	if payload.Active != nil {
+		active = *payload.Active
+	}
+
+	merchantIds := payload.MerchantIDs
+	if merchantIds == nil {
+		merchantIds = []string{}
+	}
+	businessTypes := payload.BusinessTypes
+	if businessTypes == nil {
+		businessTypes = []string{}
+	}
+
+	return model.PromoCampaign{
+		Code:               strings.ToUpper(payload.Code),
+		Name:               payload.Name,
+		RewardType:         payload.RewardType,
+		Currency:           payload.Currency,
+		PercentageBps:      payload.PercentageBps,
+		FixedAmount:        payload.FixedAmount,
+		MaxReward:          payload.MaxReward,
+		MinSpend:           payload.MinSpend,
+		MaxUses:            payload.MaxUses,
+		MaxUsesPerCustomer: payload.MaxUsesPerCustomer,
+		MerchantIDs:        merchantIds,
+		BusinessTypes:      businessTypes,
+		StartsAt:           startsAt,
+		EndsAt:             payload.EndsAt,
+		Active:             active,
+	}, nil
+}
+
+// promoEligible reports whether merchant is listed by campaign, by id or by
+// business type. A campaign that lists neither applies to every merchant.
+func promoEligible(campaign model.PromoCampaign, merchant model.Merchant) bool {
+	if len(campaign.MerchantIDs) == 0 && len(campaign.BusinessTypes) == 0 {
+		return true
+	}
+	for _, id := range campaign.MerchantIDs {
+		if id == merchant.ID {
+			return true
+		}
+	}
+	for _, businessType := range campaign.BusinessTypes {
+		if strings.EqualFold(businessType, merchant.BusinesType) {
+			return true
+		}
+	}
+	return false
+}
+
+func promoReward(campaign model.PromoCampaign, amount int64) int64 {
+	// amount * bps / 10000, rounded half up
+	n := new(big.Int).Mul(big.NewInt(amount), big.NewInt(campaign.PercentageBps))
+	n.Add(n, big.NewInt(5000))
+	n.Quo(n, big.NewInt(10000))
+	reward := n.Int64() + campaign.FixedAmount
+	if campaign.MaxReward > 0 && reward > campaign.MaxReward {
+		reward = campaign.MaxReward
+	}
+	return reward
+}
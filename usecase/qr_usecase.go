@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/emvco"
+	"github.com/albar2305/payment-app/utils/qrcode"
+)
+
+// qrGloballyUniqueID identifies this service in the merchant account template
+// of the payloads it issues.
+const qrGloballyUniqueID = "ID.CO.PAYMENTAPP"
+
+// qrMerchantCategory is the "other retail" category code; merchants do not
+// have their own category code yet.
+const qrMerchantCategory = "5999"
+
+// qrImageScale is the number of pixels per QR module in rendered images.
+const qrImageScale = 8
+
+type QRUseCase interface {
+	MerchantQR(merchantId string) (model.QRCode, error)
+	InvoiceQR(invoiceId string) (model.QRCode, error)
+	RenderPNG(code model.QRCode) ([]byte, error)
+	ParseQR(payload string) (model.QRPaymentPreview, error)
+}
+
+type qrUseCase struct {
+	merchantUC   MerchantUseCase
+	invoiceUC    InvoiceUseCase
+	merchantCity string
+	countryCode  string
+}
+
+func NewQRUseCase(merchantUC MerchantUseCase, invoiceUC InvoiceUseCase, merchantCity string, countryCode string) QRUseCase {
+	return &qrUseCase{
+		merchantUC:   merchantUC,
+		invoiceUC:    invoiceUC,
+		merchantCity: merchantCity,
+		countryCode:  countryCode,
+	}
+}
+
+// MerchantQR implements QRUseCase.
+func (usecase *qrUseCase) MerchantQR(merchantId string) (model.QRCode, error) {
+	merchant, err := usecase.merchantUC.GetMerchant(merchantId)
+	if err != nil {
+		return model.QRCode{}, err
+	}
+
+	payload, err := usecase.encode(merchant, emvco.PointOfInitiationStatic, "", "")
+	if err != nil {
+		return model.QRCode{}, err
+	}
+	return model.QRCode{
+		Type:       model.QRTypeStatic,
+		MerchantID: merchant.ID,
+		Payload:    payload,
+	}, nil
+}
+
+// InvoiceQR implements QRUseCase. Only open invoices have a code.
+func (usecase *qrUseCase) InvoiceQR(invoiceId string) (model.QRCode, error) {
+	invoice, err := usecase.invoiceUC.GetInvoice(invoiceId)
+	if err != nil {
+		return model.QRCode{}, err
+	}
+	if invoice.Status != model.InvoiceStatusOpen {
+		return model.QRCode{}, fmt.Errorf("%w: invoice %s is %s", common.ErrInvalidStatus, invoice.ID, invoice.Status)
+	}
+	merchant, err := usecase.merchantUC.GetMerchant(invoice.MerchantID)
+	if err != nil {
+		return model.QRCode{}, err
+	}
+
+	amount := emvco.FormatAmount(invoice.Amount, model.CurrencyExponents[invoice.Currency])
+	payload, err := usecase.encode(merchant, emvco.PointOfInitiationDynamic, amount, invoice.Code)
+	if err != nil {
+		return model.QRCode{}, err
+	}
+	return model.QRCode{
+		Type:       model.QRTypeDynamic,
+		MerchantID: merchant.ID,
+		InvoiceID:  invoice.ID,
+		Payload:    payload,
+	}, nil
+}
+
+// RenderPNG implements QRUseCase.
+func (usecase *qrUseCase) RenderPNG(code model.QRCode) ([]byte, error) {
+	symbol, err := qrcode.Encode([]byte(code.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding QR code: %v", err)
+	}
+	return symbol.PNG(qrImageScale)
+}
+
+// ParseQR implements QRUseCase. It only previews the payment; nothing is
+// charged until the customer confirms it.
+func (usecase *qrUseCase) ParseQR(payload string) (model.QRPaymentPreview, error) {
+	p, err := emvco.Parse(payload)
+	if err != nil {
+		return model.QRPaymentPreview{}, fmt.Errorf("%w: %v", common.ErrInvalidQRPayload, err)
+	}
+	if p.GloballyUniqueID != qrGloballyUniqueID || p.MerchantID == "" {
+		return model.QRPaymentPreview{}, fmt.Errorf("%w: not issued by this service", common.ErrInvalidQRPayload)
+	}
+
+	merchant, err := usecase.merchantUC.GetMerchant(p.MerchantID)
+	if err != nil {
+		return model.QRPaymentPreview{}, err
+	}
+	currency, ok := emvco.AlphaCurrency(p.Currency)
+	if !ok || currency != merchant.Currency {
+		return model.QRPaymentPreview{}, fmt.Errorf("%w: currency %q does not match the merchant", common.ErrInvalidQRPayload, p.Currency)
+	}
+
+	preview := model.QRPaymentPreview{
+		Type:         model.QRTypeStatic,
+		MerchantID:   merchant.ID,
+		MerchantName: merchant.Name,
+		Currency:     merchant.Currency,
+	}
+	if p.PointOfInitiation != emvco.PointOfInitiationDynamic {
+		return preview, nil
+	}
+
+	preview.Type = model.QRTypeDynamic
+	preview.Amount, err = emvco.ParseAmount(p.Amount, model.CurrencyExponents[currency])
+	if err != nil {
+		return model.QRPaymentPreview{}, fmt.Errorf("%w: %v", common.ErrInvalidQRPayload, err)
+	}
+	invoice, err := usecase.invoiceUC.GetInvoiceByCode(p.BillNumber)
+	if errors.Is(err, common.ErrRecordNotFound) {
+		return model.QRPaymentPreview{}, fmt.Errorf("%w: unknown bill number %q", common.ErrInvalidQRPayload, p.BillNumber)
+	}
+	if err != nil {
+		return model.QRPaymentPreview{}, err
+	}
+	if invoice.MerchantID != merchant.ID || invoice.Amount != preview.Amount {
+		return model.QRPaymentPreview{}, fmt.Errorf("%w: payload does not match invoice %s", common.ErrInvalidQRPayload, invoice.Code)
+	}
+	preview.InvoiceCode = invoice.Code
+	preview.InvoiceStatus = invoice.Status
+	preview.PaymentLink = invoice.PaymentLink
+	return preview, nil
+}
+
+func (usecase *qrUseCase) encode(merchant model.Merchant, pointOfInitiation string, amount string, billNumber string) (string, error) {
+	currency, ok := emvco.NumericCurrency(merchant.Currency)
+	if !ok {
+		return "", common.ErrUnsupportedCurrency
+	}
+	return emvco.Encode(emvco.Payload{
+		PointOfInitiation: pointOfInitiation,
+		GloballyUniqueID:  qrGloballyUniqueID,
+		MerchantID:        merchant.ID,
+		MerchantCategory:  qrMerchantCategory,
+		Currency:          currency,
+		Amount:            amount,
+		CountryCode:       usecase.countryCode,
+		MerchantName:      truncateRunes(strings.ToUpper(merchant.Name), 25),
+		MerchantCity:      truncateRunes(strings.ToUpper(usecase.merchantCity), 15),
+		BillNumber:        billNumber,
+	})
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
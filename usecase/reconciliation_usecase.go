@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type ReconciliationUseCase interface {
+	RunReconciliation() (model.ReconciliationReport, error)
+	GetLatestReport() (model.ReconciliationReport, error)
+}
+
+type reconciliationUseCase struct {
+	repo repository.ReconciliationRepository
+}
+
+func NewReconciliationUseCase(repo repository.ReconciliationRepository) ReconciliationUseCase {
+	return &reconciliationUseCase{
+		repo: repo,
+	}
+}
+
+// RunReconciliation implements ReconciliationUseCase.
+func (usecase *reconciliationUseCase) RunReconciliation() (model.ReconciliationReport, error) {
+	startedAt := time.Now()
+	mismatches, checked, err := usecase.repo.Reconcile()
+	if err != nil {
+		return model.ReconciliationReport{}, err
+	}
+
+	report := model.ReconciliationReport{
+		ID:             common.GenerateID(),
+		WalletsChecked: checked,
+		MismatchCount:  len(mismatches),
+		Mismatches:     mismatches,
+		StartedAt:      startedAt,
+		FinishedAt:     time.Now(),
+	}
+	return usecase.repo.Save(report)
+}
+
+// GetLatestReport implements ReconciliationUseCase.
+func (usecase *reconciliationUseCase) GetLatestReport() (model.ReconciliationReport, error) {
+	return usecase.repo.GetLatest()
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type RefundUseCase interface {
+	RegisterNewRefund(payload model.CreateRefundRequest) (model.Refund, error)
+	// GetRefundHistory lists the refunds of a transaction. A non-empty userId
+	// limits it to the payments of that user.
+	GetRefundHistory(userId string, transactionId string) (model.RefundHistory, error)
+}
+
+type refundUseCase struct {
+	repo          repository.RefundRepository
+	transactionUC TransactionUseCase
+}
+
+func NewRefundUseCase(repo repository.RefundRepository, transactionUC TransactionUseCase) RefundUseCase {
+	return &refundUseCase{
+		repo:          repo,
+		transactionUC: transactionUC,
+	}
+}
+
+// RegisterNewRefund implements RefundUseCase.
+func (usecase *refundUseCase) RegisterNewRefund(payload model.CreateRefundRequest) (model.Refund, error) {
+	if payload.Amount < 0 {
+		return model.Refund{}, common.ErrInvalidAmount
+	}
+
+	refund := model.Refund{
+		ID:            common.GenerateID(),
+		TransactionID: payload.TransactionID,
+		Amount:        payload.Amount,
+		Reason:        payload.Reason,
+		CreatedBy:     payload.CreatedBy,
+	}
+	return usecase.repo.Create(refund)
+}
+
+// GetRefundHistory implements RefundUseCase.
+func (usecase *refundUseCase) GetRefundHistory(userId string, transactionId string) (model.RefundHistory, error) {
+	detail, err := usecase.transactionUC.GetTransactionDetail(userId, transactionId, model.TransactionExpand{})
+	if err != nil {
+		return model.RefundHistory{}, err
+	}
+
+	refunds, err := usecase.repo.ListByTransactionId(transactionId)
+	if err != nil {
+		return model.RefundHistory{}, err
+	}
+
+	return model.RefundHistory{
+		Transaction: detail.Transaction,
+		Refunds:     refunds,
+	}, nil
+}
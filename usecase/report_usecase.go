@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// maxReportDays is the longest period one sales report may cover.
+const maxReportDays = 366
+
+type ReportUseCase interface {
+	SalesReport(arg model.SalesReportRequest) (model.SalesReport, error)
+	WriteSalesReportCSV(w io.Writer, report model.SalesReport) error
+}
+
+type reportUseCase struct {
+	repo       repository.ReportRepository
+	merchantUC MerchantUseCase
+	location   *time.Location
+}
+
+func NewReportUseCase(repo repository.ReportRepository, merchantUC MerchantUseCase, location *time.Location) ReportUseCase {
+	return &reportUseCase{
+		repo:       repo,
+		merchantUC: merchantUC,
+		location:   location,
+	}
+}
+
+// SalesReport implements ReportUseCase. The interval defaults to a day and the
+// time zone to the configured one.
+func (usecase *reportUseCase) SalesReport(arg model.SalesReportRequest) (model.SalesReport, error) {
+	if arg.Interval == "" {
+		arg.Interval = model.ReportIntervalDay
+	}
+	switch arg.Interval {
+	case model.ReportIntervalDay, model.ReportIntervalWeek, model.ReportIntervalMonth:
+	default:
+		return model.SalesReport{}, fmt.Errorf("%w: interval must be day, week or month", common.ErrInvalidReport)
+	}
+
+	location := usecase.location
+	if arg.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(arg.TimeZone)
+		// The database knows IANA names only, not the zone of this machine.
+		if err != nil || location.String() == "Local" {
+			return model.SalesReport{}, fmt.Errorf("%w: unknown time zone %q", common.ErrInvalidReport, arg.TimeZone)
+		}
+	}
+
+	start, end, err := parsePeriod(arg.From, arg.To, location, maxReportDays)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+
+	if arg.MerchantID != "" {
+		if _, err := usecase.merchantUC.GetMerchant(arg.MerchantID); err != nil {
+			return model.SalesReport{}, err
+		}
+	}
+
+	report, err := usecase.repo.SalesReport(model.SalesReport{
+		MerchantID:  arg.MerchantID,
+		Interval:    arg.Interval,
+		TimeZone:    location.String(),
+		PeriodStart: start,
+		PeriodEnd:   end,
+	})
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+
+	totals := map[[2]string]*model.SalesReportRow{}
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		finishSalesReportRow(row)
+
+		key := [2]string{row.BusinesType, row.Currency}
+		total, ok := totals[key]
+		if !ok {
+			total = &model.SalesReportRow{BusinesType: row.BusinesType, Currency: row.Currency}
+			totals[key] = total
+		}
+		total.Count += row.Count
+		total.Gross += row.Gross
+		total.RefundCount += row.RefundCount
+		total.Refunds += row.Refunds
+		total.Fees += row.Fees
+	}
+
+	report.Totals = []model.SalesReportRow{}
+	for _, total := range totals {
+		finishSalesReportRow(total)
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		a, b := report.Totals[i], report.Totals[j]
+		if a.BusinesType != b.BusinesType {
+			return a.BusinesType < b.BusinesType
+		}
+		return a.Currency < b.Currency
+	})
+	return report, nil
+}
+
+// finishSalesReportRow works out the amounts that follow from the sums.
+func finishSalesReportRow(row *model.SalesReportRow) {
+	if row.Count > 0 {
+		row.AverageTicket = row.Gross / row.Count
+	}
+	row.Net = row.Gross - row.Refunds - row.Fees
+}
+
+// WriteSalesReportCSV implements ReportUseCase. Bucket rows come first, dated
+// in the report time zone, followed by the totals.
+func (usecase *reportUseCase) WriteSalesReportCSV(w io.Writer, report model.SalesReport) error {
+	location, err := time.LoadLocation(report.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"period_start", "busines_type", "currency", "count", "gross", "average_ticket", "refund_count", "refunds", "fees", "net"})
+	write := func(period string, row model.SalesReportRow) {
+		cw.Write([]string{
+			period,
+			row.BusinesType,
+			row.Currency,
+			strconv.FormatInt(row.Count, 10),
+			strconv.FormatInt(row.Gross, 10),
+			strconv.FormatInt(row.AverageTicket, 10),
+			strconv.FormatInt(row.RefundCount, 10),
+			strconv.FormatInt(row.Refunds, 10),
+			strconv.FormatInt(row.Fees, 10),
+			strconv.FormatInt(row.Net, 10),
+		})
+	}
+	for _, row := range report.Rows {
+		write(row.PeriodStart.In(location).Format(time.DateOnly), row)
+	}
+	for _, row := range report.Totals {
+		write("total", row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
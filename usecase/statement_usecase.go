@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/pdf"
+)
+
+// maxStatementDays is the longest period one statement may cover.
+const maxStatementDays = 366
+
+type StatementUseCase interface {
+	// GetStatement builds the statement of a customer for the days from to
+	// to, both inclusive and given as YYYY-MM-DD in the statement time zone.
+	// A non-empty userId limits it to the customer of that user.
+	GetStatement(userId string, customerId string, from string, to string) (model.Statement, error)
+	WriteStatement(w io.Writer, statement model.Statement, format string) error
+	ListStatements(userId string, customerId string, params model.PaginationParams) ([]model.Statement, error)
+	GetGeneratedStatement(userId string, id string) (model.Statement, error)
+	GenerateMonthlyStatements() (int, error)
+}
+
+type statementUseCase struct {
+	repo          repository.StatementRepository
+	customerUC    CustomerUseCase
+	statementPath string
+	location      *time.Location
+}
+
+func NewStatementUseCase(repo repository.StatementRepository, customerUC CustomerUseCase, statementPath string, location *time.Location) StatementUseCase {
+	return &statementUseCase{
+		repo:          repo,
+		customerUC:    customerUC,
+		statementPath: statementPath,
+		location:      location,
+	}
+}
+
+// GetStatement implements StatementUseCase.
+func (usecase *statementUseCase) GetStatement(userId string, customerId string, from string, to string) (model.Statement, error) {
+	start, end, err := parsePeriod(from, to, usecase.location, maxStatementDays)
+	if err != nil {
+		return model.Statement{}, err
+	}
+
+	customer, err := usecase.ownedCustomer(userId, customerId)
+	if err != nil {
+		return model.Statement{}, err
+	}
+	return usecase.buildStatement(customer, start, end)
+}
+
+// WriteStatement implements StatementUseCase.
+func (usecase *statementUseCase) WriteStatement(w io.Writer, statement model.Statement, format string) error {
+	switch format {
+	case model.StatementFormatCSV:
+		return usecase.writeStatementCSV(w, statement)
+	case model.StatementFormatPDF:
+		return usecase.writeStatementPDF(w, statement)
+	default:
+		return fmt.Errorf("unknown statement format %q", format)
+	}
+}
+
+// ListStatements implements StatementUseCase.
+func (usecase *statementUseCase) ListStatements(userId string, customerId string, params model.PaginationParams) ([]model.Statement, error) {
+	customer, err := usecase.ownedCustomer(userId, customerId)
+	if err != nil {
+		return nil, err
+	}
+	return usecase.repo.ListByCustomer(customer.ID, params)
+}
+
+// GetGeneratedStatement implements StatementUseCase.
+func (usecase *statementUseCase) GetGeneratedStatement(userId string, id string) (model.Statement, error) {
+	statement, err := usecase.repo.Get(id)
+	if err != nil {
+		return model.Statement{}, err
+	}
+	if _, err := usecase.ownedCustomer(userId, statement.CustomerID); err != nil {
+		return model.Statement{}, fmt.Errorf("statement %s: %w", id, common.ErrRecordNotFound)
+	}
+	return statement, nil
+}
+
+// GenerateMonthlyStatements implements StatementUseCase. It writes the
+// statement of the previous month for every customer that does not have one
+// yet, as CSV and PDF files under the statement path. A customer whose
+// statement fails is skipped until the next run; the failures are returned
+// together.
+func (usecase *statementUseCase) GenerateMonthlyStatements() (int, error) {
+	now := time.Now().In(usecase.location)
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, usecase.location)
+	start := end.AddDate(0, -1, 0)
+
+	const batchSize = 100
+	generated := 0
+	after := ""
+	var errs []error
+	for {
+		ids, err := usecase.repo.ListCustomersWithoutStatement(start, end, after, batchSize)
+		if err != nil {
+			return generated, errors.Join(append(errs, err)...)
+		}
+		for _, id := range ids {
+			if err := usecase.generateStatement(id, start, end); err != nil {
+				errs = append(errs, fmt.Errorf("error generating statement of customer %v: %w", id, err))
+				continue
+			}
+			generated++
+		}
+		if len(ids) < batchSize {
+			return generated, errors.Join(errs...)
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+func (usecase *statementUseCase) generateStatement(customerId string, start time.Time, end time.Time) error {
+	customer, err := usecase.customerUC.GetCustomerById(customerId)
+	if err != nil {
+		return err
+	}
+	statement, err := usecase.buildStatement(customer, start, end)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(usecase.statementPath, customer.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	name := start.Format("2006-01")
+	statement.ID = common.GenerateID()
+	statement.CSVPath = filepath.Join(dir, name+".csv")
+	statement.PDFPath = filepath.Join(dir, name+".pdf")
+	if err := usecase.writeStatementFile(statement.CSVPath, statement, model.StatementFormatCSV); err != nil {
+		return err
+	}
+	if err := usecase.writeStatementFile(statement.PDFPath, statement, model.StatementFormatPDF); err != nil {
+		return err
+	}
+
+	_, err = usecase.repo.Create(statement)
+	return err
+}
+
+func (usecase *statementUseCase) writeStatementFile(path string, statement model.Statement, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := usecase.WriteStatement(file, statement, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ownedCustomer returns the customer, checking that it belongs to userId
+// unless userId is empty.
+func (usecase *statementUseCase) ownedCustomer(userId string, customerId string) (model.CustomerResponse, error) {
+	customer, err := usecase.customerUC.GetCustomerById(customerId)
+	if err != nil {
+		return model.CustomerResponse{}, err
+	}
+	if userId == "" {
+		return customer, nil
+	}
+
+	own, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return model.CustomerResponse{}, err
+	}
+	if own.ID != customer.ID {
+		return model.CustomerResponse{}, fmt.Errorf("customer %s: %w", customerId, common.ErrRecordNotFound)
+	}
+	return customer, nil
+}
+
+// buildStatement reads the activity of the period and works out the running
+// balance and totals.
+func (usecase *statementUseCase) buildStatement(customer model.CustomerResponse, start time.Time, end time.Time) (model.Statement, error) {
+	statement, err := usecase.repo.Activity(model.Statement{
+		CustomerID:   customer.ID,
+		CustomerName: customer.Name,
+		Currency:     customer.Currency,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+	})
+	if err != nil {
+		return model.Statement{}, err
+	}
+
+	balance := statement.OpeningBalance
+	for k := range statement.Lines {
+		line := &statement.Lines[k]
+		balance += line.Amount
+		line.Balance = balance
+		if line.Amount > 0 {
+			statement.TotalIn += line.Amount
+		} else {
+			statement.TotalOut -= line.Amount
+		}
+	}
+	statement.ClosingBalance = balance
+	return statement, nil
+}
+
+// writeStatementCSV writes one row per line between an opening and a closing
+// balance row. Amounts are in minor units, like everywhere in the API.
+func (usecase *statementUseCase) writeStatementCSV(w io.Writer, statement model.Statement) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "kind", "reference_id", "counterparty", "description", "amount", "balance", "currency"})
+	cw.Write([]string{statement.PeriodStart.In(usecase.location).Format(time.RFC3339), "opening_balance", "", "", "", "",
+		strconv.FormatInt(statement.OpeningBalance, 10), statement.Currency})
+	for _, line := range statement.Lines {
+		cw.Write([]string{
+			line.Date.In(usecase.location).Format(time.RFC3339),
+			line.Kind,
+			line.ReferenceID,
+			line.Counterparty,
+			line.Description,
+			strconv.FormatInt(line.Amount, 10),
+			strconv.FormatInt(line.Balance, 10),
+			statement.Currency,
+		})
+	}
+	cw.Write([]string{statement.PeriodEnd.In(usecase.location).Format(time.RFC3339), "closing_balance", "", "", "", "",
+		strconv.FormatInt(statement.ClosingBalance, 10), statement.Currency})
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeStatementPDF lays the statement out on A4 pages: a summary on the
+// first page, then the lines as a table whose header repeats on every page.
+func (usecase *statementUseCase) writeStatementPDF(w io.Writer, statement model.Statement) error {
+	const (
+		margin    = 40.0
+		rowHeight = 14.0
+		fontSize  = 9.0
+	)
+	right := pdf.PageWidth - margin
+	lastDay := statement.PeriodEnd.AddDate(0, 0, -1)
+	period := statement.PeriodStart.In(usecase.location).Format("2 Jan 2006") + " - " + lastDay.In(usecase.location).Format("2 Jan 2006")
+	money := func(amount int64) string {
+		return formatMoney(amount, statement.Currency)
+	}
+
+	doc := pdf.New("Statement " + statement.CustomerName + " " + period)
+	page := doc.AddPage()
+	y := pdf.PageHeight - margin - 16
+	page.Text(margin, y, pdf.Bold, 16, "Statement of Account")
+	y -= 24
+	for _, row := range [][2]string{
+		{"Customer", statement.CustomerName},
+		{"Customer ID", statement.CustomerID},
+		{"Period", period},
+		{"Currency", statement.Currency},
+	} {
+		page.Text(margin, y, pdf.Bold, 10, row[0])
+		page.Text(margin+90, y, pdf.Regular, 10, row[1])
+		y -= rowHeight
+	}
+	y -= 10
+	for _, row := range []struct {
+		label  string
+		amount int64
+	}{
+		{"Opening balance", statement.OpeningBalance},
+		{"Money in", statement.TotalIn},
+		{"Money out", -statement.TotalOut},
+		{"Closing balance", statement.ClosingBalance},
+	} {
+		page.Text(margin, y, pdf.Bold, 10, row.label)
+		page.TextRight(margin+250, y, pdf.Regular, 10, money(row.amount))
+		y -= rowHeight
+	}
+	y -= 16
+
+	columns := []float64{margin, margin + 62, margin + 130}
+	amountRight, balanceRight := right-95, right
+	detailsWidth := amountRight - 75 - columns[2]
+	header := func() {
+		page.Text(columns[0], y, pdf.Bold, fontSize, "Date")
+		page.Text(columns[1], y, pdf.Bold, fontSize, "Type")
+		page.Text(columns[2], y, pdf.Bold, fontSize, "Details")
+		page.TextRight(amountRight, y, pdf.Bold, fontSize, "Amount")
+		page.TextRight(balanceRight, y, pdf.Bold, fontSize, "Balance")
+		page.Line(margin, y-4, right, y-4, 0.5)
+		y -= rowHeight + 2
+	}
+	header()
+	if len(statement.Lines) == 0 {
+		page.Text(columns[0], y, pdf.Regular, fontSize, "No activity in this period.")
+	}
+	for _, line := range statement.Lines {
+		if y < margin+rowHeight {
+			page = doc.AddPage()
+			y = pdf.PageHeight - margin - fontSize
+			header()
+		}
+		details := line.Counterparty
+		if details == "" {
+			details = line.Description
+		}
+		page.Text(columns[0], y, pdf.Regular, fontSize, line.Date.In(usecase.location).Format("02 Jan 2006"))
+		page.Text(columns[1], y, pdf.Regular, fontSize, statementKindLabel(line.Kind))
+		page.Text(columns[2], y, pdf.Regular, fontSize, pdf.Truncate(pdf.Regular, fontSize, details, detailsWidth))
+		page.TextRight(amountRight, y, pdf.Regular, fontSize, money(line.Amount))
+		page.TextRight(balanceRight, y, pdf.Regular, fontSize, money(line.Balance))
+		y -= rowHeight
+	}
+
+	return writeDocument(w, doc, "Generated "+time.Now().In(usecase.location).Format("2 Jan 2006 15:04 MST"))
+}
+
+// writeDocument numbers the pages of doc, adds the footer and writes it.
+func writeDocument(w io.Writer, doc *pdf.Document, footer string) error {
+	pages := doc.Pages()
+	for k, page := range pages {
+		page.Text(40, 24, pdf.Regular, 8, footer)
+		page.TextRight(pdf.PageWidth-40, 24, pdf.Regular, 8, fmt.Sprintf("Page %d of %d", k+1, len(pages)))
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func statementKindLabel(kind string) string {
+	switch kind {
+	case model.JournalKindTopUp:
+		return "Top-up"
+	case model.JournalKindPayment:
+		return "Payment"
+	case model.JournalKindRefund:
+		return "Refund"
+	case model.JournalKindTransfer:
+		return "Transfer"
+	case model.JournalKindCashback:
+		return "Cashback"
+	case model.JournalKindAdjustment:
+		return "Adjustment"
+	default:
+		return kind
+	}
+}
+
+// formatMoney writes an amount in minor units for people, e.g. -123456 USD
+// as "-1,234.56".
+func formatMoney(amount int64, currency string) string {
+	exponent := model.CurrencyExponents[currency]
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	s := strconv.FormatInt(amount, 10)
+	if len(s) <= exponent {
+		s = strings.Repeat("0", exponent-len(s)+1) + s
+	}
+	whole, fraction := s[:len(s)-exponent], s[len(s)-exponent:]
+
+	var b strings.Builder
+	for k, c := range whole {
+		if k > 0 && (len(whole)-k)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if exponent > 0 {
+		b.WriteString("." + fraction)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// subscriptionChargeLease is how long a claimed subscription is held by the
+// scheduler before another run may pick it up again.
+const subscriptionChargeLease = 5 * time.Minute
+
+const subscriptionChargeBatchSize = 100
+
+type SubscriptionUseCase interface {
+	CreateSubscription(payload model.CreateSubscriptionRequest) (model.Subscription, error)
+	GetSubscription(userId string, id string) (model.Subscription, error)
+	ListSubscriptions(userId string, params model.PaginationParams) ([]model.Subscription, error)
+	PauseSubscription(userId string, id string) (model.Subscription, error)
+	ResumeSubscription(userId string, id string) (model.Subscription, error)
+	CancelSubscription(userId string, id string) (model.Subscription, error)
+	SetNextAmount(id string, amount int64) (model.Subscription, error)
+	ListCharges(userId string, id string) ([]model.SubscriptionCharge, error)
+	ChargeDueSubscriptions() (int, error)
+}
+
+type subscriptionUseCase struct {
+	repo          repository.SubscriptionRepository
+	customerUC    CustomerUseCase
+	merchantUC    MerchantUseCase
+	transactionUC TransactionUseCase
+	maxRetries    int
+	retryBackoff  time.Duration
+}
+
+func NewSubscriptionUseCase(repo repository.SubscriptionRepository, customerUC CustomerUseCase, merchantUC MerchantUseCase, transactionUC TransactionUseCase, maxRetries int, retryBackoff time.Duration) SubscriptionUseCase {
+	return &subscriptionUseCase{
+		repo:          repo,
+		customerUC:    customerUC,
+		merchantUC:    merchantUC,
+		transactionUC: transactionUC,
+		maxRetries:    maxRetries,
+		retryBackoff:  retryBackoff,
+	}
+}
+
+// CreateSubscription implements SubscriptionUseCase. The first charge runs at
+// StartAt, or right away when it is not set.
+func (usecase *subscriptionUseCase) CreateSubscription(payload model.CreateSubscriptionRequest) (model.Subscription, error) {
+	switch payload.AmountType {
+	case model.SubscriptionAmountFixed:
+		if payload.Amount <= 0 {
+			return model.Subscription{}, common.ErrInvalidAmount
+		}
+		payload.MaxAmount = 0
+	case model.SubscriptionAmountVariable:
+		if payload.MaxAmount <= 0 {
+			return model.Subscription{}, common.ErrInvalidAmount
+		}
+		payload.Amount = 0
+	default:
+		return model.Subscription{}, fmt.Errorf("%w: unknown amount type %q", common.ErrInvalidAmount, payload.AmountType)
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(payload.UserID)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	merchant, err := usecase.merchantUC.GetMerchant(payload.MerchantID)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	startedAt := time.Now()
+	if payload.StartAt != nil && payload.StartAt.After(startedAt) {
+		startedAt = *payload.StartAt
+	}
+
+	return usecase.repo.Create(model.Subscription{
+		ID:         common.GenerateID(),
+		CustomerID: customer.ID,
+		MerchantID: merchant.ID,
+		Interval:   payload.Interval,
+		AmountType: payload.AmountType,
+		Amount:     payload.Amount,
+		MaxAmount:  payload.MaxAmount,
+		StartedAt:  startedAt,
+	})
+}
+
+// GetSubscription implements SubscriptionUseCase. An empty userId skips the
+// ownership check for admins.
+func (usecase *subscriptionUseCase) GetSubscription(userId string, id string) (model.Subscription, error) {
+	subscription, err := usecase.repo.Get(id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if userId == "" {
+		return subscription, nil
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if subscription.CustomerID != customer.ID {
+		return model.Subscription{}, fmt.Errorf("subscription %s: %w", id, common.ErrRecordNotFound)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions implements SubscriptionUseCase.
+func (usecase *subscriptionUseCase) ListSubscriptions(userId string, params model.PaginationParams) ([]model.Subscription, error) {
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return usecase.repo.ListByCustomerId(customer.ID, params)
+}
+
+// PauseSubscription implements SubscriptionUseCase.
+func (usecase *subscriptionUseCase) PauseSubscription(userId string, id string) (model.Subscription, error) {
+	if _, err := usecase.GetSubscription(userId, id); err != nil {
+		return model.Subscription{}, err
+	}
+	return usecase.repo.SetStatus(id, model.SubscriptionStatusPaused, []string{model.SubscriptionStatusActive, model.SubscriptionStatusPastDue})
+}
+
+// ResumeSubscription implements SubscriptionUseCase. Periods missed while
+// paused are not charged; a past due subscription retries its open charge
+// right away.
+func (usecase *subscriptionUseCase) ResumeSubscription(userId string, id string) (model.Subscription, error) {
+	subscription, err := usecase.GetSubscription(userId, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	now := time.Now()
+	nextChargeAt := subscription.NextChargeAt
+	var retryAt *time.Time
+	switch subscription.Status {
+	case model.SubscriptionStatusPastDue:
+		retryAt = &now
+	case model.SubscriptionStatusPaused:
+		for nextChargeAt.Before(now) {
+			nextChargeAt = nextBillingDate(subscription, nextChargeAt)
+		}
+	default:
+		return model.Subscription{}, common.ErrInvalidStatus
+	}
+
+	return usecase.repo.Resume(id, nextChargeAt, retryAt)
+}
+
+// CancelSubscription implements SubscriptionUseCase.
+func (usecase *subscriptionUseCase) CancelSubscription(userId string, id string) (model.Subscription, error) {
+	if _, err := usecase.GetSubscription(userId, id); err != nil {
+		return model.Subscription{}, err
+	}
+	return usecase.repo.SetStatus(id, model.SubscriptionStatusCancelled, []string{
+		model.SubscriptionStatusActive,
+		model.SubscriptionStatusPaused,
+		model.SubscriptionStatusPastDue,
+	})
+}
+
+// SetNextAmount implements SubscriptionUseCase. It sets the amount of the
+// next charge of a variable subscription.
+func (usecase *subscriptionUseCase) SetNextAmount(id string, amount int64) (model.Subscription, error) {
+	subscription, err := usecase.repo.Get(id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if amount <= 0 || amount > subscription.MaxAmount {
+		return model.Subscription{}, fmt.Errorf("%w: amount must be between 1 and %d", common.ErrInvalidAmount, subscription.MaxAmount)
+	}
+	return usecase.repo.SetNextAmount(id, amount)
+}
+
+// ListCharges implements SubscriptionUseCase.
+func (usecase *subscriptionUseCase) ListCharges(userId string, id string) ([]model.SubscriptionCharge, error) {
+	if _, err := usecase.GetSubscription(userId, id); err != nil {
+		return nil, err
+	}
+	return usecase.repo.ListCharges(id)
+}
+
+// ChargeDueSubscriptions implements SubscriptionUseCase. It returns the
+// number of charges that went through. A subscription that cannot be charged
+// does not stop the others; the errors are returned together at the end.
+func (usecase *subscriptionUseCase) ChargeDueSubscriptions() (int, error) {
+	subscriptions, err := usecase.repo.ClaimDue(subscriptionChargeLease, subscriptionChargeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	var errs []error
+	for _, subscription := range subscriptions {
+		ok, err := usecase.charge(subscription)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error charging subscription %v: %w", subscription.ID, err))
+			continue
+		}
+		if ok {
+			charged++
+		}
+	}
+	return charged, errors.Join(errs...)
+}
+
+// charge runs the open charge of a subscription and records the outcome. A
+// successful charge is recorded with its payment, so a crash cannot leave a
+// paid period open to be charged again. A payment held for fraud review
+// leaves the charge pending, and the period moves on only once it is
+// approved. A charge that failed on insufficient
+// balance is retried with exponential backoff; once the retries are used up,
+// or when it failed for any other reason, the subscription becomes past due
+// until the customer resumes it.
+func (usecase *subscriptionUseCase) charge(subscription model.Subscription) (bool, error) {
+	next := subscription
+	charge := model.SubscriptionCharge{
+		ID:             common.GenerateID(),
+		SubscriptionID: subscription.ID,
+		Amount:         subscription.Amount,
+		Attempt:        subscription.RetryCount + 1,
+	}
+
+	if subscription.AmountType == model.SubscriptionAmountVariable {
+		if subscription.NextAmount == nil {
+			// nothing to bill for this period
+			charge.Status = model.SubscriptionChargeSkipped
+			usecase.advance(&next)
+			_, err := usecase.repo.RecordCharge(charge, next)
+			return false, err
+		}
+		charge.Amount = *subscription.NextAmount
+	}
+
+	charge.NextChargeAt = nextBillingDate(subscription, subscription.NextChargeAt)
+	transaction, err := usecase.chargeCustomer(subscription, charge)
+	if err == nil {
+		// recorded with the payment; a payment held for review leaves the
+		// charge pending until it is decided
+		return transaction.Status == model.TransactionStatusCaptured, nil
+	}
+
+	charge.Status = model.SubscriptionChargeFailed
+	charge.FailureReason = err.Error()
+	next.LastError = err.Error()
+	next.RetryCount = subscription.RetryCount + 1
+	if !errors.Is(err, common.ErrInsufficientFunds) || next.RetryCount > usecase.maxRetries {
+		next.Status = model.SubscriptionStatusPastDue
+		next.RetryAt = nil
+	} else {
+		retryAt := time.Now().Add(usecase.retryBackoff << subscription.RetryCount)
+		next.RetryAt = &retryAt
+	}
+	_, err = usecase.repo.RecordCharge(charge, next)
+	return false, err
+}
+
+func (usecase *subscriptionUseCase) chargeCustomer(subscription model.Subscription, charge model.SubscriptionCharge) (model.Transaction, error) {
+	customer, err := usecase.customerUC.GetCustomerById(subscription.CustomerID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	return usecase.transactionUC.RegisterNewTransaction(model.CreateTransactionRequest{
+		UserId:             customer.User.ID,
+		ReceiverMerchantId: subscription.MerchantID,
+		Amount:             charge.Amount,
+		SubscriptionCharge: &charge,
+	})
+}
+
+// advance moves a subscription to its next billing period.
+func (usecase *subscriptionUseCase) advance(subscription *model.Subscription) {
+	subscription.NextChargeAt = nextBillingDate(*subscription, subscription.NextChargeAt)
+	subscription.RetryAt = nil
+	subscription.RetryCount = 0
+	subscription.LastError = ""
+	subscription.NextAmount = nil
+}
+
+// nextBillingDate returns the billing date after current. Monthly dates keep
+// the day of the month the subscription started on, clamped to the end of
+// shorter months.
+func nextBillingDate(subscription model.Subscription, current time.Time) time.Time {
+	switch subscription.Interval {
+	case model.SubscriptionIntervalDaily:
+		return current.AddDate(0, 0, 1)
+	case model.SubscriptionIntervalWeekly:
+		return current.AddDate(0, 0, 7)
+	}
+
+	start := subscription.StartedAt.In(current.Location())
+	months := (current.Year()-start.Year())*12 + int(current.Month()-start.Month()) + 1
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(months), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	day := start.Day()
+	if lastDay := firstOfMonth.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+	"github.com/albar2305/payment-app/utils/gateway"
+)
+
+type TopUpUseCase interface {
+	CreateTopUp(payload model.CreateTopUpRequest) (model.TopUpOrder, error)
+	GetTopUp(userId string, id string) (model.TopUpOrder, error)
+	ListTopUps(userId string, params model.PaginationParams) ([]model.TopUpOrder, error)
+	HandleGatewayWebhook(body []byte, signature string) (model.TopUpOrder, error)
+	ExpireTopUps() (int, error)
+}
+
+type topUpUseCase struct {
+	repo       repository.TopUpRepository
+	customerUC CustomerUseCase
+	gateway    gateway.Gateway
+	orderTTL   time.Duration
+}
+
+func NewTopUpUseCase(repo repository.TopUpRepository, customerUC CustomerUseCase, gateway gateway.Gateway, orderTTL time.Duration) TopUpUseCase {
+	return &topUpUseCase{
+		repo:       repo,
+		customerUC: customerUC,
+		gateway:    gateway,
+		orderTTL:   orderTTL,
+	}
+}
+
+// CreateTopUp implements TopUpUseCase. The order stays pending until the
+// gateway confirms the payment through the webhook.
+func (usecase *topUpUseCase) CreateTopUp(payload model.CreateTopUpRequest) (model.TopUpOrder, error) {
+	if payload.Amount <= 0 {
+		return model.TopUpOrder{}, common.ErrInvalidAmount
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(payload.UserID)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	order, err := usecase.repo.Create(model.TopUpOrder{
+		ID:         common.GenerateID(),
+		CustomerID: customer.ID,
+		Amount:     payload.Amount,
+		ExpiresAt:  time.Now().Add(usecase.orderTTL),
+	})
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	charge, err := usecase.gateway.CreateCharge(gateway.ChargeRequest{
+		OrderID:   order.ID,
+		Amount:    order.Amount,
+		Currency:  order.Currency,
+		ExpiresAt: order.ExpiresAt,
+	})
+	if err != nil {
+		_, _ = usecase.repo.Close(order.ID, model.TopUpStatusFailed, err.Error())
+		return model.TopUpOrder{}, fmt.Errorf("error creating gateway charge for order %v: %v", order.ID, err)
+	}
+
+	return usecase.repo.SetCharge(order.ID, charge.Reference, charge.CheckoutURL)
+}
+
+// GetTopUp implements TopUpUseCase. An empty userId skips the ownership
+// check for admins.
+func (usecase *topUpUseCase) GetTopUp(userId string, id string) (model.TopUpOrder, error) {
+	order, err := usecase.repo.Get(id)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+	if userId == "" {
+		return order, nil
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+	if order.CustomerID != customer.ID {
+		return model.TopUpOrder{}, fmt.Errorf("top-up order %s: %w", id, common.ErrRecordNotFound)
+	}
+	return order, nil
+}
+
+// ListTopUps implements TopUpUseCase.
+func (usecase *topUpUseCase) ListTopUps(userId string, params model.PaginationParams) ([]model.TopUpOrder, error) {
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return usecase.repo.ListByCustomerId(customer.ID, params)
+}
+
+// HandleGatewayWebhook implements TopUpUseCase. Webhooks can be delivered
+// more than once, so every status change is idempotent.
+func (usecase *topUpUseCase) HandleGatewayWebhook(body []byte, signature string) (model.TopUpOrder, error) {
+	event, err := usecase.gateway.ParseWebhook(body, signature)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	order, err := usecase.repo.GetByReference(event.Reference)
+	if err != nil {
+		return model.TopUpOrder{}, err
+	}
+
+	switch event.Status {
+	case gateway.EventStatusPaid:
+		if event.Amount != order.Amount || event.Currency != order.Currency {
+			return model.TopUpOrder{}, fmt.Errorf("%w: gateway paid %d %s for order %s", common.ErrInvalidAmount, event.Amount, event.Currency, order.ID)
+		}
+		return usecase.repo.MarkPaid(order.ID)
+	case gateway.EventStatusFailed, gateway.EventStatusExpired:
+		closed, err := usecase.repo.Close(order.ID, event.Status, event.FailureReason)
+		if errors.Is(err, common.ErrInvalidStatus) {
+			// already paid, failed or expired
+			return usecase.repo.Get(order.ID)
+		}
+		return closed, err
+	default:
+		return model.TopUpOrder{}, fmt.Errorf("unknown gateway event status %q", event.Status)
+	}
+}
+
+// ExpireTopUps implements TopUpUseCase.
+func (usecase *topUpUseCase) ExpireTopUps() (int, error) {
+	ids, err := usecase.repo.ListExpired()
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		_, err := usecase.repo.Close(id, model.TopUpStatusExpired, "")
+		if errors.Is(err, common.ErrInvalidStatus) {
+			// confirmed since it was listed
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("error expiring top-up order %v: %v", id, err)
+		}
+		expired++
+	}
+	return expired, nil
+}
@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/albar2305/payment-app/model"
 	"github.com/albar2305/payment-app/repository"
@@ -10,62 +12,192 @@ import (
 
 type TransactionUseCase interface {
 	RegisterNewTransaction(payload model.CreateTransactionRequest) (model.Transaction, error)
-	GetTransactionByCustomerId(id string, params model.PaginationParams) ([]model.Transaction, error)
-	ListTransaction(params model.PaginationParams) ([]model.Transaction, error)
+	AuthorizeTransaction(payload model.CreateTransactionRequest) (model.Transaction, error)
+	CaptureTransaction(payload model.CaptureTransactionRequest) (model.Transaction, error)
+	VoidTransaction(id string) (model.Transaction, error)
+	ExpireAuthorizations() (int, error)
+	GetTransactionById(id string) (model.Transaction, error)
+	// GetTransactionDetail returns a transaction with the relations in
+	// expand. A non-empty userId limits it to the payments of that user.
+	GetTransactionDetail(userId string, id string, expand model.TransactionExpand) (model.TransactionResponse, error)
+	// GetTransactionByCustomerId lists the payments of a customer. A
+	// non-empty userId limits it to the customer of that user.
+	GetTransactionByCustomerId(userId string, id string, filter model.TransactionFilter, params model.PaginationParams) ([]model.Transaction, error)
+	// ListTransaction lists payments matching the filter. A non-empty userId
+	// limits it to the payments of that user, whatever customer the filter
+	// asks for.
+	ListTransaction(userId string, filter model.TransactionFilter, params model.PaginationParams) ([]model.Transaction, error)
 }
 
 type transactionUseCase struct {
-	repo       repository.TransactionRepository
-	userUC     UserUseCase
-	customerUC CustomerUseCase
-	merchantUC MerchantUseCase
+	repo             repository.TransactionRepository
+	userUC           UserUseCase
+	customerUC       CustomerUseCase
+	merchantUC       MerchantUseCase
+	fxRateUC         FxRateUseCase
+	feeUC            FeeUseCase
+	fraudUC          FraudUseCase
+	promoUC          PromoUseCase
+	authorizationTTL time.Duration
 }
 
-func NewTransactionUseCase(repo repository.TransactionRepository, userUC UserUseCase, customerUC CustomerUseCase, merchantUC MerchantUseCase) TransactionUseCase {
+func NewTransactionUseCase(repo repository.TransactionRepository, userUC UserUseCase, customerUC CustomerUseCase, merchantUC MerchantUseCase, fxRateUC FxRateUseCase, feeUC FeeUseCase, fraudUC FraudUseCase, promoUC PromoUseCase, authorizationTTL time.Duration) TransactionUseCase {
 	return &transactionUseCase{
-		repo:       repo,
-		userUC:     userUC,
-		customerUC: customerUC,
-		merchantUC: merchantUC,
+		repo:             repo,
+		userUC:           userUC,
+		customerUC:       customerUC,
+		merchantUC:       merchantUC,
+		fxRateUC:         fxRateUC,
+		feeUC:            feeUC,
+		fraudUC:          fraudUC,
+		promoUC:          promoUC,
+		authorizationTTL: authorizationTTL,
 	}
 }
 
-// GetTransaction implements TransactionUseCase.
-func (usecase *transactionUseCase) GetTransactionByCustomerId(id string, params model.PaginationParams) ([]model.Transaction, error) {
-	transactions, err := usecase.repo.GetByCustomerId(id, params)
+// GetTransactionById implements TransactionUseCase. Split payments come with
+// their legs.
+func (usecase *transactionUseCase) GetTransactionById(id string) (model.Transaction, error) {
+	transaction, err := usecase.repo.GetById(id)
 	if err != nil {
-		return []model.Transaction{}, err
+		return model.Transaction{}, err
 	}
-	return transactions, err
+	if transaction.IsSplit() {
+		transaction.Legs, err = usecase.repo.ListLegs(transaction.ID)
+	}
+	return transaction, err
 }
 
-// ListTransaction implements TransactionUseCase.
-func (usecase *transactionUseCase) ListTransaction(params model.PaginationParams) ([]model.Transaction, error) {
-	transactions, err := usecase.repo.List(params)
+// GetTransactionDetail implements TransactionUseCase.
+func (usecase *transactionUseCase) GetTransactionDetail(userId string, id string, expand model.TransactionExpand) (model.TransactionResponse, error) {
+	transaction, err := usecase.GetTransactionById(id)
 	if err != nil {
+		return model.TransactionResponse{}, err
+	}
+	if userId != "" {
+		customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+		if err != nil {
+			return model.TransactionResponse{}, err
+		}
+		if transaction.SenderCustomerId != customer.ID {
+			return model.TransactionResponse{}, fmt.Errorf("transaction %s: %w", id, common.ErrRecordNotFound)
+		}
+	}
+
+	detail := model.TransactionResponse{Transaction: transaction}
+	if expand.Customer {
+		customer, err := usecase.customerUC.GetCustomerById(transaction.SenderCustomerId)
+		if err != nil {
+			return model.TransactionResponse{}, err
+		}
+		detail.Customer = &customer
+	}
+	if expand.Merchant && !transaction.IsSplit() {
+		merchant, err := usecase.merchantUC.GetMerchant(transaction.ReceiverMerchantId)
+		if err != nil {
+			return model.TransactionResponse{}, err
+		}
+		detail.Merchant = &merchant
+	}
+	return detail, nil
+}
+
+// GetTransactionByCustomerId implements TransactionUseCase.
+func (usecase *transactionUseCase) GetTransactionByCustomerId(userId string, id string, filter model.TransactionFilter, params model.PaginationParams) ([]model.Transaction, error) {
+	if userId != "" {
+		customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+		if err != nil {
+			return []model.Transaction{}, err
+		}
+		if customer.ID != id {
+			return []model.Transaction{}, fmt.Errorf("customer %s: %w", id, common.ErrRecordNotFound)
+		}
+	}
+
+	filter.CustomerID = id
+	return usecase.ListTransaction("", filter, params)
+}
+
+// ListTransaction implements TransactionUseCase. Transactions are listed
+// oldest first unless the filter sorts them otherwise.
+func (usecase *transactionUseCase) ListTransaction(userId string, filter model.TransactionFilter, params model.PaginationParams) ([]model.Transaction, error) {
+	if userId != "" {
+		customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+		if err != nil {
+			return []model.Transaction{}, err
+		}
+		filter.CustomerID = customer.ID
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = model.TransactionSortCreatedAt
+	}
+	if filter.SortOrder == "" {
+		filter.SortOrder = model.SortOrderAsc
+	}
+	if err := validateTransactionFilter(filter); err != nil {
 		return []model.Transaction{}, err
 	}
 
+	transactions, err := usecase.repo.List(filter, params)
+	if err != nil {
+		return []model.Transaction{}, err
+	}
 	return transactions, err
 }
 
-// RegisterNewTransaction implements TransactionUseCase.
+func validateTransactionFilter(filter model.TransactionFilter) error {
+	if filter.SortOrder != model.SortOrderAsc && filter.SortOrder != model.SortOrderDesc {
+		return fmt.Errorf("%w: order must be asc or desc", common.ErrInvalidFilter)
+	}
+	for _, status := range filter.Statuses {
+		switch status {
+		case model.TransactionStatusAuthorized, model.TransactionStatusCaptured, model.TransactionStatusVoided,
+			model.TransactionStatusExpired, model.TransactionStatusPendingReview, model.TransactionStatusRejected:
+		default:
+			return fmt.Errorf("%w: unknown status %q", common.ErrInvalidFilter, status)
+		}
+	}
+	if (filter.MinAmount != nil && *filter.MinAmount < 0) || (filter.MaxAmount != nil && *filter.MaxAmount < 0) {
+		return fmt.Errorf("%w: amounts must not be negative", common.ErrInvalidFilter)
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return fmt.Errorf("%w: min_amount must not be more than max_amount", common.ErrInvalidFilter)
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return fmt.Errorf("%w: to must be after from", common.ErrInvalidFilter)
+	}
+	return nil
+}
+
+// RegisterNewTransaction implements TransactionUseCase. Payments flagged by
+// fraud screening are held pending review instead of captured. A promo code
+// discount is taken off the amount before the fee is quoted.
 func (usecase *transactionUseCase) RegisterNewTransaction(payload model.CreateTransactionRequest) (model.Transaction, error) {
+	return retryScreening(func() (model.Transaction, error) {
+		return usecase.registerTransaction(payload)
+	})
+}
 
-	user, err := usecase.userUC.GetUserById(payload.UserId)
+func (usecase *transactionUseCase) registerTransaction(payload model.CreateTransactionRequest) (model.Transaction, error) {
+	if len(payload.Splits) > 0 {
+		return usecase.registerSplitTransaction(payload)
+	}
+
+	req, err := usecase.newTransaction(payload)
 	if err != nil {
-		return model.Transaction{}, fmt.Errorf("error getting user from user %v: %v", payload.UserId, err)
+		return model.Transaction{}, err
+	}
+	req.Fee, err = usecase.quoteFee(req.ReceiverMerchantId, req.Amount)
+	if err != nil {
+		return model.Transaction{}, err
 	}
 
-	customer, err := usecase.customerUC.GetCustomerByUserId(user.ID)
+	assessment, err := usecase.screen(&req, nil)
 	if err != nil {
-		return model.Transaction{}, fmt.Errorf("error getting customer from customer with user id %v: %v", user.ID, err)
+		return model.Transaction{}, err
 	}
-	req := model.Transaction{
-		ID:                 common.GenerateID(),
-		SenderCustomerId:   customer.ID,
-		ReceiverMerchantId: payload.ReceiverMerchantId,
-		Amount:             payload.Amount,
+	if assessment.Decision == model.FraudDecisionReview {
+		return usecase.fraudUC.Hold(req, assessment)
 	}
 
 	transaction, err := usecase.repo.Create(req)
@@ -75,3 +207,292 @@ func (usecase *transactionUseCase) RegisterNewTransaction(payload model.CreateTr
 
 	return transaction, err
 }
+
+// registerSplitTransaction pays several merchants from one customer payment.
+// All merchants must use the same currency. The total is converted to the
+// customer currency once and the repository shares it between the legs.
+// Split payments cannot wait for review, so screening blocks them instead.
+func (usecase *transactionUseCase) registerSplitTransaction(payload model.CreateTransactionRequest) (model.Transaction, error) {
+	if payload.ReceiverMerchantId != "" {
+		return model.Transaction{}, fmt.Errorf("%w: set either receiver_merchant_id or splits", common.ErrInvalidSplit)
+	}
+	if len(payload.Splits) < 2 {
+		return model.Transaction{}, fmt.Errorf("%w: a split payment needs at least two merchants", common.ErrInvalidSplit)
+	}
+	if payload.PromoCode != "" {
+		return model.Transaction{}, fmt.Errorf("%w: promo codes apply to payments to a single merchant", common.ErrInvalidPromo)
+	}
+
+	customer, err := usecase.payingCustomer(payload.UserId)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	var currency string
+	var total int64
+	seen := map[string]bool{}
+	for _, split := range payload.Splits {
+		if split.Amount <= 0 {
+			return model.Transaction{}, common.ErrInvalidAmount
+		}
+		if seen[split.ReceiverMerchantId] {
+			return model.Transaction{}, fmt.Errorf("%w: merchant %s appears more than once", common.ErrInvalidSplit, split.ReceiverMerchantId)
+		}
+		seen[split.ReceiverMerchantId] = true
+
+		merchant, err := usecase.merchantUC.GetMerchant(split.ReceiverMerchantId)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		if currency == "" {
+			currency = merchant.Currency
+		}
+		if merchant.Currency != currency {
+			return model.Transaction{}, common.ErrCurrencyMismatch
+		}
+		total += split.Amount
+	}
+	if payload.Amount != 0 && payload.Amount != total {
+		return model.Transaction{}, fmt.Errorf("%w: amount %d does not match the split total %d", common.ErrInvalidSplit, payload.Amount, total)
+	}
+
+	sourceTotal, rate, err := usecase.fxRateUC.Convert(total, currency, customer.Currency)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	parent := model.Transaction{
+		ID:               common.GenerateID(),
+		SenderCustomerId: customer.ID,
+		Amount:           total,
+		Currency:         currency,
+		SourceAmount:     sourceTotal,
+		SourceCurrency:   customer.Currency,
+		FxRate:           rate,
+	}
+	legs := []model.Transaction{}
+	for _, split := range payload.Splits {
+		fee, err := usecase.quoteFee(split.ReceiverMerchantId, split.Amount)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		legs = append(legs, model.Transaction{
+			ID:                 common.GenerateID(),
+			SenderCustomerId:   customer.ID,
+			ReceiverMerchantId: split.ReceiverMerchantId,
+			Amount:             split.Amount,
+			Currency:           currency,
+			SourceCurrency:     customer.Currency,
+			FxRate:             rate,
+			Fee:                fee,
+		})
+		parent.Fee += fee
+	}
+
+	assessment, err := usecase.screen(&parent, legs)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if assessment.Decision == model.FraudDecisionReview {
+		return model.Transaction{}, fmt.Errorf("%w: split payment needs review", common.ErrPaymentBlocked)
+	}
+
+	return usecase.repo.CreateSplit(parent, legs)
+}
+
+// AuthorizeTransaction implements TransactionUseCase. Split payments are
+// always captured immediately. Authorizations flagged by fraud screening are
+// held pending review and become authorized once approved.
+func (usecase *transactionUseCase) AuthorizeTransaction(payload model.CreateTransactionRequest) (model.Transaction, error) {
+	return retryScreening(func() (model.Transaction, error) {
+		return usecase.authorizeTransaction(payload)
+	})
+}
+
+func (usecase *transactionUseCase) authorizeTransaction(payload model.CreateTransactionRequest) (model.Transaction, error) {
+	if len(payload.Splits) > 0 {
+		return model.Transaction{}, fmt.Errorf("%w: split payments cannot be authorized", common.ErrInvalidSplit)
+	}
+	if payload.PromoCode != "" {
+		return model.Transaction{}, fmt.Errorf("%w: promo codes cannot be used on authorizations", common.ErrInvalidPromo)
+	}
+	req, err := usecase.newTransaction(payload)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	expiresAt := time.Now().Add(usecase.authorizationTTL)
+	req.ExpiresAt = &expiresAt
+
+	assessment, err := usecase.screen(&req, nil)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if assessment.Decision == model.FraudDecisionReview {
+		return usecase.fraudUC.Hold(req, assessment)
+	}
+
+	return usecase.repo.Authorize(req)
+}
+
+// CaptureTransaction implements TransactionUseCase. The fee is charged on
+// the captured amount, not on the authorization.
+func (usecase *transactionUseCase) CaptureTransaction(payload model.CaptureTransactionRequest) (model.Transaction, error) {
+	if payload.Amount < 0 {
+		return model.Transaction{}, common.ErrInvalidAmount
+	}
+
+	authorization, err := usecase.repo.GetById(payload.TransactionID)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if authorization.IsSplit() {
+		return model.Transaction{}, common.ErrInvalidStatus
+	}
+	amount := payload.Amount
+	if amount == 0 {
+		amount = authorization.Amount
+	}
+	fee, err := usecase.quoteFee(authorization.ReceiverMerchantId, amount)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	return usecase.repo.Capture(payload.TransactionID, amount, fee)
+}
+
+// screen runs fraud screening on a payment and fails with ErrPaymentBlocked
+// when a rule blocks it. It notes on t what screening saw, so the payment is
+// not stored if another one came in since.
+func (usecase *transactionUseCase) screen(t *model.Transaction, legs []model.Transaction) (model.FraudAssessment, error) {
+	assessment, err := usecase.fraudUC.Screen(*t, legs)
+	if err != nil {
+		return model.FraudAssessment{}, err
+	}
+	if assessment.Decision == model.FraudDecisionBlock {
+		return model.FraudAssessment{}, common.ErrPaymentBlocked
+	}
+	t.ScreenedPayments = &assessment.PaymentCount
+	return assessment, nil
+}
+
+// screeningAttempts is how many times a payment is screened when other
+// payments of the customer keep coming in while it is.
+const screeningAttempts = 3
+
+// retryScreening runs register again when another payment of the customer
+// was stored while its payment was screened.
+func retryScreening(register func() (model.Transaction, error)) (model.Transaction, error) {
+	for attempt := 1; ; attempt++ {
+		transaction, err := register()
+		if !errors.Is(err, common.ErrScreeningOutdated) || attempt == screeningAttempts {
+			return transaction, err
+		}
+	}
+}
+
+func (usecase *transactionUseCase) quoteFee(merchantId string, amount int64) (int64, error) {
+	merchant, err := usecase.merchantUC.GetMerchant(merchantId)
+	if err != nil {
+		return 0, err
+	}
+	quote, err := usecase.feeUC.QuoteFee(merchant, amount)
+	if err != nil {
+		return 0, fmt.Errorf("error quoting fee for merchant %v: %w", merchantId, err)
+	}
+	return quote.Fee, nil
+}
+
+// VoidTransaction implements TransactionUseCase.
+func (usecase *transactionUseCase) VoidTransaction(id string) (model.Transaction, error) {
+	return usecase.repo.Void(id)
+}
+
+// ExpireAuthorizations implements TransactionUseCase.
+func (usecase *transactionUseCase) ExpireAuthorizations() (int, error) {
+	ids, err := usecase.repo.ListExpiredAuthorizations()
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		_, err := usecase.repo.Expire(id)
+		if errors.Is(err, common.ErrInvalidStatus) {
+			// captured or voided since it was listed
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("error expiring authorization %v: %v", id, err)
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (usecase *transactionUseCase) newTransaction(payload model.CreateTransactionRequest) (model.Transaction, error) {
+	if payload.Amount <= 0 {
+		return model.Transaction{}, common.ErrInvalidAmount
+	}
+
+	customer, err := usecase.payingCustomer(payload.UserId)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	merchant, err := usecase.merchantUC.GetMerchant(payload.ReceiverMerchantId)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	amount := payload.Amount
+	var promo *model.PromoRedemption
+	var discount int64
+	if payload.PromoCode != "" {
+		promo, err = usecase.promoUC.Apply(payload.PromoCode, customer.ID, merchant, amount)
+		if err != nil {
+			return model.Transaction{}, err
+		}
+		if promo.RewardType == model.PromoRewardDiscount {
+			discount = promo.Amount
+			amount -= discount
+		}
+	}
+
+	// The amount is priced in the merchant currency and converted to the
+	// customer currency at the current rate.
+	sourceAmount, rate, err := usecase.fxRateUC.Convert(amount, merchant.Currency, customer.Currency)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if sourceAmount <= 0 {
+		return model.Transaction{}, common.ErrInvalidAmount
+	}
+
+	return model.Transaction{
+		ID:                 common.GenerateID(),
+		SenderCustomerId:   customer.ID,
+		ReceiverMerchantId: merchant.ID,
+		Amount:             amount,
+		Currency:           merchant.Currency,
+		SourceAmount:       sourceAmount,
+		SourceCurrency:     customer.Currency,
+		FxRate:             rate,
+		Discount:           discount,
+		InvoiceID:          payload.InvoiceID,
+		Promo:              promo,
+		SubscriptionCharge: payload.SubscriptionCharge,
+	}, nil
+}
+
+func (usecase *transactionUseCase) payingCustomer(userId string) (model.CustomerResponse, error) {
+	user, err := usecase.userUC.GetUserById(userId)
+	if err != nil {
+		return model.CustomerResponse{}, fmt.Errorf("error getting user from user %v: %v", userId, err)
+	}
+
+	customer, err := usecase.customerUC.GetCustomerByUserId(user.ID)
+	if err != nil {
+		return model.CustomerResponse{}, fmt.Errorf("error getting customer from customer with user id %v: %v", user.ID, err)
+	}
+	return customer, nil
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+type TransferUseCase interface {
+	RegisterNewTransfer(payload model.CreateTransferRequest) (model.Transfer, error)
+	ListTransferByUserId(userId string, params model.PaginationParams) ([]model.Transfer, error)
+}
+
+type transferUseCase struct {
+	repo       repository.TransferRepository
+	userUC     UserUseCase
+	customerUC CustomerUseCase
+}
+
+func NewTransferUseCase(repo repository.TransferRepository, userUC UserUseCase, customerUC CustomerUseCase) TransferUseCase {
+	return &transferUseCase{
+		repo:       repo,
+		userUC:     userUC,
+		customerUC: customerUC,
+	}
+}
+
+// RegisterNewTransfer implements TransferUseCase.
+func (usecase *transferUseCase) RegisterNewTransfer(payload model.CreateTransferRequest) (model.Transfer, error) {
+	if payload.Amount <= 0 {
+		return model.Transfer{}, common.ErrInvalidAmount
+	}
+
+	sender, err := usecase.customerUC.GetCustomerByUserId(payload.UserId)
+	if err != nil {
+		return model.Transfer{}, fmt.Errorf("error getting customer from customer with user id %v: %v", payload.UserId, err)
+	}
+
+	receiverId := payload.ReceiverCustomerId
+	if receiverId == "" {
+		user, err := usecase.userUC.GetUser(payload.ReceiverUsername)
+		if err != nil {
+			return model.Transfer{}, err
+		}
+		receiver, err := usecase.customerUC.GetCustomerByUserId(user.ID)
+		if err != nil {
+			return model.Transfer{}, fmt.Errorf("user %v has no customer wallet: %w", payload.ReceiverUsername, common.ErrRecordNotFound)
+		}
+		receiverId = receiver.ID
+	}
+
+	transfer := model.Transfer{
+		ID:                 common.GenerateID(),
+		SenderCustomerId:   sender.ID,
+		ReceiverCustomerId: receiverId,
+		Amount:             payload.Amount,
+		Note:               payload.Note,
+	}
+	result, err := usecase.repo.Create(transfer)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	result.Direction = model.TransferDirectionSent
+	return result, nil
+}
+
+// ListTransferByUserId implements TransferUseCase.
+func (usecase *transferUseCase) ListTransferByUserId(userId string, params model.PaginationParams) ([]model.Transfer, error) {
+	customer, err := usecase.customerUC.GetCustomerByUserId(userId)
+	if err != nil {
+		return []model.Transfer{}, err
+	}
+
+	transfers, err := usecase.repo.ListByCustomerId(customer.ID, params)
+	if err != nil {
+		return []model.Transfer{}, err
+	}
+	for i := range transfers {
+		transfers[i].Direction = model.TransferDirectionReceived
+		if transfers[i].SenderCustomerId == customer.ID {
+			transfers[i].Direction = model.TransferDirectionSent
+		}
+	}
+	return transfers, nil
+}
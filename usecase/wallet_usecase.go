@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"github.com/albar2305/payment-app/model"
+	"github.com/albar2305/payment-app/repository"
+	"github.com/albar2305/payment-app/utils/common"
+)
+
+// WalletUseCase lets support freeze customer and merchant wallets and hold
+// parts of their balance. ownerType is model.AccountOwnerCustomer or
+// model.AccountOwnerMerchant and actorId the user taking the action.
+type WalletUseCase interface {
+	FreezeWallet(ownerType string, ownerId string, actorId string, payload model.FreezeWalletRequest) (model.WalletFreeze, error)
+	UnfreezeWallet(ownerType string, ownerId string, actorId string, payload model.WalletActionRequest) (model.WalletFreeze, error)
+	ListFreezes(ownerType string, ownerId string) ([]model.WalletFreeze, error)
+	PlaceHold(ownerType string, ownerId string, actorId string, payload model.PlaceHoldRequest) (model.WalletHold, error)
+	ReleaseHold(id string, actorId string, payload model.WalletActionRequest) (model.WalletHold, error)
+	GetHold(id string) (model.WalletHold, error)
+	ListHolds(ownerType string, ownerId string) ([]model.WalletHold, error)
+}
+
+type walletUseCase struct {
+	repo repository.WalletRepository
+}
+
+func NewWalletUseCase(repo repository.WalletRepository) WalletUseCase {
+	return &walletUseCase{
+		repo: repo,
+	}
+}
+
+// FreezeWallet implements WalletUseCase.
+func (usecase *walletUseCase) FreezeWallet(ownerType string, ownerId string, actorId string, payload model.FreezeWalletRequest) (model.WalletFreeze, error) {
+	return usecase.repo.Freeze(model.WalletFreeze{
+		ID:            common.GenerateID(),
+		OwnerType:     ownerType,
+		OwnerID:       ownerId,
+		BlockIncoming: payload.BlockIncoming,
+		Reason:        payload.Reason,
+		FrozenBy:      actorId,
+	})
+}
+
+// UnfreezeWallet implements WalletUseCase. Holds stay until they are
+// released on their own.
+func (usecase *walletUseCase) UnfreezeWallet(ownerType string, ownerId string, actorId string, payload model.WalletActionRequest) (model.WalletFreeze, error) {
+	return usecase.repo.Unfreeze(ownerType, ownerId, actorId, payload.Reason)
+}
+
+// ListFreezes implements WalletUseCase.
+func (usecase *walletUseCase) ListFreezes(ownerType string, ownerId string) ([]model.WalletFreeze, error) {
+	return usecase.repo.ListFreezes(ownerType, ownerId)
+}
+
+// PlaceHold implements WalletUseCase.
+func (usecase *walletUseCase) PlaceHold(ownerType string, ownerId string, actorId string, payload model.PlaceHoldRequest) (model.WalletHold, error) {
+	if payload.Amount <= 0 {
+		return model.WalletHold{}, common.ErrInvalidAmount
+	}
+	return usecase.repo.PlaceHold(model.WalletHold{
+		ID:        common.GenerateID(),
+		OwnerType: ownerType,
+		OwnerID:   ownerId,
+		Amount:    payload.Amount,
+		Reason:    payload.Reason,
+		CreatedBy: actorId,
+	})
+}
+
+// ReleaseHold implements WalletUseCase.
+func (usecase *walletUseCase) ReleaseHold(id string, actorId string, payload model.WalletActionRequest) (model.WalletHold, error) {
+	return usecase.repo.ReleaseHold(id, actorId, payload.Reason)
+}
+
+// GetHold implements WalletUseCase.
+func (usecase *walletUseCase) GetHold(id string) (model.WalletHold, error) {
+	return usecase.repo.GetHold(id)
+}
+
+// ListHolds implements WalletUseCase.
+func (usecase *walletUseCase) ListHolds(ownerType string, ownerId string) ([]model.WalletHold, error) {
+	return usecase.repo.ListHolds(ownerType, ownerId)
+}
@@ -0,0 +1,39 @@
+package bank
+
+import (
+	"strings"
+	"sync"
+)
+
+// FakeProvider is an in-memory Provider for local development. Transfers to
+// account numbers ending in "000" are rejected so the failure path can be
+// exercised.
+type FakeProvider struct {
+	mu        sync.Mutex
+	transfers map[string]TransferResult
+}
+
+// NewFakeProvider creates a new FakeProvider
+func NewFakeProvider() Provider {
+	return &FakeProvider{transfers: map[string]TransferResult{}}
+}
+
+// Transfer implements Provider.
+func (p *FakeProvider) Transfer(req TransferRequest) (TransferResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.transfers[req.Reference]; ok {
+		return result, nil
+	}
+	result := TransferResult{
+		ProviderReference: "FAKE-" + req.Reference,
+		Status:            TransferStatusSuccess,
+	}
+	if strings.HasSuffix(req.AccountNumber, "000") {
+		result.Status = TransferStatusFailed
+		result.FailureReason = "account rejected by bank"
+	}
+	p.transfers[req.Reference] = result
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package bank
+
+const (
+	TransferStatusSuccess = "success"
+	TransferStatusFailed  = "failed"
+)
+
+type TransferRequest struct {
+	// Reference identifies the payout; providers must treat repeated
+	// requests with the same reference as one transfer.
+	Reference     string
+	BankCode      string
+	AccountNumber string
+	AccountName   string
+	Amount        int64
+	Currency      string
+}
+
+type TransferResult struct {
+	ProviderReference string
+	Status            string
+	FailureReason     string
+}
+
+type Provider interface {
+	// Transfer sends money to a bank account. A returned error means the
+	// outcome is unknown; a failed result means the bank rejected it.
+	Transfer(req TransferRequest) (TransferResult, error)
+}
@@ -1,7 +1,20 @@
 package common
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// codedError is an error that carries a machine readable code for clients.
+type codedError interface {
+	ErrorCode() string
+}
 
 func ErrorResponse(err error) gin.H {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return gin.H{"error": err.Error(), "code": coded.ErrorCode()}
+	}
 	return gin.H{"error": err.Error()}
 }
@@ -1,7 +1,30 @@
 package common
 
-import "github.com/google/uuid"
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/google/uuid"
+)
 
 func GenerateID() string {
 	return uuid.New().String()
 }
+
+// codeAlphabet leaves out characters that are easy to mistype: 0, O, 1 and I.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateCode returns a random code of length n that people can read out
+// and type, e.g. for payment links.
+func GenerateCode(n int) string {
+	code := make([]byte, n)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range code {
+		k, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		code[i] = codeAlphabet[k.Int64()]
+	}
+	return string(code)
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrLimitExceeded = errors.New("spending limit exceeded")
+
+// Codes of the limit a LimitError hit.
+const (
+	LimitCodeSingleAmount  = "single_amount_limit"
+	LimitCodeDailyAmount   = "daily_amount_limit"
+	LimitCodeMonthlyAmount = "monthly_amount_limit"
+	LimitCodeHourlyCount   = "hourly_count_limit"
+)
+
+// LimitError says which spending limit rejected an operation. It matches
+// ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	Code   string
+	Kind   string
+	Limit  int64
+	Used   int64
+	Amount int64
+}
+
+func (e *LimitError) Error() string {
+	switch e.Code {
+	case LimitCodeSingleAmount:
+		return fmt.Sprintf("%s: %s amount %d is above the limit of %d", e.Code, e.Kind, e.Amount, e.Limit)
+	case LimitCodeHourlyCount:
+		return fmt.Sprintf("%s: %d %s operations in the last hour, the limit is %d", e.Code, e.Used, e.Kind, e.Limit)
+	default:
+		return fmt.Sprintf("%s: %s amount %d on top of %d used would exceed the limit of %d", e.Code, e.Kind, e.Amount, e.Used, e.Limit)
+	}
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ErrorCode is returned to clients next to the error message.
+func (e *LimitError) ErrorCode() string {
+	return e.Code
+}
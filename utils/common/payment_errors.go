@@ -0,0 +1,33 @@
+package common
+
+import "errors"
+
+var (
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrInvalidAmount       = errors.New("amount must be greater than zero")
+	ErrRefundExceeded      = errors.New("refund exceeds the refundable amount of the transaction")
+	ErrCaptureExceeded     = errors.New("capture exceeds the authorized amount")
+	ErrInvalidStatus       = errors.New("transaction status does not allow this operation")
+	ErrSelfTransfer        = errors.New("cannot transfer to the same wallet")
+	ErrCurrencyMismatch    = errors.New("wallet currencies do not match")
+	ErrUnsupportedCurrency = errors.New("currency is not supported")
+	ErrFxRateNotFound      = errors.New("no exchange rate between the currencies")
+	ErrInvalidFxRate       = errors.New("invalid exchange rate")
+	ErrNoPendingPayouts    = errors.New("no pending payouts to settle")
+	ErrInvalidFeeRule      = errors.New("invalid fee rule")
+	ErrInvalidInvoice      = errors.New("invalid invoice")
+	ErrInvalidQRPayload    = errors.New("invalid QR payload")
+	ErrInvalidSplit        = errors.New("invalid split payment")
+	ErrInvalidLimit        = errors.New("invalid spending limit")
+	ErrInvalidFraudRule    = errors.New("invalid fraud rule")
+	ErrPaymentBlocked      = errors.New("payment blocked by risk screening")
+	ErrScreeningOutdated   = errors.New("another payment was made while the payment was screened")
+	ErrWalletFrozen        = errors.New("wallet is frozen")
+	ErrInvalidPromo        = errors.New("promo code cannot be used")
+	ErrInvalidDispute      = errors.New("invalid dispute")
+	ErrInvalidPeriod       = errors.New("invalid period")
+	ErrInvalidReport       = errors.New("invalid report")
+	ErrInvalidFilter       = errors.New("invalid filter")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrUnbalancedEntry     = errors.New("journal entry postings must sum to zero")
+)
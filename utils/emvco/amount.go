@@ -0,0 +1,58 @@
+package emvco
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// numericCurrencies maps ISO 4217 alphabetic codes to the numeric codes the
+// payload uses.
+var numericCurrencies = map[string]string{
+	"IDR": "360",
+	"SGD": "702",
+	"USD": "840",
+}
+
+// NumericCurrency returns the ISO 4217 numeric code of an alphabetic one.
+func NumericCurrency(code string) (string, bool) {
+	numeric, ok := numericCurrencies[code]
+	return numeric, ok
+}
+
+// AlphaCurrency returns the ISO 4217 alphabetic code of a numeric one.
+func AlphaCurrency(numeric string) (string, bool) {
+	for code, n := range numericCurrencies {
+		if n == numeric {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// FormatAmount writes an amount in minor units as the decimal string of the
+// transaction amount field, e.g. 1050 with exponent 2 is "10.50".
+func FormatAmount(minor int64, exponent int) string {
+	s := strconv.FormatInt(minor, 10)
+	if exponent == 0 {
+		return s
+	}
+	if len(s) <= exponent {
+		s = strings.Repeat("0", exponent-len(s)+1) + s
+	}
+	return s[:len(s)-exponent] + "." + s[len(s)-exponent:]
+}
+
+// ParseAmount reads a transaction amount field into minor units.
+func ParseAmount(s string, exponent int) (int64, error) {
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" || len(fraction) > exponent {
+		return 0, fmt.Errorf("%w: bad amount %q", ErrInvalidPayload, s)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || minor < 0 {
+		return 0, fmt.Errorf("%w: bad amount %q", ErrInvalidPayload, s)
+	}
+	return minor, nil
+}
@@ -0,0 +1,207 @@
+// Package emvco builds and parses merchant presented QR payloads in the
+// EMVCo format used by QRIS: a string of ID, length and value fields
+// closed by a CRC-16 checksum.
+package emvco
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Root field IDs.
+const (
+	IDPayloadFormat       = "00"
+	IDPointOfInitiation   = "01"
+	IDMerchantAccount     = "26"
+	IDMerchantCategory    = "52"
+	IDTransactionCurrency = "53"
+	IDTransactionAmount   = "54"
+	IDCountryCode         = "58"
+	IDMerchantName        = "59"
+	IDMerchantCity        = "60"
+	IDAdditionalData      = "62"
+	IDCRC                 = "63"
+)
+
+// Sub field IDs of the merchant account and additional data templates.
+const (
+	IDGloballyUniqueID = "00"
+	IDMerchantID       = "01"
+	IDBillNumber       = "01"
+)
+
+const (
+	// PointOfInitiationStatic codes can be paid many times; the customer
+	// enters the amount.
+	PointOfInitiationStatic = "11"
+	// PointOfInitiationDynamic codes carry the amount and are paid once.
+	PointOfInitiationDynamic = "12"
+)
+
+var (
+	ErrInvalidPayload   = errors.New("emvco: invalid payload")
+	ErrChecksumMismatch = errors.New("emvco: checksum mismatch")
+)
+
+// Payload holds the fields this service reads and writes. Currency is the
+// ISO 4217 numeric code and Amount a decimal string, empty on static codes.
+type Payload struct {
+	PointOfInitiation string
+	GloballyUniqueID  string
+	MerchantID        string
+	MerchantCategory  string
+	Currency          string
+	Amount            string
+	CountryCode       string
+	MerchantName      string
+	MerchantCity      string
+	BillNumber        string
+}
+
+// Encode returns the payload string, including the trailing CRC field.
+func Encode(p Payload) (string, error) {
+	var b strings.Builder
+	fields := []struct{ id, value string }{
+		{IDPayloadFormat, "01"},
+		{IDPointOfInitiation, p.PointOfInitiation},
+		{IDMerchantAccount, template(
+			field{IDGloballyUniqueID, p.GloballyUniqueID},
+			field{IDMerchantID, p.MerchantID},
+		)},
+		{IDMerchantCategory, p.MerchantCategory},
+		{IDTransactionCurrency, p.Currency},
+		{IDTransactionAmount, p.Amount},
+		{IDCountryCode, p.CountryCode},
+		{IDMerchantName, p.MerchantName},
+		{IDMerchantCity, p.MerchantCity},
+		{IDAdditionalData, template(field{IDBillNumber, p.BillNumber})},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		if utf8.RuneCountInString(f.value) > 99 {
+			return "", fmt.Errorf("%w: field %s is longer than 99 characters", ErrInvalidPayload, f.id)
+		}
+		writeField(&b, f.id, f.value)
+	}
+
+	b.WriteString(IDCRC + "04")
+	b.WriteString(fmt.Sprintf("%04X", CRC16([]byte(b.String()))))
+	return b.String(), nil
+}
+
+// Parse checks the CRC of s and decodes its fields. Fields this package does
+// not know are ignored.
+func Parse(s string) (Payload, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 8 || s[len(s)-8:len(s)-4] != IDCRC+"04" {
+		return Payload{}, fmt.Errorf("%w: missing CRC field", ErrInvalidPayload)
+	}
+	want, err := strconv.ParseUint(s[len(s)-4:], 16, 16)
+	if err != nil {
+		return Payload{}, fmt.Errorf("%w: malformed CRC", ErrInvalidPayload)
+	}
+	if uint16(want) != CRC16([]byte(s[:len(s)-4])) {
+		return Payload{}, ErrChecksumMismatch
+	}
+
+	root, err := parseFields(s[:len(s)-8])
+	if err != nil {
+		return Payload{}, err
+	}
+	if root[IDPayloadFormat] != "01" {
+		return Payload{}, fmt.Errorf("%w: unsupported payload format %q", ErrInvalidPayload, root[IDPayloadFormat])
+	}
+	account, err := parseFields(root[IDMerchantAccount])
+	if err != nil {
+		return Payload{}, err
+	}
+	additional, err := parseFields(root[IDAdditionalData])
+	if err != nil {
+		return Payload{}, err
+	}
+
+	return Payload{
+		PointOfInitiation: root[IDPointOfInitiation],
+		GloballyUniqueID:  account[IDGloballyUniqueID],
+		MerchantID:        account[IDMerchantID],
+		MerchantCategory:  root[IDMerchantCategory],
+		Currency:          root[IDTransactionCurrency],
+		Amount:            root[IDTransactionAmount],
+		CountryCode:       root[IDCountryCode],
+		MerchantName:      root[IDMerchantName],
+		MerchantCity:      root[IDMerchantCity],
+		BillNumber:        additional[IDBillNumber],
+	}, nil
+}
+
+// CRC16 is CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF),
+// computed over the payload up to and including the ID and length of the
+// CRC field.
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+type field struct {
+	id, value string
+}
+
+// template encodes the non empty sub fields of a template, or returns "" when
+// all are empty.
+func template(fields ...field) string {
+	var b strings.Builder
+	for _, f := range fields {
+		if f.value != "" {
+			writeField(&b, f.id, f.value)
+		}
+	}
+	return b.String()
+}
+
+// writeField writes one field. Lengths count characters, not bytes, so
+// merchant names in other scripts keep their length.
+func writeField(b *strings.Builder, id string, value string) {
+	b.WriteString(id)
+	b.WriteString(fmt.Sprintf("%02d", utf8.RuneCountInString(value)))
+	b.WriteString(value)
+}
+
+func parseFields(s string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(s) > 0 {
+		if len(s) < 4 {
+			return nil, fmt.Errorf("%w: truncated field", ErrInvalidPayload)
+		}
+		id := s[:2]
+		length, err := strconv.Atoi(s[2:4])
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("%w: bad length in field %s", ErrInvalidPayload, id)
+		}
+		end := 4
+		for n := 0; n < length; n++ {
+			if end >= len(s) {
+				return nil, fmt.Errorf("%w: bad length in field %s", ErrInvalidPayload, id)
+			}
+			_, size := utf8.DecodeRuneInString(s[end:])
+			end += size
+		}
+		fields[id] = s[4:end]
+		s = s[end:]
+	}
+	return fields, nil
+}
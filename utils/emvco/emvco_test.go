@@ -0,0 +1,161 @@
+package emvco
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// The merchant presented mode sample of the EMVCo QR Code Specification for
+// Payment Systems, with its published CRC A13A.
+const emvcoSample = "00020101021229300012D156000000000510A93FO3230Q31280012D15600000001030812345678520441115802CN5914BEST TRANSPORT6007BEIJING64200002ZH0104最佳运输0202北京540523.7253031565502016233030412340603***0708A60086670902ME91320016A0112233449988770708123456786304A13A"
+
+// A static QRIS payload of a small merchant, with its CRC 58C7.
+const qrisSample = "00020101021126570011ID.DANA.WWW011893600915302259148102090225914810303UMI51440014ID.CO.QRIS.WWW0215ID10200176114730303UMI5204581253033605802ID5922Warung Sayur Bu Sugeng6010Kab. Demak610559567630458C7"
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		data string
+		want uint16
+	}{
+		// the check value of CRC-16/CCITT-FALSE
+		{"123456789", 0x29B1},
+		{emvcoSample[:len(emvcoSample)-4], 0xA13A},
+		{qrisSample[:len(qrisSample)-4], 0x58C7},
+	}
+	for _, tt := range tests {
+		if got := CRC16([]byte(tt.data)); got != tt.want {
+			t.Errorf("CRC16(%q) = %04X, want %04X", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    Payload
+	}{
+		{
+			name:    "emvco sample",
+			payload: emvcoSample,
+			want: Payload{
+				PointOfInitiation: PointOfInitiationDynamic,
+				MerchantCategory:  "4111",
+				Currency:          "156",
+				Amount:            "23.72",
+				CountryCode:       "CN",
+				MerchantName:      "BEST TRANSPORT",
+				MerchantCity:      "BEIJING",
+			},
+		},
+		{
+			name:    "qris sample",
+			payload: qrisSample,
+			want: Payload{
+				PointOfInitiation: PointOfInitiationStatic,
+				GloballyUniqueID:  "ID.DANA.WWW",
+				MerchantID:        "936009153022591481",
+				MerchantCategory:  "5812",
+				Currency:          "360",
+				CountryCode:       "ID",
+				MerchantName:      "Warung Sayur Bu Sugeng",
+				MerchantCity:      "Kab. Demak",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.payload)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    error
+	}{
+		{"changed amount", emvcoSample[:len(emvcoSample)-1] + "B", ErrChecksumMismatch},
+		{"no crc", emvcoSample[:len(emvcoSample)-8], ErrInvalidPayload},
+		{"field longer than payload", withCRC("000201011299"), ErrInvalidPayload},
+		{"unknown payload format", withCRC("000202"), ErrInvalidPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.payload); !errors.Is(err, tt.want) {
+				t.Errorf("Parse error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	p := Payload{
+		PointOfInitiation: PointOfInitiationDynamic,
+		GloballyUniqueID:  "ID.CO.PAYMENTAPP",
+		MerchantID:        "M-1",
+		MerchantCategory:  "5812",
+		Currency:          "360",
+		Amount:            "15000",
+		CountryCode:       "ID",
+		MerchantName:      "Kedai Kopi Ñusantara",
+		MerchantCity:      "JAKARTA",
+		BillNumber:        "INV42",
+	}
+	s, err := Encode(p)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	// lengths count characters, so the Ñ counts as one
+	const wantName = "5920Kedai Kopi Ñusantara"
+	if !strings.Contains(s, wantName) {
+		t.Errorf("Encode = %q, want it to contain %q", s, wantName)
+	}
+	got, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got != p {
+		t.Errorf("Parse(Encode(p)) = %+v, want %+v", got, p)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		exponent int
+		text     string
+	}{
+		{1050, 2, "10.50"},
+		{5, 2, "0.05"},
+		{15000, 0, "15000"},
+		{0, 2, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.minor, tt.exponent); got != tt.text {
+			t.Errorf("FormatAmount(%d, %d) = %q, want %q", tt.minor, tt.exponent, got, tt.text)
+		}
+		if got, err := ParseAmount(tt.text, tt.exponent); err != nil || got != tt.minor {
+			t.Errorf("ParseAmount(%q, %d) = %d, %v, want %d", tt.text, tt.exponent, got, err, tt.minor)
+		}
+	}
+	for _, s := range []string{"", ".5", "1.234", "-1", "1e3"} {
+		if _, err := ParseAmount(s, 2); !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("ParseAmount(%q) error = %v, want ErrInvalidPayload", s, err)
+		}
+	}
+}
+
+func withCRC(s string) string {
+	s += IDCRC + "04"
+	return s + fmt.Sprintf("%04X", CRC16([]byte(s)))
+}
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the webhook body.
+const SignatureHeader = "X-Gateway-Signature"
+
+const (
+	EventStatusPaid    = "paid"
+	EventStatusFailed  = "failed"
+	EventStatusExpired = "expired"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+type ChargeRequest struct {
+	OrderID   string
+	Amount    int64
+	Currency  string
+	ExpiresAt time.Time
+}
+
+type Charge struct {
+	Reference   string
+	CheckoutURL string
+}
+
+// Event is a payment status update the gateway sends to the webhook.
+type Event struct {
+	Reference     string `json:"reference"`
+	OrderID       string `json:"order_id"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+type Gateway interface {
+	// CreateCharge asks the gateway to collect a payment for an order.
+	CreateCharge(req ChargeRequest) (Charge, error)
+
+	// ParseWebhook verifies the signature of a webhook body and decodes it.
+	ParseWebhook(body []byte, signature string) (Event, error)
+}
+
+// Sign returns the signature of body for the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature in constant time.
+func Verify(secret string, body []byte, signature string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrChargeNotFound = errors.New("charge not found")
+
+// Simulator is a Gateway for local development. Charges live in memory and
+// Complete delivers a signed webhook the way a real gateway would.
+type Simulator struct {
+	secret     string
+	baseURL    string
+	webhookURL string
+	client     *http.Client
+
+	mu      sync.Mutex
+	charges map[string]ChargeRequest
+}
+
+// NewSimulator creates a new Simulator. Checkout links point at baseURL and
+// webhooks are posted to webhookURL.
+func NewSimulator(secret string, baseURL string, webhookURL string) *Simulator {
+	return &Simulator{
+		secret:     secret,
+		baseURL:    baseURL,
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		charges:    map[string]ChargeRequest{},
+	}
+}
+
+// CreateCharge implements Gateway.
+func (s *Simulator) CreateCharge(req ChargeRequest) (Charge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reference := "SIM-" + uuid.New().String()
+	s.charges[reference] = req
+	return Charge{
+		Reference:   reference,
+		CheckoutURL: fmt.Sprintf("%s/api/v1/simulator/gateway/charges/%s", s.baseURL, reference),
+	}, nil
+}
+
+// ParseWebhook implements Gateway.
+func (s *Simulator) ParseWebhook(body []byte, signature string) (Event, error) {
+	if err := Verify(s.secret, body, signature); err != nil {
+		return Event{}, err
+	}
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// Complete settles a charge with the given status and posts the signed
+// webhook.
+func (s *Simulator) Complete(reference string, status string) (Event, error) {
+	s.mu.Lock()
+	req, ok := s.charges[reference]
+	s.mu.Unlock()
+	if !ok {
+		return Event{}, ErrChargeNotFound
+	}
+
+	event := Event{
+		Reference: reference,
+		OrderID:   req.OrderID,
+		Status:    status,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+	}
+	if status == EventStatusFailed {
+		event.FailureReason = "payment declined by simulator"
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return Event{}, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return Event{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(SignatureHeader, Sign(s.secret, body))
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return Event{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return Event{}, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return event, nil
+}
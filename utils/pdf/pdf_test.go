@@ -0,0 +1,182 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	doc := New(`Statement (May) C:\x`)
+	first := doc.AddPage()
+	first.Text(40, 800, Bold, 16, `Refund (partial) of a\b`)
+	first.Line(40, 790, 555, 790, 0.5)
+	second := doc.AddPage()
+	second.TextRight(555, 800, Regular, 10, "Café — 1.000")
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := buf.Bytes()
+	if n != int64(len(out)) {
+		t.Errorf("WriteTo = %d, wrote %d bytes", n, len(out))
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing header: %q", out[:16])
+	}
+
+	objects := readXref(t, out)
+	// catalog, pages, two fonts, info, then a page and its content per page
+	if len(objects) != 5+2*2 {
+		t.Fatalf("%d objects in xref, want 9", len(objects))
+	}
+	for i, offset := range objects {
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref offset %d of object %d points at %q", offset, i+1, out[offset:offset+len(want)])
+		}
+	}
+
+	info := object(out, objects, 5)
+	title := regexp.MustCompile(`/Title \(((?:\\.|[^\\)])*)\)`).FindSubmatch(info)
+	if title == nil {
+		t.Fatalf("no title in %q", info)
+	}
+	if got := unescape(title[1]); got != `Statement (May) C:\x` {
+		t.Errorf("title = %q", got)
+	}
+
+	tests := []struct {
+		object int
+		want   string
+	}{
+		{7, `Refund (partial) of a\b`},
+		// WinAnsi keeps é and replaces the dash it cannot encode
+		{9, "Caf\xe9 ? 1.000"},
+	}
+	for _, tt := range tests {
+		content := stream(t, object(out, objects, tt.object))
+		shown := regexp.MustCompile(`\(((?:\\.|[^\\)])*)\) Tj`).FindSubmatch(content)
+		if shown == nil {
+			t.Fatalf("object %d: no text in %q", tt.object, content)
+		}
+		if got := unescape(shown[1]); got != tt.want {
+			t.Errorf("object %d: text = %q, want %q", tt.object, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"(a)", `\(a\)`},
+		{`back\slash`, `back\\slash`},
+		{`\)`, `\\\)`},
+		{"Rp 10.000 ✓", "Rp 10.000 ?"},
+	}
+	for _, tt := range tests {
+		if got := string(encodeText(tt.in)); got != tt.want {
+			t.Errorf("encodeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	s := "A merchant name that is far too long for its column"
+	got := Truncate(Regular, 10, s, 100)
+	if w := TextWidth(Regular, 10, got); w > 100 {
+		t.Errorf("Truncate = %q, %v points wide", got, w)
+	}
+	if got == s || got[len(got)-3:] != "..." {
+		t.Errorf("Truncate = %q, want it shortened with ...", got)
+	}
+	if got := Truncate(Regular, 10, "short", 100); got != "short" {
+		t.Errorf("Truncate = %q, want it unchanged", got)
+	}
+}
+
+// readXref follows startxref to the cross-reference table and returns the
+// offsets of the objects in use, object 1 first.
+func readXref(t *testing.T, out []byte) []int {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("no startxref at the end of the file")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	table := out[start:]
+	header := regexp.MustCompile(`^xref\n0 (\d+)\n0000000000 65535 f \n`).FindSubmatch(table)
+	if header == nil {
+		t.Fatalf("startxref %d does not point at an xref table", start)
+	}
+	size, _ := strconv.Atoi(string(header[1]))
+	table = table[len(header[0]):]
+
+	var offsets []int
+	for i := 1; i < size; i++ {
+		// every entry is exactly 20 bytes
+		entry := table[:20]
+		table = table[20:]
+		if !regexp.MustCompile(`^\d{10} 00000 n \n$`).Match(entry) {
+			t.Fatalf("bad xref entry %q", entry)
+		}
+		offset, _ := strconv.Atoi(string(entry[:10]))
+		offsets = append(offsets, offset)
+	}
+	trailer := regexp.MustCompile(`^trailer\n<< /Size (\d+) `).FindSubmatch(table)
+	if trailer == nil || string(trailer[1]) != strconv.Itoa(size) {
+		t.Fatalf("trailer does not follow the xref table with /Size %d", size)
+	}
+	return offsets
+}
+
+// object returns the body of object n.
+func object(out []byte, offsets []int, n int) []byte {
+	body := out[offsets[n-1]:]
+	body = body[bytes.IndexByte(body, '\n')+1:]
+	return body[:bytes.Index(body, []byte("\nendobj\n"))]
+}
+
+// stream inflates the content stream in an object body, reading exactly
+// /Length bytes.
+func stream(t *testing.T, body []byte) []byte {
+	t.Helper()
+	m := regexp.MustCompile(`^<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindSubmatch(body)
+	if m == nil {
+		t.Fatalf("not a content stream: %q", body)
+	}
+	length, _ := strconv.Atoi(string(m[1]))
+	data := body[len(m[0]):]
+	if !bytes.Equal(data[length:], []byte("\nendstream")) {
+		t.Fatalf("/Length %d does not end at endstream", length)
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data[:length]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// unescape reads the inside of a PDF literal string.
+func unescape(b []byte) string {
+	var out []byte
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) {
+			i++
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
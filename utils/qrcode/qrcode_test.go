@@ -0,0 +1,339 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			// ISO/IEC 18004 Annex I, "01234567" as version 1-M
+			name: "iso annex",
+			data: []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			want: []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55},
+		},
+		{
+			// "HELLO WORLD" as version 1-M
+			name: "hello world",
+			data: []byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			want: []byte{0xC4, 0x23, 0x27, 0x77, 0xEB, 0xD7, 0xE7, 0xE2, 0x5D, 0x17},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reedSolomonRemainder(tt.data, reedSolomonDivisor(len(tt.want)))
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("reedSolomonRemainder = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+// formatInfoM is the published format information of level M for masks 0
+// to 7, already masked with 101010000010010.
+var formatInfoM = [8]int{
+	0b101010000010010,
+	0b101000100100101,
+	0b101111001111100,
+	0b101101101001011,
+	0b100010111111001,
+	0b100000011001110,
+	0b100111110010111,
+	0b100101010100000,
+}
+
+func TestFormatBits(t *testing.T) {
+	c := &Code{Version: 1, Size: 21}
+	c.modules = newGrid(c.Size)
+	c.isFunction = newGrid(c.Size)
+	for mask, want := range formatInfoM {
+		c.drawFormatBits(mask)
+		first, second := readFormatBits(c)
+		if first != want || second != want {
+			t.Errorf("mask %d: format bits %015b and %015b, want %015b", mask, first, second, want)
+		}
+		if !c.Dark(8, c.Size-8) {
+			t.Errorf("mask %d: dark module is light", mask)
+		}
+	}
+}
+
+// versionInfo is the published version information of versions 7 to 20.
+var versionInfo = map[int]int{
+	7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3, 11: 0x0BBF6, 12: 0x0C762, 13: 0x0D847,
+	14: 0x0E60D, 15: 0x0F928, 16: 0x10B78, 17: 0x1145D, 18: 0x12A17, 19: 0x13532, 20: 0x149A6,
+}
+
+func TestVersionBits(t *testing.T) {
+	for version, want := range versionInfo {
+		c := &Code{Version: version, Size: version*4 + 17}
+		c.modules = newGrid(c.Size)
+		c.isFunction = newGrid(c.Size)
+		c.drawVersion()
+		// the block above the bottom left finder and its transpose left of
+		// the top right finder, least significant bit first
+		var below, right int
+		for i := 17; i >= 0; i-- {
+			below = below<<1 | module(c, i/3, c.Size-11+i%3)
+			right = right<<1 | module(c, c.Size-11+i%3, i/3)
+		}
+		if below != want || right != want {
+			t.Errorf("version %d: version bits %018b and %018b, want %018b", version, below, right, want)
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		6:  {6, 34},
+		7:  {6, 22, 38},
+		14: {6, 26, 46, 66},
+		15: {6, 26, 48, 70},
+		16: {6, 26, 50, 74},
+		20: {6, 34, 62, 90},
+	}
+	for version, want := range tests {
+		got := alignmentPositions(version)
+		if len(got) != len(want) {
+			t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+				break
+			}
+		}
+	}
+}
+
+// byteCapacityM is the published number of bytes each version holds in byte
+// mode at level M.
+var byteCapacityM = [maxVersion + 1]int{
+	0, 14, 26, 42, 62, 84, 106, 122, 152, 180, 213,
+	251, 287, 331, 362, 412, 450, 504, 560, 624, 666,
+}
+
+func TestEncodeVersion(t *testing.T) {
+	for version := minVersion; version <= maxVersion; version++ {
+		c, err := Encode(bytes.Repeat([]byte("a"), byteCapacityM[version]))
+		if err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+		if c.Version != version || c.Size != version*4+17 {
+			t.Errorf("%d bytes: version %d size %d, want version %d", byteCapacityM[version], c.Version, c.Size, version)
+		}
+	}
+	if _, err := Encode(bytes.Repeat([]byte("a"), byteCapacityM[maxVersion]+1)); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("Encode error = %v, want ErrDataTooLong", err)
+	}
+}
+
+// TestEncodeDecodes reads symbols back the way a scanner does: format
+// information, unmasking, codeword placement, block interleaving and error
+// correction, then the byte mode segment.
+func TestEncodeDecodes(t *testing.T) {
+	payloads := []string{
+		"HELLO WORLD",
+		"00020101021126570011ID.DANA.WWW011893600915302259148102090225914810303UMI5204581253033605802ID5922Warung Sayur Bu Sugeng6010Kab. Demak6304ABCD",
+		string(bytes.Repeat([]byte("0123456789abcdef"), 20)),
+		string(bytes.Repeat([]byte("x"), byteCapacityM[maxVersion])),
+	}
+	for _, payload := range payloads {
+		c, err := Encode([]byte(payload))
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(payload), err)
+		}
+		got, err := decode(c)
+		if err != nil {
+			t.Fatalf("version %d: %v", c.Version, err)
+		}
+		if string(got) != payload {
+			t.Errorf("version %d: decoded %q, want %q", c.Version, got, payload)
+		}
+	}
+}
+
+func TestFinderPatterns(t *testing.T) {
+	c, err := Encode([]byte("HELLO WORLD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := []string{
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.###.#",
+		"#.###.#",
+		"#.....#",
+		"#######",
+	}
+	for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+		for dy, row := range finder {
+			for dx, m := range row {
+				if c.Dark(corner[0]+dx, corner[1]+dy) != (m == '#') {
+					t.Fatalf("finder at %v differs at %d, %d", corner, dx, dy)
+				}
+			}
+		}
+	}
+	for i := 8; i < c.Size-8; i++ {
+		if c.Dark(i, 6) != (i%2 == 0) || c.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("timing pattern differs at %d", i)
+		}
+	}
+}
+
+// readFormatBits returns both copies of the format information, most
+// significant bit first as the standard prints them.
+func readFormatBits(c *Code) (int, int) {
+	var first, second int
+	// first copy: right along row 8 then up column 8, around the top left
+	// finder, skipping the timing pattern
+	for _, p := range [][2]int{
+		{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8},
+		{8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0},
+	} {
+		first = first<<1 | module(c, p[0], p[1])
+	}
+	// second copy: up column 8 by the bottom left finder, then right along
+	// row 8 by the top right one
+	for i := 0; i < 7; i++ {
+		second = second<<1 | module(c, 8, c.Size-1-i)
+	}
+	for i := 8; i > 0; i-- {
+		second = second<<1 | module(c, c.Size-i, 8)
+	}
+	return first, second
+}
+
+// decode reads the byte mode data of a level M symbol.
+func decode(c *Code) ([]byte, error) {
+	first, second := readFormatBits(c)
+	if first != second {
+		return nil, errors.New("format copies differ")
+	}
+	mask := -1
+	for m, info := range formatInfoM {
+		if info == first {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return nil, errors.New("not a level M symbol")
+	}
+
+	// unmask a copy so the symbol under test is left as it was
+	u := &Code{Version: c.Version, Size: c.Size, modules: newGrid(c.Size), isFunction: newGrid(c.Size)}
+	u.drawFunctionPatterns()
+	for y := range u.modules {
+		for x := range u.modules[y] {
+			if !u.isFunction[y][x] {
+				u.modules[y][x] = c.modules[y][x]
+			}
+		}
+	}
+	u.applyMask(mask)
+
+	// codewords in placement order: two columns at a time from the right,
+	// alternately upwards and downwards
+	var raw []byte
+	var cur byte
+	n := 0
+	upward := true
+	for right := u.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for i := 0; i < u.Size; i++ {
+			y := i
+			if upward {
+				y = u.Size - 1 - i
+			}
+			for x := right; x >= right-1; x-- {
+				if u.isFunction[y][x] {
+					continue
+				}
+				cur = cur<<1 | byte(module(u, x, y))
+				if n++; n%8 == 0 {
+					raw = append(raw, cur)
+					cur = 0
+				}
+			}
+		}
+		upward = !upward
+	}
+	if want := numRawDataModules(c.Version) / 8; len(raw) != want {
+		return nil, errors.New("wrong number of codewords")
+	}
+
+	// de-interleave: short blocks come first and all blocks end with the same
+	// number of error correction codewords
+	blocks := numBlocks[c.Version]
+	eccLen := eccCodewordsPerBlock[c.Version]
+	shortData := len(raw)/blocks - eccLen
+	numShort := blocks - len(raw)%blocks
+	dataBlocks := make([][]byte, blocks)
+	k := 0
+	for i := 0; i < shortData+1; i++ {
+		for b := 0; b < blocks; b++ {
+			if i == shortData && b < numShort {
+				continue
+			}
+			dataBlocks[b] = append(dataBlocks[b], raw[k])
+			k++
+		}
+	}
+	eccBlocks := make([][]byte, blocks)
+	for i := 0; i < eccLen; i++ {
+		for b := range eccBlocks {
+			eccBlocks[b] = append(eccBlocks[b], raw[k])
+			k++
+		}
+	}
+	divisor := reedSolomonDivisor(eccLen)
+	var data []byte
+	for b := range dataBlocks {
+		if !bytes.Equal(reedSolomonRemainder(dataBlocks[b], divisor), eccBlocks[b]) {
+			return nil, errors.New("error correction codewords do not match")
+		}
+		data = append(data, dataBlocks[b]...)
+	}
+
+	// byte mode indicator, character count, then the bytes
+	read := func(pos, length int) int {
+		v := 0
+		for i := pos; i < pos+length; i++ {
+			v = v<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return v
+	}
+	if read(0, 4) != 0x4 {
+		return nil, errors.New("not byte mode")
+	}
+	countBits := charCountBits(c.Version)
+	count := read(4, countBits)
+	if 4+countBits+count*8 > len(data)*8 {
+		return nil, errors.New("character count too large")
+	}
+	result := make([]byte, count)
+	for i := range result {
+		result[i] = byte(read(4+countBits+i*8, 8))
+	}
+	return result, nil
+}
+
+func module(c *Code, x, y int) int {
+	if c.Dark(x, y) {
+		return 1
+	}
+	return 0
+}